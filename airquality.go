@@ -0,0 +1,202 @@
+package weatherapi
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/airquality"
+	"github.com/TomSED/weather-api/pkg/openweathermap"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	// Air quality is refetched once it's older than this, unless SetAirQualityTTL says otherwise.
+	// Openweathermap updates concentrations hourly.
+	AIR_QUALITY_CACHE_SECONDS = 30 * 60
+	// Cached air quality up to this old is served when openweathermap can't be used
+	MAX_AIR_QUALITY_STALE_SECONDS = 3 * 60 * 60
+)
+
+// SetAirQualityTTL sets how long fetched air quality is served before it's refetched, AIR_QUALITY_CACHE_SECONDS by default
+func (ws *WeatherService) SetAirQualityTTL(ttl time.Duration) {
+	ws.airQualityTTL = ttl
+}
+
+// Concentrations are the pollutant concentrations in µg/m³ in the GetAirQuality api response
+type Concentrations struct {
+	PM25 float64 `json:"pm2_5"`
+	PM10 float64 `json:"pm10"`
+	O3   float64 `json:"o3"`
+	NO2  float64 `json:"no2"`
+	SO2  float64 `json:"so2"`
+	CO   float64 `json:"co"`
+}
+
+// AirQualityIndex is an index in the GetAirQuality api response
+type AirQualityIndex struct {
+	Value             int    `json:"value"`
+	Category          string `json:"category"`
+	DominantPollutant string `json:"dominant_pollutant"`
+}
+
+// GetAirQualityResponse is the struct for the GetAirQuality api response
+type GetAirQualityResponse struct {
+	City           string          `json:"city"`
+	Lat            float64         `json:"lat"`
+	Lon            float64         `json:"lon"`
+	MeasuredAt     time.Time       `json:"measured_at"`
+	FetchedAt      time.Time       `json:"fetched_at"`
+	Concentrations Concentrations  `json:"concentrations"`
+	USEPA          AirQualityIndex `json:"us_epa"`
+	CAQI           AirQualityIndex `json:"caqi"`
+}
+
+// GetAirQuality is the endpoint for retrieving a city's air quality (via query params city=sydney).
+// Concentrations come from openweathermap, the US EPA AQI and European CAQI are computed from them.
+func (ws *WeatherService) GetAirQuality(ctx context.Context, e events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	// Validate input
+	city, exist := e.QueryStringParameters["city"]
+	if !exist || city == "" {
+		if ws.logger != nil {
+			ws.logger.Errorf(`Missing e.QueryStringParameters["city"]: %v\n`, city)
+		}
+		return badRequest("Missing city in query parameter"), nil
+	}
+
+	// Try querying DB
+	cached, err := ws.store.GetAirQuality(ctx, city)
+	if err != nil {
+		ws.logStoreError("GetAirQuality", err)
+		cached = nil
+	}
+
+	airQuality := cached
+	if !ws.airQualityIsFresh(cached) {
+		airQuality, err = ws.fetchAirQuality(ctx, city, cached)
+		if err == nil {
+			// Update db
+			err = ws.store.InsertAirQuality(ctx, airQuality)
+			if err != nil {
+				// Non-blocking error, do not need to return a http error, just log error
+				ws.logStoreError("InsertAirQuality", err)
+			}
+		} else if airQualityServableWhenStale(cached) {
+			airQuality = cached
+		} else {
+			return internalServerError(), nil
+		}
+	}
+
+	// Marshal resp
+	byt, err := json.Marshal(mapAirQuality(airQuality))
+	if err != nil {
+		if ws.logger != nil {
+			ws.logger.Errorf("json.Marshal error: %v\n", err)
+		}
+		return internalServerError(), nil
+	}
+
+	return success(string(byt)), nil
+}
+
+// fetchAirQuality fetches the city's current concentrations, geocoding the city first unless it's been cached
+func (ws *WeatherService) fetchAirQuality(ctx context.Context, city string, cached *storage.AirQuality) (*storage.AirQuality, error) {
+	var lat, lon float64
+	if cached != nil {
+		lat, lon = cached.Lat, cached.Lon
+	} else {
+		location, err := ws.locate(ctx, city)
+		if err != nil {
+			return nil, err
+		}
+		lat, lon = location.Lat, location.Lon
+	}
+
+	err := ws.beforeCall(ctx, OpenWeatherMapSource)
+	if err == nil {
+		var resp *openweathermap.AirPollutionResponse
+		resp, err = ws.openWeatherMapClient.GetAirPollution(lat, lon)
+		if err == nil {
+			return mapOpenWeatherMapAirPollution(city, lat, lon, resp), nil
+		}
+	}
+	if ws.logger != nil {
+		ws.logger.Errorf("%s GetAirPollution error: %v\n", OpenWeatherMapSource, err)
+	}
+	return nil, err
+}
+
+// airQualityIsFresh reports whether the air quality was fetched within the air quality TTL
+func (ws *WeatherService) airQualityIsFresh(airQuality *storage.AirQuality) bool {
+	if airQuality == nil {
+		return false
+	}
+
+	ttl := ws.airQualityTTL
+	if ttl <= 0 {
+		ttl = AIR_QUALITY_CACHE_SECONDS * time.Second
+	}
+	return time.Now().UTC().Sub(airQuality.FetchedAt.UTC()) <= ttl
+}
+
+// airQualityServableWhenStale reports whether cached air quality can still be served when it can't be refreshed
+func airQualityServableWhenStale(airQuality *storage.AirQuality) bool {
+	if airQuality == nil {
+		return false
+	}
+	return time.Now().UTC().Sub(airQuality.FetchedAt.UTC()) <= MAX_AIR_QUALITY_STALE_SECONDS*time.Second
+}
+
+// mapAirQuality computes the indices from the stored concentrations
+func mapAirQuality(airQuality *storage.AirQuality) *GetAirQualityResponse {
+	concentrations := airquality.Concentrations{
+		PM25: airQuality.PM25,
+		PM10: airQuality.PM10,
+		O3:   airQuality.O3,
+		NO2:  airQuality.NO2,
+		SO2:  airQuality.SO2,
+		CO:   airQuality.CO,
+	}
+	epa := airquality.EPA(concentrations)
+	caqi := airquality.CAQI(concentrations)
+
+	return &GetAirQualityResponse{
+		City:       airQuality.City,
+		Lat:        airQuality.Lat,
+		Lon:        airQuality.Lon,
+		MeasuredAt: airQuality.MeasuredAt.UTC(),
+		FetchedAt:  airQuality.FetchedAt.UTC(),
+		Concentrations: Concentrations{
+			PM25: airQuality.PM25,
+			PM10: airQuality.PM10,
+			O3:   airQuality.O3,
+			NO2:  airQuality.NO2,
+			SO2:  airQuality.SO2,
+			CO:   airQuality.CO,
+		},
+		USEPA: AirQualityIndex{Value: epa.Value, Category: epa.Category, DominantPollutant: epa.Dominant},
+		CAQI:  AirQualityIndex{Value: caqi.Value, Category: caqi.Category, DominantPollutant: caqi.Dominant},
+	}
+}
+
+// mapOpenWeatherMapAirPollution normalizes the first (current) reading
+func mapOpenWeatherMapAirPollution(city string, lat float64, lon float64, resp *openweathermap.AirPollutionResponse) *storage.AirQuality {
+	reading := resp.List[0]
+	return &storage.AirQuality{
+		DataSource: OpenWeatherMapSource,
+		City:       city,
+		Lat:        lat,
+		Lon:        lon,
+		MeasuredAt: time.Unix(int64(reading.Dt), 0).UTC(),
+		FetchedAt:  time.Now().UTC(),
+		PM25:       reading.Components.Pm25,
+		PM10:       reading.Components.Pm10,
+		O3:         reading.Components.O3,
+		NO2:        reading.Components.No2,
+		SO2:        reading.Components.So2,
+		CO:         reading.Components.Co,
+	}
+}
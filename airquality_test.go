@@ -0,0 +1,239 @@
+package weatherapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	weatherapi "github.com/TomSED/weather-api"
+	"github.com/TomSED/weather-api/mocks"
+	"github.com/TomSED/weather-api/pkg/airquality"
+	"github.com/TomSED/weather-api/pkg/openweathermap"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeAirQualityResponse(t *testing.T, body string) *weatherapi.GetAirQualityResponse {
+	airQuality := &weatherapi.GetAirQualityResponse{}
+	err := json.Unmarshal([]byte(body), airQuality)
+	if !assert.Nil(t, err) {
+		t.Fatal(err)
+	}
+	return airQuality
+}
+
+// openWeatherMapAirPollution returns a clean air reading measured at measuredAt
+func openWeatherMapAirPollution(measuredAt time.Time) *openweathermap.AirPollutionResponse {
+	reading := openweathermap.AirPollutionReading{Dt: int(measuredAt.Unix())}
+	reading.Components.Pm25 = 8.5
+	reading.Components.Pm10 = 17.2
+	reading.Components.O3 = 60.1
+	reading.Components.No2 = 12.3
+	reading.Components.So2 = 1.5
+	reading.Components.Co = 230.3
+	return &openweathermap.AirPollutionResponse{List: []openweathermap.AirPollutionReading{reading}}
+}
+
+func TestGetAirQuality(t *testing.T) {
+
+	measuredAt := time.Now().UTC().Truncate(time.Hour)
+
+	t.Run("On a miss it should geocode the city, fetch its concentrations, store them and compute the indices", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetAirQualityFunc: func(ctx context.Context, city string) (*storage.AirQuality, error) {
+				return nil, nil
+			},
+			GetLocationFunc: func(ctx context.Context, city string) (*storage.Location, error) {
+				return nil, nil
+			},
+			InsertLocationFunc: func(ctx context.Context, location *storage.Location) error {
+				return nil
+			},
+			InsertAirQualityFunc: func(ctx context.Context, airQuality *storage.AirQuality) error {
+				return nil
+			},
+		}
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GeocodeFunc: func(city string) (*openweathermap.GeocodeResult, error) {
+				return &openweathermap.GeocodeResult{Name: "Sydney", Lat: -33.8698, Lon: 151.2083}, nil
+			},
+			GetAirPollutionFunc: func(lat float64, lon float64) (*openweathermap.AirPollutionResponse, error) {
+				return openWeatherMapAirPollution(measuredAt), nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.GetAirQuality(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		airQuality := decodeAirQualityResponse(t, resp.Body)
+		assert.Equal(t, "sydney", airQuality.City)
+		assert.Equal(t, -33.8698, airQuality.Lat)
+		assert.True(t, measuredAt.Equal(airQuality.MeasuredAt))
+		assert.Equal(t, 8.5, airQuality.Concentrations.PM25)
+		assert.Equal(t, weatherapi.AirQualityIndex{Value: 47, Category: airquality.EPAGood, DominantPollutant: airquality.PM25}, airQuality.USEPA)
+		assert.Equal(t, weatherapi.AirQualityIndex{Value: 25, Category: airquality.CAQILow, DominantPollutant: airquality.O3}, airQuality.CAQI)
+
+		if assert.Len(t, mockStore.InsertAirQualityCalls(), 1) {
+			stored := mockStore.InsertAirQualityCalls()[0].AirQuality
+			assert.Equal(t, weatherapi.OpenWeatherMapSource, stored.DataSource)
+			assert.Equal(t, 151.2083, stored.Lon)
+			assert.Equal(t, 230.3, stored.CO)
+		}
+		if assert.Len(t, mockOpenWeatherMapClient.GetAirPollutionCalls(), 1) {
+			assert.Equal(t, -33.8698, mockOpenWeatherMapClient.GetAirPollutionCalls()[0].Lat)
+		}
+		if assert.Len(t, mockStore.InsertLocationCalls(), 1) {
+			assert.Equal(t, "sydney", mockStore.InsertLocationCalls()[0].Location.City)
+		}
+	})
+
+	t.Run("On a fresh hit it should return the cached concentrations without calling openweathermap", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetAirQualityFunc: func(ctx context.Context, city string) (*storage.AirQuality, error) {
+				return &storage.AirQuality{City: city, MeasuredAt: measuredAt, FetchedAt: time.Now().UTC().Add(-time.Minute), PM25: 40}, nil
+			},
+		}
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.GetAirQuality(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, airquality.EPAUnhealthyForSensitiveGroups, decodeAirQualityResponse(t, resp.Body).USEPA.Category)
+		assert.Len(t, mockOpenWeatherMapClient.GetAirPollutionCalls(), 0)
+		assert.Len(t, mockStore.InsertAirQualityCalls(), 0)
+	})
+
+	t.Run("It should refetch after the TTL set, reusing the cached coordinates", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetAirQualityFunc: func(ctx context.Context, city string) (*storage.AirQuality, error) {
+				return &storage.AirQuality{City: city, Lat: -33.8698, Lon: 151.2083, FetchedAt: time.Now().UTC().Add(-10 * time.Minute)}, nil
+			},
+			InsertAirQualityFunc: func(ctx context.Context, airQuality *storage.AirQuality) error {
+				return nil
+			},
+		}
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetAirPollutionFunc: func(lat float64, lon float64) (*openweathermap.AirPollutionResponse, error) {
+				return openWeatherMapAirPollution(measuredAt), nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, mockStore)
+		mockWeatherService.SetAirQualityTTL(5 * time.Minute)
+
+		resp, err := mockWeatherService.GetAirQuality(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Len(t, mockOpenWeatherMapClient.GeocodeCalls(), 0)
+		if assert.Len(t, mockOpenWeatherMapClient.GetAirPollutionCalls(), 1) {
+			assert.Equal(t, 151.2083, mockOpenWeatherMapClient.GetAirPollutionCalls()[0].Lon)
+		}
+		assert.Len(t, mockStore.InsertAirQualityCalls(), 1)
+	})
+
+	t.Run("When openweathermap fails it should serve cached concentrations up to MAX_AIR_QUALITY_STALE_SECONDS old", func(t *testing.T) {
+		fetchedAt := time.Now().UTC().Add(-time.Hour)
+		mockStore := &mocks.StoreMock{
+			GetAirQualityFunc: func(ctx context.Context, city string) (*storage.AirQuality, error) {
+				return &storage.AirQuality{City: city, Lat: -33.8698, Lon: 151.2083, FetchedAt: fetchedAt}, nil
+			},
+		}
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetAirPollutionFunc: func(lat float64, lon float64) (*openweathermap.AirPollutionResponse, error) {
+				return nil, errors.New("Response status code: 500")
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.GetAirQuality(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Len(t, mockStore.InsertAirQualityCalls(), 0)
+
+		fetchedAt = time.Now().UTC().Add(-4 * time.Hour)
+		resp, err = mockWeatherService.GetAirQuality(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 500, resp.StatusCode)
+	})
+
+	t.Run("It should return a server error when the city can't be geocoded", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetAirQualityFunc: func(ctx context.Context, city string) (*storage.AirQuality, error) {
+				return nil, nil
+			},
+			GetLocationFunc: func(ctx context.Context, city string) (*storage.Location, error) {
+				return nil, nil
+			},
+			InsertLocationFunc: func(ctx context.Context, location *storage.Location) error {
+				return nil
+			},
+		}
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GeocodeFunc: func(city string) (*openweathermap.GeocodeResult, error) {
+				return nil, errors.New("No location found for atlantis")
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.GetAirQuality(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "atlantis",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 500, resp.StatusCode)
+		assert.Len(t, mockOpenWeatherMapClient.GetAirPollutionCalls(), 0)
+	})
+
+	t.Run("It should return a bad request without a city", func(t *testing.T) {
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, &mocks.StoreMock{})
+
+		resp, err := mockWeatherService.GetAirQuality(context.Background(), events.APIGatewayProxyRequest{})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+}
@@ -0,0 +1,456 @@
+package weatherapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/nws"
+	"github.com/TomSED/weather-api/pkg/openweathermap"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	NWSSource = "nws"
+
+	// Alerts are refetched once they're older than this
+	ALERTS_CACHE_SECONDS = 5 * 60
+	// Cached alerts up to this old are served when no provider can be used
+	MAX_ALERTS_STALE_SECONDS = 30 * 60
+
+	// Alerts from different sources are the same alert when their events match and their windows start and end this close
+	alertMatchWindow = time.Hour
+)
+
+// SetNWSClient sets the US national weather service client, whose alerts are aggregated with openweathermap's
+func (ws *WeatherService) SetNWSClient(client NWSClient) {
+	ws.nwsClient = client
+}
+
+// alertSource is a provider queried for the alerts in effect at a point
+type alertSource struct {
+	name  string
+	fetch func(ctx context.Context, lat float64, lon float64) ([]storage.Alert, error)
+}
+
+// AlertsLocation is where the alerts in the GetAlerts api response apply
+type AlertsLocation struct {
+	City string  `json:"city,omitempty"`
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+}
+
+// Alert is an alert in the GetAlerts api response
+type Alert struct {
+	Sources     []string `json:"sources"`
+	Sender      string   `json:"sender,omitempty"`
+	Event       string   `json:"event"`
+	Headline    string   `json:"headline,omitempty"`
+	Description string   `json:"description,omitempty"`
+	// Severity is extreme, severe, moderate, minor or unknown
+	Severity string `json:"severity"`
+	// Urgency is immediate, expected, future, past or unknown
+	Urgency string    `json:"urgency"`
+	Start   time.Time `json:"start"`
+	// End is null when the alert is in effect until further notice
+	End *time.Time `json:"end"`
+}
+
+// GetAlertsResponse is the struct for the GetAlerts api response
+type GetAlertsResponse struct {
+	Location  AlertsLocation `json:"location"`
+	FetchedAt time.Time      `json:"fetched_at"`
+	Alerts    []Alert        `json:"alerts"`
+}
+
+// GetAlerts is the endpoint for retrieving the severe weather alerts in effect at a city (via query params city=sydney)
+// or a point (lat=-33.87&lon=151.21). Alerts from every provider that has them are normalized and de-duplicated.
+func (ws *WeatherService) GetAlerts(ctx context.Context, e events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	// Validate input
+	city := e.QueryStringParameters["city"]
+	var lat, lon float64
+	var key string
+	if city != "" {
+		key = "city:" + strings.ToLower(city)
+	} else {
+		var err error
+		lat, lon, err = parsePoint(e.QueryStringParameters["lat"], e.QueryStringParameters["lon"])
+		if err != nil {
+			if ws.logger != nil {
+				ws.logger.Errorf("parsePoint error: %v\n", err)
+			}
+			return badRequest(err.Error()), nil
+		}
+		key = fmt.Sprintf("point:%.2f,%.2f", lat, lon)
+	}
+
+	// Try querying DB
+	cached, err := ws.store.GetAlerts(ctx, key)
+	if err != nil {
+		ws.logStoreError("GetAlerts", err)
+		cached = nil
+	}
+
+	alerts := cached
+	if !alertsAreFresh(cached) {
+		alerts, err = ws.fetchAlerts(ctx, key, city, lat, lon, cached)
+		if err == nil {
+			// Update db
+			err = ws.store.InsertAlerts(ctx, alerts)
+			if err != nil {
+				// Non-blocking error, do not need to return a http error, just log error
+				ws.logStoreError("InsertAlerts", err)
+			}
+		} else if alertsServableWhenStale(cached) {
+			alerts = cached
+		} else {
+			return internalServerError(), nil
+		}
+	}
+
+	// Marshal resp
+	byt, err := json.Marshal(mapAlerts(alerts, city, time.Now().UTC()))
+	if err != nil {
+		if ws.logger != nil {
+			ws.logger.Errorf("json.Marshal error: %v\n", err)
+		}
+		return internalServerError(), nil
+	}
+
+	return success(string(byt)), nil
+}
+
+// parsePoint parses and validates coordinates
+func parsePoint(latParam string, lonParam string) (float64, float64, error) {
+	if latParam == "" || lonParam == "" {
+		return 0, 0, fmt.Errorf("Missing city or lat and lon in query parameter")
+	}
+	lat, err := strconv.ParseFloat(latParam, 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, fmt.Errorf("lat must be between -90 and 90")
+	}
+	lon, err := strconv.ParseFloat(lonParam, 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return 0, 0, fmt.Errorf("lon must be between -180 and 180")
+	}
+	return lat, lon, nil
+}
+
+// fetchAlerts queries every alert source concurrently, geocoding the city first unless it's been cached.
+// Sources that fail are left out, it's only an error if they all do.
+func (ws *WeatherService) fetchAlerts(ctx context.Context, key string, city string, lat float64, lon float64, cached *storage.Alerts) (*storage.Alerts, error) {
+	if city != "" {
+		if cached != nil {
+			lat, lon = cached.Lat, cached.Lon
+		} else {
+			location, err := ws.locate(ctx, city)
+			if err != nil {
+				return nil, err
+			}
+			lat, lon = location.Lat, location.Lon
+		}
+	}
+
+	sources := ws.alertSources(lat, lon)
+	results := make([][]storage.Alert, len(sources))
+	errs := make([]error, len(sources))
+
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source alertSource) {
+			defer wg.Done()
+			results[i], errs[i] = source.fetch(ctx, lat, lon)
+		}(i, source)
+	}
+	wg.Wait()
+
+	out := &storage.Alerts{
+		Key:       key,
+		Lat:       lat,
+		Lon:       lon,
+		FetchedAt: time.Now().UTC(),
+	}
+	var err error
+	succeeded := 0
+	for i, source := range sources {
+		if errs[i] != nil {
+			err = errs[i]
+			if ws.logger != nil {
+				ws.logger.Errorf("%s GetAlerts error: %v\n", source.name, errs[i])
+			}
+			continue
+		}
+		succeeded++
+		out.Alerts = append(out.Alerts, results[i]...)
+	}
+	if succeeded == 0 {
+		return nil, err
+	}
+
+	out.Alerts = mergeAlerts(out.Alerts)
+	return out, nil
+}
+
+// alertSources returns the providers with alerts for the point. The NWS is free and unmetered, and only queried for
+// points it covers.
+func (ws *WeatherService) alertSources(lat float64, lon float64) []alertSource {
+	sources := []alertSource{
+		{
+			name: OpenWeatherMapSource,
+			fetch: func(ctx context.Context, lat float64, lon float64) ([]storage.Alert, error) {
+				err := ws.beforeCall(ctx, OpenWeatherMapSource)
+				if err != nil {
+					return nil, err
+				}
+				resp, err := ws.openWeatherMapClient.GetAlerts(lat, lon)
+				if err != nil {
+					return nil, err
+				}
+				return mapOpenWeatherMapAlerts(resp, time.Now().UTC()), nil
+			},
+		},
+	}
+
+	if ws.nwsClient != nil && nwsCovers(lat, lon) {
+		sources = append(sources, alertSource{
+			name: NWSSource,
+			fetch: func(ctx context.Context, lat float64, lon float64) ([]storage.Alert, error) {
+				resp, err := ws.nwsClient.GetActiveAlerts(lat, lon)
+				if err != nil {
+					return nil, err
+				}
+				return mapNWSAlerts(resp), nil
+			},
+		})
+	}
+
+	return sources
+}
+
+// nwsCovers reports whether the point is roughly in the US and its territories around the Caribbean and Pacific
+func nwsCovers(lat float64, lon float64) bool {
+	return lat >= 13 && lat <= 72 && lon >= -180 && lon <= -64
+}
+
+// alertsAreFresh reports whether the alerts were fetched within ALERTS_CACHE_SECONDS
+func alertsAreFresh(alerts *storage.Alerts) bool {
+	if alerts == nil {
+		return false
+	}
+	return time.Now().UTC().Sub(alerts.FetchedAt.UTC()) <= ALERTS_CACHE_SECONDS*time.Second
+}
+
+// alertsServableWhenStale reports whether cached alerts can still be served when they can't be refreshed
+func alertsServableWhenStale(alerts *storage.Alerts) bool {
+	if alerts == nil {
+		return false
+	}
+	return time.Now().UTC().Sub(alerts.FetchedAt.UTC()) <= MAX_ALERTS_STALE_SECONDS*time.Second
+}
+
+// mapAlerts returns the alerts that haven't ended by now
+func mapAlerts(alerts *storage.Alerts, city string, now time.Time) *GetAlertsResponse {
+	out := &GetAlertsResponse{
+		Location:  AlertsLocation{City: city, Lat: alerts.Lat, Lon: alerts.Lon},
+		FetchedAt: alerts.FetchedAt.UTC(),
+		Alerts:    []Alert{},
+	}
+
+	for _, alert := range alerts.Alerts {
+		if !alert.End.IsZero() && alert.End.Before(now) {
+			continue
+		}
+
+		var end *time.Time
+		if !alert.End.IsZero() {
+			alertEnd := alert.End.UTC()
+			end = &alertEnd
+		}
+		out.Alerts = append(out.Alerts, Alert{
+			Sources:     alert.Sources,
+			Sender:      alert.Sender,
+			Event:       alert.Event,
+			Headline:    alert.Headline,
+			Description: alert.Description,
+			Severity:    alert.Severity,
+			Urgency:     alert.Urgency,
+			Start:       alert.Start.UTC(),
+			End:         end,
+		})
+	}
+	return out
+}
+
+// mapOpenWeatherMapAlerts normalizes One Call alerts. They have no severity, and their urgency is judged by whether
+// the alert has started.
+func mapOpenWeatherMapAlerts(resp *openweathermap.AlertsResponse, now time.Time) []storage.Alert {
+	out := []storage.Alert{}
+	for _, alert := range resp.Alerts {
+		normalized := storage.Alert{
+			Sources:     []string{OpenWeatherMapSource},
+			Sender:      alert.SenderName,
+			Event:       alert.Event,
+			Description: alert.Description,
+			Severity:    storage.SeverityUnknown,
+		}
+		if alert.Start > 0 {
+			normalized.Start = time.Unix(int64(alert.Start), 0).UTC()
+		}
+		if alert.End > 0 {
+			normalized.End = time.Unix(int64(alert.End), 0).UTC()
+		}
+
+		switch {
+		case !normalized.End.IsZero() && normalized.End.Before(now):
+			normalized.Urgency = storage.UrgencyPast
+		case normalized.Start.After(now):
+			normalized.Urgency = storage.UrgencyFuture
+		default:
+			normalized.Urgency = storage.UrgencyImmediate
+		}
+		out = append(out, normalized)
+	}
+	return out
+}
+
+// mapNWSAlerts normalizes the CAP alerts, leaving out tests, exercises and cancellations.
+// An alert's window runs from its onset (or when it took effect) until it ends (or expires).
+func mapNWSAlerts(resp *nws.AlertsResponse) []storage.Alert {
+	out := []storage.Alert{}
+	for _, feature := range resp.Features {
+		alert := feature.Properties
+		if alert.Status != "Actual" || alert.MessageType == "Cancel" {
+			continue
+		}
+
+		normalized := storage.Alert{
+			Sources:     []string{NWSSource},
+			Sender:      alert.SenderName,
+			Event:       alert.Event,
+			Headline:    alert.Headline,
+			Description: alert.Description,
+			Severity:    normalizeCAPValue(alert.Severity, storage.SeverityExtreme, storage.SeveritySevere, storage.SeverityModerate, storage.SeverityMinor),
+			Urgency:     normalizeCAPValue(alert.Urgency, storage.UrgencyImmediate, storage.UrgencyExpected, storage.UrgencyFuture, storage.UrgencyPast),
+		}
+		for _, start := range []*time.Time{alert.Onset, alert.Effective, alert.Sent} {
+			if start != nil {
+				normalized.Start = start.UTC()
+				break
+			}
+		}
+		for _, end := range []*time.Time{alert.Ends, alert.Expires} {
+			if end != nil {
+				normalized.End = end.UTC()
+				break
+			}
+		}
+		out = append(out, normalized)
+	}
+	return out
+}
+
+// normalizeCAPValue lower cases a CAP severity or urgency, unknown unless it's one of the known values
+func normalizeCAPValue(value string, known ...string) string {
+	value = strings.ToLower(value)
+	for _, k := range known {
+		if value == k {
+			return value
+		}
+	}
+	return storage.SeverityUnknown
+}
+
+// severityRanks orders severities from the least specific
+var severityRanks = map[string]int{
+	storage.SeverityUnknown:  0,
+	storage.SeverityMinor:    1,
+	storage.SeverityModerate: 2,
+	storage.SeveritySevere:   3,
+	storage.SeverityExtreme:  4,
+}
+
+// mergeAlerts de-duplicates alerts reported by several sources, e.g. openweathermap relays the NWS's alerts in the US.
+// Alerts for the same event whose windows start and end within alertMatchWindow of each other are merged, keeping the
+// known severity and urgency, the longest text and each source once. The result is ordered by start, most severe first.
+func mergeAlerts(alerts []storage.Alert) []storage.Alert {
+	out := []storage.Alert{}
+	for _, alert := range alerts {
+		merged := false
+		for i := range out {
+			if !sameAlert(out[i], alert) {
+				continue
+			}
+
+			out[i].Sources = appendMissing(out[i].Sources, alert.Sources...)
+			if severityRanks[alert.Severity] > severityRanks[out[i].Severity] {
+				out[i].Severity = alert.Severity
+			}
+			if out[i].Urgency == storage.UrgencyUnknown {
+				out[i].Urgency = alert.Urgency
+			}
+			out[i].Sender = longer(out[i].Sender, alert.Sender)
+			out[i].Headline = longer(out[i].Headline, alert.Headline)
+			out[i].Description = longer(out[i].Description, alert.Description)
+			merged = true
+			break
+		}
+		if !merged {
+			out = append(out, alert)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].Start.Equal(out[j].Start) {
+			return out[i].Start.Before(out[j].Start)
+		}
+		return severityRanks[out[i].Severity] > severityRanks[out[j].Severity]
+	})
+	return out
+}
+
+func sameAlert(a storage.Alert, b storage.Alert) bool {
+	if !strings.EqualFold(strings.TrimSpace(a.Event), strings.TrimSpace(b.Event)) {
+		return false
+	}
+	if absDuration(a.Start.Sub(b.Start)) > alertMatchWindow {
+		return false
+	}
+	// Until further notice matches any end
+	if a.End.IsZero() || b.End.IsZero() {
+		return true
+	}
+	return absDuration(a.End.Sub(b.End)) <= alertMatchWindow
+}
+
+// appendMissing appends the values not already in values to a copy of it
+func appendMissing(values []string, more ...string) []string {
+	out := append([]string(nil), values...)
+	for _, value := range more {
+		missing := true
+		for _, existing := range out {
+			if existing == value {
+				missing = false
+				break
+			}
+		}
+		if missing {
+			out = append(out, value)
+		}
+	}
+	return out
+}
+
+func longer(a string, b string) string {
+	if len(b) > len(a) {
+		return b
+	}
+	return a
+}
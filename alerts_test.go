@@ -0,0 +1,427 @@
+package weatherapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	weatherapi "github.com/TomSED/weather-api"
+	"github.com/TomSED/weather-api/mocks"
+	"github.com/TomSED/weather-api/pkg/nws"
+	"github.com/TomSED/weather-api/pkg/openweathermap"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeAlertsResponse(t *testing.T, body string) *weatherapi.GetAlertsResponse {
+	alerts := &weatherapi.GetAlertsResponse{}
+	err := json.Unmarshal([]byte(body), alerts)
+	if !assert.Nil(t, err) {
+		t.Fatal(err)
+	}
+	return alerts
+}
+
+// openWeatherMapAlerts returns a One Call response with an alert for event between start and end
+func openWeatherMapAlerts(t *testing.T, event string, start time.Time, end time.Time) *openweathermap.AlertsResponse {
+	resp := &openweathermap.AlertsResponse{}
+	err := json.Unmarshal([]byte(fmt.Sprintf(`{
+		"lat": 40.7128,
+		"lon": -74.006,
+		"alerts": [{
+			"sender_name": "NWS New York City",
+			"event": %q,
+			"start": %d,
+			"end": %d,
+			"description": "Wind gusts up to 60 mph"
+		}]
+	}`, event, start.Unix(), end.Unix())), resp)
+	if !assert.Nil(t, err) {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// nwsAlerts returns an active alert for event between onset and ends
+func nwsAlerts(t *testing.T, event string, onset time.Time, ends time.Time) *nws.AlertsResponse {
+	resp := &nws.AlertsResponse{}
+	err := json.Unmarshal([]byte(fmt.Sprintf(`{
+		"features": [{
+			"id": "urn:oid:2.49.0.1.840.0.1",
+			"properties": {
+				"sent": %q,
+				"onset": %q,
+				"ends": %q,
+				"status": "Actual",
+				"messageType": "Alert",
+				"severity": "Severe",
+				"urgency": "Expected",
+				"event": %q,
+				"senderName": "NWS New York NY",
+				"headline": "High Wind Warning issued by NWS New York NY",
+				"description": "West winds 30 to 40 mph with gusts up to 60 mph expected."
+			}
+		}, {
+			"id": "urn:oid:2.49.0.1.840.0.2",
+			"properties": {
+				"onset": %q,
+				"status": "Test",
+				"messageType": "Alert",
+				"event": "Test Message"
+			}
+		}]
+	}`, onset.Add(-time.Hour).Format(time.RFC3339), onset.Format(time.RFC3339), ends.Format(time.RFC3339), event, onset.Format(time.RFC3339))), resp)
+	if !assert.Nil(t, err) {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestGetAlerts(t *testing.T) {
+
+	start := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+	end := start.Add(12 * time.Hour)
+
+	t.Run("On a miss it should geocode the city, merge the same alert from both sources and store it", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLocationFunc: func(ctx context.Context, city string) (*storage.Location, error) {
+				return nil, nil
+			},
+			InsertLocationFunc: func(ctx context.Context, location *storage.Location) error {
+				return nil
+			},
+			GetAlertsFunc: func(ctx context.Context, key string) (*storage.Alerts, error) {
+				return nil, nil
+			},
+			InsertAlertsFunc: func(ctx context.Context, alerts *storage.Alerts) error {
+				return nil
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GeocodeFunc: func(city string) (*openweathermap.GeocodeResult, error) {
+				return &openweathermap.GeocodeResult{Name: "New York", Lat: 40.7128, Lon: -74.006}, nil
+			},
+			GetAlertsFunc: func(lat float64, lon float64) (*openweathermap.AlertsResponse, error) {
+				// openweathermap relays the NWS alert, its times rounded
+				return openWeatherMapAlerts(t, "High Wind Warning", start.Add(10*time.Minute), end.Add(-10*time.Minute)), nil
+			},
+		}
+		mockNWSClient := &mocks.NWSClientMock{
+			GetActiveAlertsFunc: func(lat float64, lon float64) (*nws.AlertsResponse, error) {
+				return nwsAlerts(t, "High Wind Warning", start, end), nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, mockStore)
+		mockWeatherService.SetNWSClient(mockNWSClient)
+
+		resp, err := mockWeatherService.GetAlerts(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "New York",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		alerts := decodeAlertsResponse(t, resp.Body)
+		assert.Equal(t, "New York", alerts.Location.City)
+		assert.Equal(t, 40.7128, alerts.Location.Lat)
+		if assert.Len(t, alerts.Alerts, 1) {
+			alert := alerts.Alerts[0]
+			assert.Equal(t, []string{weatherapi.OpenWeatherMapSource, weatherapi.NWSSource}, alert.Sources)
+			assert.Equal(t, "High Wind Warning", alert.Event)
+			assert.Equal(t, storage.SeveritySevere, alert.Severity)
+			assert.Equal(t, storage.UrgencyImmediate, alert.Urgency)
+			assert.Equal(t, "High Wind Warning issued by NWS New York NY", alert.Headline)
+			assert.Equal(t, "West winds 30 to 40 mph with gusts up to 60 mph expected.", alert.Description)
+			assert.True(t, start.Add(10*time.Minute).Equal(alert.Start))
+			if assert.NotNil(t, alert.End) {
+				assert.True(t, end.Add(-10*time.Minute).Equal(*alert.End))
+			}
+		}
+
+		if assert.Len(t, mockStore.InsertAlertsCalls(), 1) {
+			stored := mockStore.InsertAlertsCalls()[0].Alerts
+			assert.Equal(t, "city:new york", stored.Key)
+			assert.Equal(t, -74.006, stored.Lon)
+			assert.Len(t, stored.Alerts, 1)
+		}
+		if assert.Len(t, mockNWSClient.GetActiveAlertsCalls(), 1) {
+			assert.Equal(t, 40.7128, mockNWSClient.GetActiveAlertsCalls()[0].Lat)
+		}
+	})
+
+	t.Run("It should list each source of a merged alert once", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLocationFunc: func(ctx context.Context, city string) (*storage.Location, error) {
+				return &storage.Location{City: "new york", Name: "New York", Lat: 40.7128, Lon: -74.006}, nil
+			},
+			GetAlertsFunc: func(ctx context.Context, key string) (*storage.Alerts, error) {
+				return nil, nil
+			},
+			InsertAlertsFunc: func(ctx context.Context, alerts *storage.Alerts) error {
+				return nil
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetAlertsFunc: func(lat float64, lon float64) (*openweathermap.AlertsResponse, error) {
+				// openweathermap relays the warning from two NWS offices
+				resp := openWeatherMapAlerts(t, "High Wind Warning", start, end)
+				resp.Alerts = append(resp.Alerts, resp.Alerts[0])
+				resp.Alerts[1].SenderName = "NWS Upton NY"
+				return resp, nil
+			},
+		}
+		mockNWSClient := &mocks.NWSClientMock{
+			GetActiveAlertsFunc: func(lat float64, lon float64) (*nws.AlertsResponse, error) {
+				return nwsAlerts(t, "High Wind Warning", start, end), nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, mockStore)
+		mockWeatherService.SetNWSClient(mockNWSClient)
+
+		resp, err := mockWeatherService.GetAlerts(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "New York",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		alerts := decodeAlertsResponse(t, resp.Body)
+		if assert.Len(t, alerts.Alerts, 1) {
+			assert.Equal(t, []string{weatherapi.OpenWeatherMapSource, weatherapi.NWSSource}, alerts.Alerts[0].Sources)
+		}
+	})
+
+	t.Run("It should keep different alerts apart and drop the ones that have ended", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetAlertsFunc: func(ctx context.Context, key string) (*storage.Alerts, error) {
+				return nil, nil
+			},
+			InsertAlertsFunc: func(ctx context.Context, alerts *storage.Alerts) error {
+				return nil
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetAlertsFunc: func(lat float64, lon float64) (*openweathermap.AlertsResponse, error) {
+				return openWeatherMapAlerts(t, "Coastal Flood Advisory", start.Add(-6*time.Hour), start.Add(-2*time.Hour)), nil
+			},
+		}
+		mockNWSClient := &mocks.NWSClientMock{
+			GetActiveAlertsFunc: func(lat float64, lon float64) (*nws.AlertsResponse, error) {
+				return nwsAlerts(t, "High Wind Warning", start, end), nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, mockStore)
+		mockWeatherService.SetNWSClient(mockNWSClient)
+
+		resp, err := mockWeatherService.GetAlerts(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"lat": "40.7128",
+				"lon": "-74.006",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		alerts := decodeAlertsResponse(t, resp.Body)
+		assert.Equal(t, "", alerts.Location.City)
+		if assert.Len(t, alerts.Alerts, 1) {
+			assert.Equal(t, []string{weatherapi.NWSSource}, alerts.Alerts[0].Sources)
+		}
+
+		if assert.Len(t, mockStore.InsertAlertsCalls(), 1) {
+			stored := mockStore.InsertAlertsCalls()[0].Alerts
+			assert.Equal(t, "point:40.71,-74.01", stored.Key)
+			if assert.Len(t, stored.Alerts, 2) {
+				assert.Equal(t, storage.UrgencyPast, stored.Alerts[0].Urgency)
+			}
+		}
+		assert.Len(t, mockOpenWeatherMapClient.GeocodeCalls(), 0)
+	})
+
+	t.Run("It should only ask the NWS about points it covers", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetAlertsFunc: func(ctx context.Context, key string) (*storage.Alerts, error) {
+				return nil, nil
+			},
+			InsertAlertsFunc: func(ctx context.Context, alerts *storage.Alerts) error {
+				return nil
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetAlertsFunc: func(lat float64, lon float64) (*openweathermap.AlertsResponse, error) {
+				return &openweathermap.AlertsResponse{Lat: lat, Lon: lon}, nil
+			},
+		}
+		mockNWSClient := &mocks.NWSClientMock{}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, mockStore)
+		mockWeatherService.SetNWSClient(mockNWSClient)
+
+		resp, err := mockWeatherService.GetAlerts(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"lat": "-33.8698",
+				"lon": "151.2083",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Len(t, decodeAlertsResponse(t, resp.Body).Alerts, 0)
+		assert.Len(t, mockNWSClient.GetActiveAlertsCalls(), 0)
+	})
+
+	t.Run("On a fresh hit it should return the cached alerts without calling providers", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetAlertsFunc: func(ctx context.Context, key string) (*storage.Alerts, error) {
+				return &storage.Alerts{
+					Key:       key,
+					Lat:       40.7128,
+					Lon:       -74.006,
+					FetchedAt: time.Now().UTC().Add(-time.Minute),
+					Alerts: []storage.Alert{
+						{Sources: []string{weatherapi.NWSSource}, Event: "High Wind Warning", Severity: storage.SeveritySevere, Urgency: storage.UrgencyExpected, Start: start, End: end},
+					},
+				}, nil
+			},
+		}
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{}
+		mockNWSClient := &mocks.NWSClientMock{}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, mockStore)
+		mockWeatherService.SetNWSClient(mockNWSClient)
+
+		resp, err := mockWeatherService.GetAlerts(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "New York",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Len(t, decodeAlertsResponse(t, resp.Body).Alerts, 1)
+		assert.Len(t, mockOpenWeatherMapClient.GetAlertsCalls(), 0)
+		assert.Len(t, mockNWSClient.GetActiveAlertsCalls(), 0)
+		assert.Len(t, mockStore.InsertAlertsCalls(), 0)
+	})
+
+	t.Run("On a stale hit it should reuse the cached coordinates and carry on when one source fails", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetAlertsFunc: func(ctx context.Context, key string) (*storage.Alerts, error) {
+				return &storage.Alerts{
+					Key:       key,
+					Lat:       40.7128,
+					Lon:       -74.006,
+					FetchedAt: time.Now().UTC().Add(-time.Hour),
+				}, nil
+			},
+			InsertAlertsFunc: func(ctx context.Context, alerts *storage.Alerts) error {
+				return nil
+			},
+		}
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetAlertsFunc: func(lat float64, lon float64) (*openweathermap.AlertsResponse, error) {
+				return nil, errors.New("Unexpected response code: 401")
+			},
+		}
+		mockNWSClient := &mocks.NWSClientMock{
+			GetActiveAlertsFunc: func(lat float64, lon float64) (*nws.AlertsResponse, error) {
+				return nwsAlerts(t, "High Wind Warning", start, end), nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, mockStore)
+		mockWeatherService.SetNWSClient(mockNWSClient)
+
+		resp, err := mockWeatherService.GetAlerts(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "New York",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Len(t, decodeAlertsResponse(t, resp.Body).Alerts, 1)
+		assert.Len(t, mockOpenWeatherMapClient.GeocodeCalls(), 0)
+		assert.Len(t, mockStore.InsertAlertsCalls(), 1)
+	})
+
+	t.Run("When every source fails it should serve cached alerts up to MAX_ALERTS_STALE_SECONDS old", func(t *testing.T) {
+		fetchedAt := time.Now().UTC().Add(-20 * time.Minute)
+		mockStore := &mocks.StoreMock{
+			GetAlertsFunc: func(ctx context.Context, key string) (*storage.Alerts, error) {
+				return &storage.Alerts{Key: key, Lat: 40.7128, Lon: -74.006, FetchedAt: fetchedAt}, nil
+			},
+		}
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetAlertsFunc: func(lat float64, lon float64) (*openweathermap.AlertsResponse, error) {
+				return nil, errors.New("Unexpected response code: 500")
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.GetAlerts(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "New York",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.True(t, fetchedAt.Truncate(time.Second).Equal(decodeAlertsResponse(t, resp.Body).FetchedAt.Truncate(time.Second)))
+		assert.Len(t, mockStore.InsertAlertsCalls(), 0)
+
+		fetchedAt = time.Now().UTC().Add(-time.Hour)
+		resp, err = mockWeatherService.GetAlerts(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "New York",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 500, resp.StatusCode)
+	})
+
+	t.Run("It should return a bad request without a city or valid coordinates", func(t *testing.T) {
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, &mocks.StoreMock{})
+
+		for _, params := range []map[string]string{
+			{},
+			{"lat": "40.7128"},
+			{"lat": "91", "lon": "0"},
+			{"lat": "0", "lon": "east"},
+		} {
+			resp, err := mockWeatherService.GetAlerts(context.Background(), events.APIGatewayProxyRequest{
+				QueryStringParameters: params,
+			})
+			if !assert.Nil(t, err) {
+				t.Fatal(err)
+			}
+			assert.Equal(t, 400, resp.StatusCode)
+		}
+	})
+}
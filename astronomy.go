@@ -0,0 +1,131 @@
+package weatherapi
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/astro"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// dateFormat is the format of the date query param
+const dateFormat = "2006-01-02"
+
+// MoonPhase is the moon's phase in the GetAstronomy api response
+type MoonPhase struct {
+	// Phase runs from 0 (new) through 0.5 (full) back towards 1
+	Phase        float64 `json:"phase"`
+	PhaseName    string  `json:"phase_name"`
+	Illumination float64 `json:"illumination"`
+	AgeDays      float64 `json:"age_days"`
+}
+
+// GetAstronomyResponse is the struct for the GetAstronomy api response. Events that don't happen that day, e.g. sunrise
+// in the polar night, are null.
+type GetAstronomyResponse struct {
+	City             string     `json:"city,omitempty"`
+	Lat              float64    `json:"lat"`
+	Lon              float64    `json:"lon"`
+	Date             string     `json:"date"`
+	Sunrise          *time.Time `json:"sunrise"`
+	Sunset           *time.Time `json:"sunset"`
+	SolarNoon        time.Time  `json:"solar_noon"`
+	CivilDawn        *time.Time `json:"civil_dawn"`
+	CivilDusk        *time.Time `json:"civil_dusk"`
+	DayLengthSeconds int        `json:"day_length_seconds"`
+	Moon             MoonPhase  `json:"moon"`
+}
+
+// GetAstronomy is the endpoint for retrieving the sun's events and the moon's phase at a city (via query params
+// city=sydney) or a point (lat=-33.87&lon=151.21) on a local date (date=2021-05-16, today by default).
+// They're computed locally, only a city that hasn't been located before needs a provider call.
+func (ws *WeatherService) GetAstronomy(ctx context.Context, e events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	// Validate input
+	city := e.QueryStringParameters["city"]
+	var lat, lon float64
+	if city == "" {
+		var err error
+		lat, lon, err = parsePoint(e.QueryStringParameters["lat"], e.QueryStringParameters["lon"])
+		if err != nil {
+			if ws.logger != nil {
+				ws.logger.Errorf("parsePoint error: %v\n", err)
+			}
+			return badRequest(err.Error()), nil
+		}
+	}
+
+	var date time.Time
+	if param := e.QueryStringParameters["date"]; param != "" {
+		var err error
+		date, err = time.Parse(dateFormat, param)
+		if err != nil {
+			return badRequest("date must be formatted as YYYY-MM-DD"), nil
+		}
+	}
+
+	if city != "" {
+		location, err := ws.locate(ctx, city)
+		if err != nil {
+			return internalServerError(), nil
+		}
+		lat, lon = location.Lat, location.Lon
+	}
+
+	if date.IsZero() {
+		// Today in local mean solar time
+		date = time.Now().UTC().Add(time.Duration(lon / 15 * float64(time.Hour)))
+	}
+
+	// Marshal resp
+	byt, err := json.Marshal(mapAstronomy(city, lat, lon, date))
+	if err != nil {
+		if ws.logger != nil {
+			ws.logger.Errorf("json.Marshal error: %v\n", err)
+		}
+		return internalServerError(), nil
+	}
+
+	return success(string(byt)), nil
+}
+
+// mapAstronomy computes the sun's events on the date, and the moon's phase at solar noon
+func mapAstronomy(city string, lat float64, lon float64, date time.Time) *GetAstronomyResponse {
+	sun := astro.Sun(date, lat, lon)
+	moon := astro.Moon(sun.SolarNoon)
+
+	return &GetAstronomyResponse{
+		City:             city,
+		Lat:              lat,
+		Lon:              lon,
+		Date:             date.Format(dateFormat),
+		Sunrise:          optionalTime(sun.Sunrise),
+		Sunset:           optionalTime(sun.Sunset),
+		SolarNoon:        sun.SolarNoon.UTC(),
+		CivilDawn:        optionalTime(sun.CivilDawn),
+		CivilDusk:        optionalTime(sun.CivilDusk),
+		DayLengthSeconds: int(sun.DayLength.Seconds()),
+		Moon: MoonPhase{
+			Phase:        roundTo(moon.Phase, 3),
+			PhaseName:    moon.Name,
+			Illumination: roundTo(moon.Illumination, 3),
+			AgeDays:      roundTo(moon.Age, 1),
+		},
+	}
+}
+
+// optionalTime returns nil for the zero time
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+func roundTo(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
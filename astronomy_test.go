@@ -0,0 +1,188 @@
+package weatherapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	weatherapi "github.com/TomSED/weather-api"
+	"github.com/TomSED/weather-api/mocks"
+	"github.com/TomSED/weather-api/pkg/astro"
+	"github.com/TomSED/weather-api/pkg/openweathermap"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeAstronomyResponse(t *testing.T, body string) *weatherapi.GetAstronomyResponse {
+	astronomy := &weatherapi.GetAstronomyResponse{}
+	err := json.Unmarshal([]byte(body), astronomy)
+	if !assert.Nil(t, err) {
+		t.Fatal(err)
+	}
+	return astronomy
+}
+
+func TestGetAstronomy(t *testing.T) {
+
+	t.Run("It should compute the sun's events of a located city without calling providers", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLocationFunc: func(ctx context.Context, city string) (*storage.Location, error) {
+				return &storage.Location{City: city, Name: "Sydney", Lat: -33.8679, Lon: 151.2073}, nil
+			},
+		}
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.GetAstronomy(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+				"date": "2021-05-16",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		astronomy := decodeAstronomyResponse(t, resp.Body)
+		assert.Equal(t, "Sydney", astronomy.City)
+		assert.Equal(t, "2021-05-16", astronomy.Date)
+		// openweathermap's sunrise and sunset for Sydney that day
+		if assert.NotNil(t, astronomy.Sunrise) && assert.NotNil(t, astronomy.Sunset) {
+			assert.InDelta(t, 1621111254, astronomy.Sunrise.Unix(), 60)
+			assert.InDelta(t, 1621148542, astronomy.Sunset.Unix(), 60)
+			assert.True(t, astronomy.SolarNoon.After(*astronomy.Sunrise) && astronomy.SolarNoon.Before(*astronomy.Sunset))
+		}
+		if assert.NotNil(t, astronomy.CivilDawn) {
+			assert.True(t, astronomy.CivilDawn.Before(*astronomy.Sunrise))
+		}
+		assert.InDelta(t, 1621148542-1621111254, astronomy.DayLengthSeconds, 60)
+		assert.Equal(t, astro.WaxingCrescent, astronomy.Moon.PhaseName)
+
+		assert.Len(t, mockOpenWeatherMapClient.GeocodeCalls(), 0)
+		if assert.Len(t, mockStore.GetLocationCalls(), 1) {
+			assert.Equal(t, "sydney", mockStore.GetLocationCalls()[0].City)
+		}
+	})
+
+	t.Run("It should geocode and store a city the first time", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLocationFunc: func(ctx context.Context, city string) (*storage.Location, error) {
+				return nil, nil
+			},
+			InsertLocationFunc: func(ctx context.Context, location *storage.Location) error {
+				return nil
+			},
+		}
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GeocodeFunc: func(city string) (*openweathermap.GeocodeResult, error) {
+				return &openweathermap.GeocodeResult{Name: "Sydney", Country: "AU", Lat: -33.8679, Lon: 151.2073}, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.GetAstronomy(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, -33.8679, decodeAstronomyResponse(t, resp.Body).Lat)
+
+		if assert.Len(t, mockStore.InsertLocationCalls(), 1) {
+			stored := mockStore.InsertLocationCalls()[0].Location
+			assert.Equal(t, "sydney", stored.City)
+			assert.Equal(t, "AU", stored.Country)
+			assert.Equal(t, 151.2073, stored.Lon)
+		}
+	})
+
+	t.Run("It should return a server error when the city can't be geocoded", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLocationFunc: func(ctx context.Context, city string) (*storage.Location, error) {
+				return nil, errors.New("connection refused")
+			},
+		}
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GeocodeFunc: func(city string) (*openweathermap.GeocodeResult, error) {
+				return nil, errors.New(`city "atlantis" not found`)
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.GetAstronomy(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "atlantis",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 500, resp.StatusCode)
+	})
+
+	t.Run("It should compute a point's polar night without a sunrise", func(t *testing.T) {
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, &mocks.StoreMock{})
+
+		resp, err := mockWeatherService.GetAstronomy(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"lat":  "69.6492",
+				"lon":  "18.9553",
+				"date": "2021-12-21",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		astronomy := decodeAstronomyResponse(t, resp.Body)
+		assert.Nil(t, astronomy.Sunrise)
+		assert.Nil(t, astronomy.Sunset)
+		assert.NotNil(t, astronomy.CivilDawn)
+		assert.Equal(t, 0, astronomy.DayLengthSeconds)
+	})
+
+	t.Run("It should default to today at the point", func(t *testing.T) {
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, &mocks.StoreMock{})
+
+		resp, err := mockWeatherService.GetAstronomy(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"lat": "0",
+				"lon": "0",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, time.Now().UTC().Format("2006-01-02"), decodeAstronomyResponse(t, resp.Body).Date)
+	})
+
+	t.Run("It should return a bad request without a location or with a malformed date", func(t *testing.T) {
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, &mocks.StoreMock{})
+
+		for _, params := range []map[string]string{
+			{},
+			{"lat": "-33.87"},
+			{"city": "sydney", "date": "16/05/2021"},
+		} {
+			resp, err := mockWeatherService.GetAstronomy(context.Background(), events.APIGatewayProxyRequest{
+				QueryStringParameters: params,
+			})
+			if !assert.Nil(t, err) {
+				t.Fatal(err)
+			}
+			assert.Equal(t, 400, resp.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,171 @@
+package weatherapi
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	// Most cities a batch request can ask for
+	MAX_BATCH_CITIES = 50
+	// Most cities refreshed from the providers at once
+	BATCH_CONCURRENCY = 5
+)
+
+// GetWeatherBatchRequest is the POST body of the GetWeatherBatch api
+type GetWeatherBatchRequest struct {
+	Cities []string `json:"cities"`
+}
+
+// CityWeather is a city's weather, or why it couldn't be returned, in the GetWeatherBatch api response
+type CityWeather struct {
+	City    string              `json:"city"`
+	Weather *GetWeatherResponse `json:"weather,omitempty"`
+	Error   string              `json:"error,omitempty"`
+}
+
+// GetWeatherBatchResponse is the struct for the GetWeatherBatch api response, results are in request order
+type GetWeatherBatchResponse struct {
+	Results []CityWeather `json:"results"`
+}
+
+// GetWeatherBatch is the endpoint for retrieving the weather of several cities at once
+// (via query params cities=sydney,melbourne&lang=fr or a POST body {"cities":["sydney","melbourne"]}).
+// Cached rows are read in one query and stale cities refreshed concurrently, a city failing doesn't fail the batch.
+func (ws *WeatherService) GetWeatherBatch(ctx context.Context, e events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	// Validate input
+	cities, err := batchCities(e)
+	if err != nil {
+		if ws.logger != nil {
+			ws.logger.Errorf("batchCities error: %v\n", err)
+		}
+		return badRequest(err.Error()), nil
+	}
+	language, ok := requestLanguage(e)
+	if !ok {
+		if ws.logger != nil {
+			ws.logger.Errorf(`Invalid e.QueryStringParameters["lang"]: %v\n`, e.QueryStringParameters["lang"])
+		}
+		return badRequest("Invalid lang in query parameter"), nil
+	}
+
+	// Try querying DB, cities it returned are still used if the batch partly failed
+	cached, err := ws.store.GetLatestWeatherDataBatch(ctx, cities)
+	if err != nil {
+		ws.logStoreError("GetLatestWeatherDataBatch", err)
+	}
+
+	results := make([]CityWeather, len(cities))
+	current := make([]*storage.WeatherData, len(cities))
+	fetched := make([]*storage.WeatherData, len(cities))
+	places := make([]*storage.Location, len(cities))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, BATCH_CONCURRENCY)
+	for i, city := range cities {
+		wg.Add(1)
+		go func(i int, city string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = CityWeather{City: city}
+			weatherData, place, isFetched, err := ws.currentWeather(ctx, city, language, cached[city])
+			if err != nil {
+				results[i].Error = providerError(err)
+				return
+			}
+			current[i] = weatherData
+			if isFetched {
+				fetched[i] = weatherData
+				places[i] = place
+			}
+		}(i, city)
+	}
+	wg.Wait()
+
+	ws.cityTimezones(ctx, cities, fetched, places, cached)
+	for i, weatherData := range current {
+		if weatherData != nil {
+			results[i].Weather = mapWeatherData(weatherData, language)
+		}
+	}
+
+	// Update db
+	toInsert := []*storage.WeatherData{}
+	for _, weatherData := range fetched {
+		if weatherData != nil {
+			toInsert = append(toInsert, weatherData)
+		}
+	}
+	if len(toInsert) > 0 {
+		_, err = ws.store.InsertWeatherDataBatch(ctx, toInsert)
+		if err != nil {
+			// Non-blocking error, do not need to return a http error, just log error
+			ws.logStoreError("InsertWeatherDataBatch", err)
+		}
+	}
+
+	// Marshal resp
+	byt, err := json.Marshal(&GetWeatherBatchResponse{Results: results})
+	if err != nil {
+		if ws.logger != nil {
+			ws.logger.Errorf("json.Marshal error: %v\n", err)
+		}
+		return internalServerError(), nil
+	}
+
+	return success(string(byt)), nil
+}
+
+// batchCities returns the requested cities in order without blanks or repeats
+func batchCities(e events.APIGatewayProxyRequest) ([]string, error) {
+	requested := []string{}
+	if e.HTTPMethod == http.MethodPost {
+		body := []byte(e.Body)
+		if e.IsBase64Encoded {
+			var err error
+			body, err = base64.StdEncoding.DecodeString(e.Body)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid request body")
+			}
+		}
+
+		req := &GetWeatherBatchRequest{}
+		err := json.Unmarshal(body, req)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid request body")
+		}
+		requested = req.Cities
+	} else if param := e.QueryStringParameters["cities"]; param != "" {
+		requested = strings.Split(param, ",")
+	}
+
+	cities := []string{}
+	seen := map[string]bool{}
+	for _, city := range requested {
+		city = strings.TrimSpace(city)
+		if city == "" || seen[city] {
+			continue
+		}
+		seen[city] = true
+		cities = append(cities, city)
+	}
+
+	if len(cities) == 0 {
+		return nil, fmt.Errorf("Missing cities")
+	}
+	if len(cities) > MAX_BATCH_CITIES {
+		return nil, fmt.Errorf("Too many cities, at most %d can be requested", MAX_BATCH_CITIES)
+	}
+	return cities, nil
+}
@@ -0,0 +1,430 @@
+package weatherapi_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	weatherapi "github.com/TomSED/weather-api"
+	"github.com/TomSED/weather-api/mocks"
+	"github.com/TomSED/weather-api/pkg/openweathermap"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/TomSED/weather-api/pkg/weatherstack"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeBatchResponse(t *testing.T, body string) *weatherapi.GetWeatherBatchResponse {
+	batch := &weatherapi.GetWeatherBatchResponse{}
+	err := json.Unmarshal([]byte(body), batch)
+	if !assert.Nil(t, err) {
+		t.Fatal(err)
+	}
+	return batch
+}
+
+func TestGetWeatherBatch(t *testing.T) {
+
+	t.Run("It should read the cities in one query and only refresh stale ones", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataBatchFunc: func(ctx context.Context, cities []string) (map[string]*storage.WeatherData, error) {
+				return map[string]*storage.WeatherData{
+					"Sydney": {
+						DataSource:  weatherapi.WeatherStackSource,
+						City:        "Sydney",
+						Temperature: 15,
+						WindSpeed:   24,
+						UpdatedDate: time.Now(),
+					},
+				}, nil
+			},
+			InsertWeatherDataBatchFunc: func(ctx context.Context, weatherData []*storage.WeatherData) (int, error) {
+				return len(weatherData), nil
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				resp := &weatherstack.APIResponse{}
+				resp.Current.Temperature = 10
+				resp.Current.WindSpeed = 20
+				return resp, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		resp, err := mockWeatherService.GetWeatherBatch(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			QueryStringParameters: map[string]string{
+				"cities": "Sydney, Melbourne,,Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		batch := decodeBatchResponse(t, resp.Body)
+		if !assert.Len(t, batch.Results, 2) {
+			t.Fatal()
+		}
+		assert.Equal(t, "Sydney", batch.Results[0].City)
+		assert.Equal(t, 15, batch.Results[0].Weather.Temperature)
+		assert.Equal(t, "Melbourne", batch.Results[1].City)
+		assert.Equal(t, 10, batch.Results[1].Weather.Temperature)
+		assert.Empty(t, batch.Results[1].Error)
+
+		if assert.Len(t, mockStore.GetLatestWeatherDataBatchCalls(), 1) {
+			assert.Equal(t, []string{"Sydney", "Melbourne"}, mockStore.GetLatestWeatherDataBatchCalls()[0].Cities)
+		}
+		assert.Len(t, mockStore.GetLatestWeatherDataCalls(), 0)
+		assert.Len(t, mockWeatherStackClient.GetWeatherCalls(), 1)
+		if assert.Len(t, mockStore.InsertWeatherDataBatchCalls(), 1) {
+			inserted := mockStore.InsertWeatherDataBatchCalls()[0].WeatherData
+			if assert.Len(t, inserted, 1) {
+				assert.Equal(t, "Melbourne", inserted[0].City)
+			}
+		}
+	})
+
+	t.Run("It should accept the cities as a POST body", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataBatchFunc: func(ctx context.Context, cities []string) (map[string]*storage.WeatherData, error) {
+				return map[string]*storage.WeatherData{}, nil
+			},
+			InsertWeatherDataBatchFunc: func(ctx context.Context, weatherData []*storage.WeatherData) (int, error) {
+				return len(weatherData), nil
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				resp := &weatherstack.APIResponse{}
+				resp.Current.Temperature = len(city)
+				return resp, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		body := base64.StdEncoding.EncodeToString([]byte(`{"cities":["Perth","Melbourne"]}`))
+		resp, err := mockWeatherService.GetWeatherBatch(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod:      "POST",
+			Body:            body,
+			IsBase64Encoded: true,
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		batch := decodeBatchResponse(t, resp.Body)
+		if !assert.Len(t, batch.Results, 2) {
+			t.Fatal()
+		}
+		assert.Equal(t, "Perth", batch.Results[0].City)
+		assert.Equal(t, 5, batch.Results[0].Weather.Temperature)
+		assert.Equal(t, "Melbourne", batch.Results[1].City)
+		assert.Equal(t, 9, batch.Results[1].Weather.Temperature)
+		if assert.Len(t, mockStore.InsertWeatherDataBatchCalls(), 1) {
+			assert.Len(t, mockStore.InsertWeatherDataBatchCalls()[0].WeatherData, 2)
+		}
+	})
+
+	t.Run("It should return per-city errors without failing the batch", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataBatchFunc: func(ctx context.Context, cities []string) (map[string]*storage.WeatherData, error) {
+				return map[string]*storage.WeatherData{}, nil
+			},
+			InsertWeatherDataBatchFunc: func(ctx context.Context, weatherData []*storage.WeatherData) (int, error) {
+				return 0, errors.New("db error")
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				if city == "Atlantis" {
+					return nil, errors.New("city not found")
+				}
+				resp := &weatherstack.APIResponse{}
+				resp.Current.Temperature = 12
+				return resp, nil
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				return nil, errors.New("city not found")
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.GetWeatherBatch(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			QueryStringParameters: map[string]string{
+				"cities": "Atlantis,Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		batch := decodeBatchResponse(t, resp.Body)
+		if !assert.Len(t, batch.Results, 2) {
+			t.Fatal()
+		}
+		assert.Equal(t, "Atlantis", batch.Results[0].City)
+		assert.Nil(t, batch.Results[0].Weather)
+		assert.NotEmpty(t, batch.Results[0].Error)
+		assert.Equal(t, "Sydney", batch.Results[1].City)
+		assert.Equal(t, 12, batch.Results[1].Weather.Temperature)
+		assert.Empty(t, batch.Results[1].Error)
+	})
+
+	t.Run("It should use the cities the DB returned when the batch read fails", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataBatchFunc: func(ctx context.Context, cities []string) (map[string]*storage.WeatherData, error) {
+				return map[string]*storage.WeatherData{
+					"Sydney": {
+						DataSource:  weatherapi.WeatherStackSource,
+						City:        "Sydney",
+						Temperature: 15,
+						UpdatedDate: time.Now(),
+					},
+				}, errors.New("db error")
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		resp, err := mockWeatherService.GetWeatherBatch(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			QueryStringParameters: map[string]string{
+				"cities": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		batch := decodeBatchResponse(t, resp.Body)
+		if assert.Len(t, batch.Results, 1) {
+			assert.Equal(t, 15, batch.Results[0].Weather.Temperature)
+		}
+		assert.Len(t, mockWeatherStackClient.GetWeatherCalls(), 0)
+		assert.Len(t, mockStore.InsertWeatherDataBatchCalls(), 0)
+	})
+
+	t.Run("It should refresh at most BATCH_CONCURRENCY cities at once", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataBatchFunc: func(ctx context.Context, cities []string) (map[string]*storage.WeatherData, error) {
+				return map[string]*storage.WeatherData{}, nil
+			},
+			InsertWeatherDataBatchFunc: func(ctx context.Context, weatherData []*storage.WeatherData) (int, error) {
+				return len(weatherData), nil
+			},
+		}
+
+		var running, maxRunning int32
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				n := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+				for {
+					max := atomic.LoadInt32(&maxRunning)
+					if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				return &weatherstack.APIResponse{}, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		cities := []string{}
+		for _, c := range "abcdefghijkl" {
+			cities = append(cities, string(c))
+		}
+		resp, err := mockWeatherService.GetWeatherBatch(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			QueryStringParameters: map[string]string{
+				"cities": strings.Join(cities, ","),
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Len(t, decodeBatchResponse(t, resp.Body).Results, len(cities))
+		assert.Len(t, mockWeatherStackClient.GetWeatherCalls(), len(cities))
+		assert.LessOrEqual(t, int(maxRunning), weatherapi.BATCH_CONCURRENCY)
+	})
+
+	t.Run("It should describe the conditions in the lang query parameter and forward it to the providers", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataBatchFunc: func(ctx context.Context, cities []string) (map[string]*storage.WeatherData, error) {
+				return map[string]*storage.WeatherData{
+					"Sydney": {DataSource: weatherapi.WeatherStackSource, City: "Sydney", Condition: "clear", Description: "Sunny", DescriptionLanguage: "en", UpdatedDate: time.Now()},
+				}, nil
+			},
+			InsertWeatherDataBatchFunc: func(ctx context.Context, weatherData []*storage.WeatherData) (int, error) {
+				return len(weatherData), nil
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				return nil, errors.New("weatherstack error")
+			},
+		}
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				resp := &openweathermap.APIResponse{}
+				err := json.Unmarshal([]byte(`{"weather":[{"id":800,"main":"Clear","description":"ciel dégagé","icon":"01d"}]}`), resp)
+				return resp, err
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.GetWeatherBatch(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			QueryStringParameters: map[string]string{
+				"cities": "Sydney,Melbourne",
+				"lang":   "fr",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		batch := decodeBatchResponse(t, resp.Body)
+		if !assert.Len(t, batch.Results, 2) {
+			t.Fatal()
+		}
+		for _, result := range batch.Results {
+			if assert.NotNil(t, result.Weather, result.City) {
+				assert.Equal(t, "Ensoleillé", result.Weather.ConditionText, result.City)
+				assert.Equal(t, "fr", result.Weather.Language, result.City)
+			}
+		}
+		if assert.Len(t, mockOpenWeatherMapClient.GetWeatherCalls(), 1) {
+			assert.Equal(t, "fr", mockOpenWeatherMapClient.GetWeatherCalls()[0].Language)
+		}
+	})
+
+	t.Run("It should render each city's times in its timezone, reading the placed cities' locations at once", func(t *testing.T) {
+		var inserted []*storage.WeatherData
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataBatchFunc: func(ctx context.Context, cities []string) (map[string]*storage.WeatherData, error) {
+				return map[string]*storage.WeatherData{
+					"Sydney": {DataSource: weatherapi.WeatherStackSource, City: "Sydney", Timezone: "Australia/Sydney", UpdatedDate: time.Now()},
+				}, nil
+			},
+			InsertWeatherDataBatchFunc: func(ctx context.Context, weatherData []*storage.WeatherData) (int, error) {
+				inserted = weatherData
+				return len(weatherData), nil
+			},
+			GetLocationBatchFunc: func(ctx context.Context, cities []string) (map[string]*storage.Location, error) {
+				return map[string]*storage.Location{
+					"perth": {City: "perth", Name: "Perth", Country: "AU", Lat: -31.95, Lon: 115.86, Timezone: "Australia/Perth"},
+				}, nil
+			},
+			InsertLocationFunc: func(ctx context.Context, location *storage.Location) error {
+				return nil
+			},
+		}
+
+		places := map[string]string{
+			"Melbourne": `{"location":{"lat":"-37.817","lon":"144.967","timezone_id":"Australia/Melbourne","utc_offset":"10.0"}}`,
+			"Perth":     `{"location":{"lat":"-31.950","lon":"115.860","timezone_id":"Australia/Perth","utc_offset":"8.0"}}`,
+		}
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				resp := &weatherstack.APIResponse{}
+				err := json.Unmarshal([]byte(places[city]), resp)
+				return resp, err
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		resp, err := mockWeatherService.GetWeatherBatch(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			QueryStringParameters: map[string]string{
+				"cities": "Sydney,Melbourne,Perth",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		batch := decodeBatchResponse(t, resp.Body)
+		if !assert.Len(t, batch.Results, 3) {
+			t.Fatal()
+		}
+		for i, timezone := range []string{"Australia/Sydney", "Australia/Melbourne", "Australia/Perth"} {
+			weather := batch.Results[i].Weather
+			if assert.NotNil(t, weather, batch.Results[i].City) {
+				assert.Equal(t, timezone, weather.Timezone)
+				assert.NotEmpty(t, weather.UTCOffset)
+				assert.NotNil(t, weather.LocalTime)
+				assert.NotNil(t, weather.ObservedAtLocal)
+			}
+		}
+
+		if assert.Len(t, mockStore.GetLocationBatchCalls(), 1) {
+			assert.Equal(t, []string{"melbourne", "perth"}, mockStore.GetLocationBatchCalls()[0].Cities)
+		}
+		if assert.Len(t, mockStore.InsertLocationCalls(), 1) {
+			assert.Equal(t, "melbourne", mockStore.InsertLocationCalls()[0].Location.City)
+			assert.Equal(t, "Australia/Melbourne", mockStore.InsertLocationCalls()[0].Location.Timezone)
+		}
+		if assert.Len(t, inserted, 2) {
+			assert.Equal(t, "Australia/Melbourne", inserted[0].Timezone)
+			assert.Equal(t, "Australia/Perth", inserted[1].Timezone)
+		}
+	})
+
+	t.Run("It should return bad request for missing, invalid or too many cities, or an invalid lang", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{}
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		many := []string{}
+		for i := 0; i <= weatherapi.MAX_BATCH_CITIES; i++ {
+			many = append(many, strings.Repeat("x", i+1))
+		}
+
+		requests := []events.APIGatewayProxyRequest{
+			{HTTPMethod: "GET"},
+			{HTTPMethod: "GET", QueryStringParameters: map[string]string{"cities": " , "}},
+			{HTTPMethod: "GET", QueryStringParameters: map[string]string{"cities": strings.Join(many, ",")}},
+			{HTTPMethod: "POST", Body: `{"cities":`},
+			{HTTPMethod: "POST", Body: `{"cities":[]}`},
+			{HTTPMethod: "GET", QueryStringParameters: map[string]string{"cities": "sydney", "lang": "f1"}},
+		}
+		for _, req := range requests {
+			resp, err := mockWeatherService.GetWeatherBatch(context.Background(), req)
+			if !assert.Nil(t, err) {
+				t.Fatal(err)
+			}
+			assert.Equal(t, 400, resp.StatusCode)
+		}
+		assert.Len(t, mockStore.GetLatestWeatherDataBatchCalls(), 0)
+	})
+}
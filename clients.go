@@ -1,8 +1,8 @@
 package weatherapi
 
 import (
+	"github.com/TomSED/weather-api/pkg/nws"
 	"github.com/TomSED/weather-api/pkg/openweathermap"
-	"github.com/TomSED/weather-api/pkg/postgres"
 	"github.com/TomSED/weather-api/pkg/weatherstack"
 )
 
@@ -10,20 +10,24 @@ import (
 
 // WeatherStackClient is an interface for the weather stack api client
 type WeatherStackClient interface {
-	GetWeather(city string) (*weatherstack.APIResponse, error)
+	GetWeather(city string, language string) (*weatherstack.APIResponse, error)
+	GetForecast(city string, days int) (*weatherstack.ForecastResponse, error)
 }
 
 //go:generate moq -pkg mocks -out mocks/mock_openweathermap_client.go . OpenWeatherMapClient
 
 // OpenWeatherMapClient is an interface for the open weather map api client
 type OpenWeatherMapClient interface {
-	GetWeather(city string) (*openweathermap.APIResponse, error)
+	GetWeather(city string, language string) (*openweathermap.APIResponse, error)
+	GetForecast(city string, days int) (*openweathermap.ForecastResponse, error)
+	Geocode(city string) (*openweathermap.GeocodeResult, error)
+	GetAlerts(lat float64, lon float64) (*openweathermap.AlertsResponse, error)
+	GetAirPollution(lat float64, lon float64) (*openweathermap.AirPollutionResponse, error)
 }
 
-//go:generate moq -pkg mocks -out mocks/mock_postgres_client.go . PostgresClient
+//go:generate moq -pkg mocks -out mocks/mock_nws_client.go . NWSClient
 
-// PostgresClient is an interface for the weather database client
-type PostgresClient interface {
-	InsertWeatherData(*postgres.WeatherData) error
-	GetLatestWeatherData(city string) (*postgres.WeatherData, error)
+// NWSClient is an interface for the US national weather service api client
+type NWSClient interface {
+	GetActiveAlerts(lat float64, lon float64) (*nws.AlertsResponse, error)
 }
@@ -0,0 +1,49 @@
+package weatherapi
+
+import (
+	"github.com/TomSED/weather-api/pkg/derived"
+	"github.com/TomSED/weather-api/pkg/storage"
+)
+
+// Comfort are the comfort metrics derived from an observation, in °C rounded to a tenth.
+// Metrics without their inputs, or outside the conditions they're defined for, are left out.
+type Comfort struct {
+	HeatIndex           *float64 `json:"heat_index,omitempty"`
+	WindChill           *float64 `json:"wind_chill,omitempty"`
+	DewPoint            *float64 `json:"dew_point,omitempty"`
+	Humidex             *float64 `json:"humidex,omitempty"`
+	ApparentTemperature *float64 `json:"apparent_temperature,omitempty"`
+	Beaufort            Beaufort `json:"beaufort"`
+}
+
+// Beaufort is the wind's Beaufort scale force
+type Beaufort struct {
+	Force       int    `json:"force"`
+	Description string `json:"description"`
+}
+
+// mapComfort derives the comfort metrics of the stored observation
+func mapComfort(data *storage.WeatherData) *Comfort {
+	metrics := derived.Compute(derived.Observation{
+		Temperature: float64(data.Temperature),
+		WindSpeed:   float64(data.WindSpeed),
+		Humidity:    float64(data.Humidity),
+	})
+
+	return &Comfort{
+		HeatIndex:           roundedTenth(metrics.HeatIndex),
+		WindChill:           roundedTenth(metrics.WindChill),
+		DewPoint:            roundedTenth(metrics.DewPoint),
+		Humidex:             roundedTenth(metrics.Humidex),
+		ApparentTemperature: roundedTenth(metrics.ApparentTemperature),
+		Beaufort:            Beaufort{Force: metrics.Beaufort.Force, Description: metrics.Beaufort.Description},
+	}
+}
+
+func roundedTenth(value *float64) *float64 {
+	if value == nil {
+		return nil
+	}
+	rounded := roundTo(*value, 1)
+	return &rounded
+}
@@ -0,0 +1,140 @@
+package weatherapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ProviderReading is one provider's normalized reading in the CompareWeather response
+type ProviderReading struct {
+	Source      string `json:"source"`
+	WindSpeed   *int   `json:"wind_speed,omitempty"`
+	Temperature *int   `json:"temperature_degrees,omitempty"`
+	LatencyMs   int64  `json:"latency_ms"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Spread is the difference between the highest and lowest successful readings
+type Spread struct {
+	WindSpeed   int `json:"wind_speed"`
+	Temperature int `json:"temperature_degrees"`
+}
+
+// CompareWeatherResponse is the struct for the CompareWeather api response
+type CompareWeatherResponse struct {
+	City      string            `json:"city"`
+	Providers []ProviderReading `json:"providers"`
+	Spread    *Spread           `json:"spread,omitempty"`
+}
+
+// CompareWeather is the endpoint for debugging provider quality (via query params city=sydney)
+// Every provider is queried concurrently, bypassing the cache
+func (ws *WeatherService) CompareWeather(ctx context.Context, e events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	// Validate input
+	city, exist := e.QueryStringParameters["city"]
+	if !exist || city == "" {
+		if ws.logger != nil {
+			ws.logger.Errorf(`Missing e.QueryStringParameters["city"]: %v\n`, city)
+		}
+		return badRequest("Missing city in query parameter"), nil
+	}
+
+	sources := ws.weatherSources()
+	readings := make([]ProviderReading, len(sources))
+
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source weatherSource) {
+			defer wg.Done()
+
+			start := time.Now()
+			weatherData, _, err := source.fetch(ctx, city, "")
+			reading := ProviderReading{
+				Source:    source.name,
+				LatencyMs: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				reading.Error = providerError(err)
+			} else {
+				reading.WindSpeed = &weatherData.WindSpeed
+				reading.Temperature = &weatherData.Temperature
+			}
+			readings[i] = reading
+		}(i, source)
+	}
+	wg.Wait()
+
+	compare := &CompareWeatherResponse{
+		City:      city,
+		Providers: readings,
+		Spread:    spread(readings),
+	}
+
+	// Marshal resp
+	byt, err := json.Marshal(compare)
+	if err != nil {
+		if ws.logger != nil {
+			ws.logger.Errorf("json.Marshal error: %v\n", err)
+		}
+		return internalServerError(), nil
+	}
+
+	return success(string(byt)), nil
+}
+
+// spread returns the max - min of the successful readings, nil if none succeeded
+func spread(readings []ProviderReading) *Spread {
+	var out *Spread
+	var minWind, maxWind, minTemp, maxTemp int
+	for _, r := range readings {
+		if r.Error != "" {
+			continue
+		}
+		if out == nil {
+			out = &Spread{}
+			minWind, maxWind = *r.WindSpeed, *r.WindSpeed
+			minTemp, maxTemp = *r.Temperature, *r.Temperature
+			continue
+		}
+		minWind, maxWind = minInt(minWind, *r.WindSpeed), maxInt(maxWind, *r.WindSpeed)
+		minTemp, maxTemp = minInt(minTemp, *r.Temperature), maxInt(maxTemp, *r.Temperature)
+	}
+
+	if out != nil {
+		out.WindSpeed = maxWind - minWind
+		out.Temperature = maxTemp - minTemp
+	}
+	return out
+}
+
+// providerError formats a provider error for the response without leaking request urls (which contain api keys)
+func providerError(err error) string {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Sprintf("%s request failed: %v", urlErr.Op, urlErr.Err)
+	}
+	return err.Error()
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,118 @@
+package weatherapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+
+	weatherapi "github.com/TomSED/weather-api"
+	"github.com/TomSED/weather-api/mocks"
+	"github.com/TomSED/weather-api/pkg/openweathermap"
+	"github.com/TomSED/weather-api/pkg/weatherstack"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareWeather(t *testing.T) {
+
+	t.Run("It should return every provider's reading and the spread, bypassing the DB", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				resp := &weatherstack.APIResponse{}
+				resp.Current.Temperature = 10
+				resp.Current.WindSpeed = 20
+				return resp, nil
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				resp := &openweathermap.APIResponse{}
+				resp.Main.Temp = 13
+				resp.Wind.Speed = 5
+				return resp, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.CompareWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		compare := &weatherapi.CompareWeatherResponse{}
+		err = json.Unmarshal([]byte(resp.Body), compare)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "Sydney", compare.City)
+		if !assert.Len(t, compare.Providers, 2) {
+			t.Fatal()
+		}
+		assert.Equal(t, weatherapi.WeatherStackSource, compare.Providers[0].Source)
+		assert.Equal(t, 10, *compare.Providers[0].Temperature)
+		assert.Equal(t, weatherapi.OpenWeatherMapSource, compare.Providers[1].Source)
+		assert.Equal(t, 18, *compare.Providers[1].WindSpeed)
+		assert.Equal(t, &weatherapi.Spread{WindSpeed: 2, Temperature: 3}, compare.Spread)
+		assert.Len(t, mockStore.GetLatestWeatherDataCalls(), 0)
+		assert.Len(t, mockStore.InsertWeatherDataCalls(), 0)
+	})
+
+	t.Run("It should report provider errors without leaking the request url", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				return nil, &url.Error{Op: "Get", URL: "http://api.weatherstack.com/current?access_key=secret", Err: errors.New("timeout")}
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				return nil, errors.New("openweathermap error")
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.CompareWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.NotContains(t, resp.Body, "secret")
+
+		compare := &weatherapi.CompareWeatherResponse{}
+		_ = json.Unmarshal([]byte(resp.Body), compare)
+		assert.Equal(t, "Get request failed: timeout", compare.Providers[0].Error)
+		assert.Nil(t, compare.Providers[0].Temperature)
+		assert.Equal(t, "openweathermap error", compare.Providers[1].Error)
+		assert.Nil(t, compare.Spread)
+	})
+
+	t.Run("If no city in query provided, it should return a 400 error", func(t *testing.T) {
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, &mocks.StoreMock{})
+
+		resp, err := mockWeatherService.CompareWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+}
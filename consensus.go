@@ -0,0 +1,199 @@
+package weatherapi
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+)
+
+const (
+	ConsensusDataSource = "consensus"
+
+	ConsensusMedian   = "median"
+	ConsensusWeighted = "weighted"
+)
+
+// ConsensusConfig enables querying every provider and blending their responses
+type ConsensusConfig struct {
+	// Method is ConsensusMedian or ConsensusWeighted
+	Method string
+	// Weights per data source for ConsensusWeighted, sources not listed have a weight of 1
+	Weights map[string]float64
+	// Readings further than the tolerance from the median are discarded as outliers.
+	// Outliers can only be told apart with at least 3 readings, so the tolerances do nothing while weatherstack and
+	// openweathermap are the only sources. 0 disables.
+	TemperatureTolerance float64
+	WindSpeedTolerance   float64
+}
+
+// SetConsensus switches GetWeather from failover to consensus mode, nil switches back
+func (ws *WeatherService) SetConsensus(config *ConsensusConfig) {
+	ws.consensus = config
+}
+
+// getConsensusWeather queries all providers concurrently and blends the successful responses. place is where the first
+// provider reporting an IANA timezone placed the city, otherwise the first reporting an offset.
+func (ws *WeatherService) getConsensusWeather(ctx context.Context, city string, language string) (*storage.WeatherData, *storage.Location, error) {
+	sources := ws.weatherSources()
+	results := make([]*storage.WeatherData, len(sources))
+	places := make([]*storage.Location, len(sources))
+
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source weatherSource) {
+			defer wg.Done()
+			weatherData, place, err := source.fetch(ctx, city, language)
+			if err != nil {
+				if ws.logger != nil {
+					ws.logger.Errorf("%s GetWeather error: %v\n", source.name, err)
+				}
+				return
+			}
+			results[i], places[i] = weatherData, place
+		}(i, source)
+	}
+	wg.Wait()
+
+	readings := []*storage.WeatherData{}
+	for _, result := range results {
+		if result != nil {
+			readings = append(readings, result)
+		}
+	}
+	if len(readings) == 0 {
+		return nil, nil, errors.New("no provider returned weather data")
+	}
+
+	var place *storage.Location
+	for _, p := range places {
+		if p != nil && (place == nil || isFixedZone(place.Timezone) && !isFixedZone(p.Timezone)) {
+			place = p
+		}
+	}
+
+	return blendWeatherData(city, readings, ws.consensus), place, nil
+}
+
+// blendWeatherData combines provider readings field by field, recording which sources contributed
+func blendWeatherData(city string, readings []*storage.WeatherData, config *ConsensusConfig) *storage.WeatherData {
+	temperatures := []weightedValue{}
+	windSpeeds := []weightedValue{}
+	// Only some sources report humidity
+	humidities := []weightedValue{}
+	for _, r := range readings {
+		weight := 1.0
+		if w, exist := config.Weights[r.DataSource]; exist {
+			weight = w
+		}
+		temperatures = append(temperatures, weightedValue{source: r.DataSource, value: float64(r.Temperature), weight: weight})
+		windSpeeds = append(windSpeeds, weightedValue{source: r.DataSource, value: float64(r.WindSpeed), weight: weight})
+		if r.Humidity > 0 {
+			humidities = append(humidities, weightedValue{source: r.DataSource, value: float64(r.Humidity), weight: weight})
+		}
+	}
+
+	temperatures = discardOutliers(temperatures, config.TemperatureTolerance)
+	windSpeeds = discardOutliers(windSpeeds, config.WindSpeedTolerance)
+
+	contributed := map[string]bool{}
+	for _, v := range append(append([]weightedValue{}, temperatures...), windSpeeds...) {
+		contributed[v.source] = true
+	}
+	sources := []string{}
+	// The blend is as old as its oldest contributing observation
+	observedAt := time.Time{}
+	// Conditions can't be blended, the first contributing source reporting one is used along with its description
+	var condition *storage.WeatherData
+	for _, r := range readings {
+		if contributed[r.DataSource] {
+			sources = append(sources, r.DataSource)
+			if !r.ObservedAt.IsZero() && (observedAt.IsZero() || r.ObservedAt.Before(observedAt)) {
+				observedAt = r.ObservedAt
+			}
+			if condition == nil && r.Condition != "" {
+				condition = r
+			}
+		}
+	}
+	if condition == nil {
+		condition = &storage.WeatherData{}
+	}
+
+	blend := median
+	if config.Method == ConsensusWeighted {
+		blend = weightedAverage
+	}
+	humidity := 0
+	if len(humidities) > 0 {
+		humidity = int(math.Round(blend(humidities)))
+	}
+
+	return &storage.WeatherData{
+		DataSource:          ConsensusDataSource,
+		Sources:             sources,
+		City:                city,
+		Temperature:         int(math.Round(blend(temperatures))),
+		WindSpeed:           int(math.Round(blend(windSpeeds))),
+		Humidity:            humidity,
+		Condition:           condition.Condition,
+		Night:               condition.Night,
+		Description:         condition.Description,
+		DescriptionLanguage: condition.DescriptionLanguage,
+		UpdatedDate:         time.Now().UTC(),
+		ObservedAt:          observedAt,
+	}
+}
+
+type weightedValue struct {
+	source string
+	value  float64
+	weight float64
+}
+
+// discardOutliers drops values further than tolerance from the median
+func discardOutliers(values []weightedValue, tolerance float64) []weightedValue {
+	if tolerance <= 0 || len(values) < 3 {
+		return values
+	}
+
+	m := median(values)
+	out := []weightedValue{}
+	for _, v := range values {
+		if math.Abs(v.value-m) <= tolerance {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func median(values []weightedValue) float64 {
+	sorted := make([]float64, len(values))
+	for i, v := range values {
+		sorted[i] = v.value
+	}
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func weightedAverage(values []weightedValue) float64 {
+	sum, totalWeight := 0.0, 0.0
+	for _, v := range values {
+		sum += v.value * v.weight
+		totalWeight += v.weight
+	}
+	if totalWeight == 0 {
+		return median(values)
+	}
+	return sum / totalWeight
+}
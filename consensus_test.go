@@ -0,0 +1,96 @@
+package weatherapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlendWeatherData(t *testing.T) {
+
+	readings := []*storage.WeatherData{
+		{DataSource: "a", Temperature: 15, WindSpeed: 20},
+		{DataSource: "b", Temperature: 16, WindSpeed: 22},
+		{DataSource: "c", Temperature: 30, WindSpeed: 21},
+	}
+
+	t.Run("It should discard outliers before blending", func(t *testing.T) {
+		blended := blendWeatherData("Sydney", readings, &ConsensusConfig{
+			Method:               ConsensusWeighted,
+			TemperatureTolerance: 5,
+			WindSpeedTolerance:   5,
+		})
+		assert.Equal(t, ConsensusDataSource, blended.DataSource)
+		assert.Equal(t, 16, blended.Temperature) // (15 + 16) / 2 rounded
+		assert.Equal(t, 21, blended.WindSpeed)
+		// c still contributed a windspeed
+		assert.Equal(t, []string{"a", "b", "c"}, blended.Sources)
+	})
+
+	t.Run("It should only record sources that contributed", func(t *testing.T) {
+		blended := blendWeatherData("Sydney", []*storage.WeatherData{
+			{DataSource: "a", Temperature: 15, WindSpeed: 20},
+			{DataSource: "b", Temperature: 16, WindSpeed: 21},
+			{DataSource: "c", Temperature: 30, WindSpeed: 60},
+		}, &ConsensusConfig{
+			Method:               ConsensusMedian,
+			TemperatureTolerance: 5,
+			WindSpeedTolerance:   5,
+		})
+		assert.Equal(t, 16, blended.Temperature)
+		assert.Equal(t, 21, blended.WindSpeed)
+		assert.Equal(t, []string{"a", "b"}, blended.Sources)
+	})
+
+	t.Run("It should keep every reading when there are less than three", func(t *testing.T) {
+		blended := blendWeatherData("Sydney", readings[1:], &ConsensusConfig{
+			Method:               ConsensusMedian,
+			TemperatureTolerance: 1,
+		})
+		assert.Equal(t, 23, blended.Temperature)
+		assert.Equal(t, []string{"b", "c"}, blended.Sources)
+	})
+
+	t.Run("It should be observed when its oldest contributing reading was", func(t *testing.T) {
+		observedAt := time.Date(2021, 5, 16, 1, 30, 0, 0, time.UTC)
+		blended := blendWeatherData("Sydney", []*storage.WeatherData{
+			{DataSource: "a", Temperature: 15, WindSpeed: 20, ObservedAt: observedAt},
+			{DataSource: "b", Temperature: 16, WindSpeed: 21, ObservedAt: observedAt.Add(-10 * time.Minute)},
+			{DataSource: "c", Temperature: 30, WindSpeed: 60, ObservedAt: observedAt.Add(-time.Hour)},
+			{DataSource: "d", Temperature: 17, WindSpeed: 22},
+		}, &ConsensusConfig{
+			Method:               ConsensusMedian,
+			TemperatureTolerance: 5,
+			WindSpeedTolerance:   5,
+		})
+		assert.Equal(t, []string{"a", "b", "d"}, blended.Sources)
+		assert.Equal(t, observedAt.Add(-10*time.Minute), blended.ObservedAt)
+	})
+	t.Run("It should blend humidity over the readings that have it", func(t *testing.T) {
+		blended := blendWeatherData("Sydney", []*storage.WeatherData{
+			{DataSource: "a", Temperature: 15, WindSpeed: 20, Humidity: 60},
+			{DataSource: "b", Temperature: 16, WindSpeed: 21},
+			{DataSource: "c", Temperature: 17, WindSpeed: 22, Humidity: 71},
+		}, &ConsensusConfig{Method: ConsensusMedian})
+		assert.Equal(t, 66, blended.Humidity) // (60 + 71) / 2 rounded
+
+		blended = blendWeatherData("Sydney", readings, &ConsensusConfig{Method: ConsensusMedian})
+		assert.Equal(t, 0, blended.Humidity)
+	})
+	t.Run("It should use the condition of the first contributing source reporting one", func(t *testing.T) {
+		blended := blendWeatherData("Sydney", []*storage.WeatherData{
+			{DataSource: "a", Temperature: 15, WindSpeed: 20},
+			{DataSource: "b", Temperature: 40, WindSpeed: 60, Condition: "clear"},
+			{DataSource: "c", Temperature: 16, WindSpeed: 21, Condition: "rain", Night: true},
+			{DataSource: "d", Temperature: 17, WindSpeed: 22, Condition: "cloudy"},
+		}, &ConsensusConfig{
+			Method:               ConsensusMedian,
+			TemperatureTolerance: 5,
+			WindSpeedTolerance:   5,
+		})
+		assert.Equal(t, "rain", blended.Condition)
+		assert.True(t, blended.Night)
+	})
+}
@@ -0,0 +1,337 @@
+package weatherapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/openweathermap"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/TomSED/weather-api/pkg/weatherstack"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	// Forecasts are refetched once they're older than this, unless SetForecastTTL says otherwise
+	FORECAST_CACHE_SECONDS = 30 * 60
+	// Cached forecasts up to this old are served when providers can't be used
+	MAX_FORECAST_STALE_SECONDS = 6 * 60 * 60
+	// Days forecast when the request doesn't say, and the most it can ask for: as far ahead as openweathermap goes
+	DEFAULT_FORECAST_DAYS = 3
+	MAX_FORECAST_DAYS     = openweathermap.MaxForecastDays
+
+	GranularityHourly = "hourly"
+	GranularityDaily  = "daily"
+)
+
+// SetForecastTTL sets how long a fetched forecast is served before it's refetched, FORECAST_CACHE_SECONDS by default
+func (ws *WeatherService) SetForecastTTL(ttl time.Duration) {
+	ws.forecastTTL = ttl
+}
+
+// ForecastPeriod is a period of the GetForecast api response, hourly periods are the provider's forecast steps
+type ForecastPeriod struct {
+	Time                time.Time `json:"time"`
+	Temperature         int       `json:"temperature_degrees"`
+	TemperatureMin      int       `json:"temperature_min_degrees"`
+	TemperatureMax      int       `json:"temperature_max_degrees"`
+	WindSpeed           int       `json:"wind_speed"`
+	PrecipitationChance int       `json:"precipitation_chance"`
+	Description         string    `json:"description,omitempty"`
+}
+
+// GetForecastResponse is the struct for the GetForecast api response
+type GetForecastResponse struct {
+	City        string           `json:"city"`
+	Granularity string           `json:"granularity"`
+	FetchedAt   time.Time        `json:"fetched_at"`
+	Periods     []ForecastPeriod `json:"periods"`
+}
+
+// GetForecast is the endpoint for retrieving a city's forecast (via query params city=sydney&days=3&granularity=daily)
+// Forecast sources uses open weathermap with a failover of weather stack
+func (ws *WeatherService) GetForecast(ctx context.Context, e events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	// Validate input
+	city, exist := e.QueryStringParameters["city"]
+	if !exist || city == "" {
+		if ws.logger != nil {
+			ws.logger.Errorf(`Missing e.QueryStringParameters["city"]: %v\n`, city)
+		}
+		return badRequest("Missing city in query parameter"), nil
+	}
+
+	days := DEFAULT_FORECAST_DAYS
+	if param := e.QueryStringParameters["days"]; param != "" {
+		var err error
+		days, err = strconv.Atoi(param)
+		if err != nil || days < 1 || days > MAX_FORECAST_DAYS {
+			return badRequest(fmt.Sprintf("days must be between 1 and %d", MAX_FORECAST_DAYS)), nil
+		}
+	}
+
+	granularity := e.QueryStringParameters["granularity"]
+	if granularity == "" {
+		granularity = GranularityDaily
+	}
+	if granularity != GranularityHourly && granularity != GranularityDaily {
+		return badRequest("granularity must be hourly or daily"), nil
+	}
+
+	// Try querying DB
+	cached, err := ws.store.GetForecast(ctx, city)
+	if err != nil {
+		ws.logStoreError("GetForecast", err)
+		cached = nil
+	}
+
+	forecast := cached
+	if !ws.forecastIsFresh(cached) {
+		forecast, err = ws.getFailoverForecast(ctx, city)
+		if err == nil {
+			// Update db
+			err = ws.store.InsertForecast(ctx, forecast)
+			if err != nil {
+				// Non-blocking error, do not need to return a http error, just log error
+				ws.logStoreError("InsertForecast", err)
+			}
+		} else if forecastServableWhenStale(cached) {
+			forecast = cached
+		} else {
+			return internalServerError(), nil
+		}
+	}
+
+	// Marshal resp
+	byt, err := json.Marshal(mapForecast(forecast, granularity, days, time.Now().UTC()))
+	if err != nil {
+		if ws.logger != nil {
+			ws.logger.Errorf("json.Marshal error: %v\n", err)
+		}
+		return internalServerError(), nil
+	}
+
+	return success(string(byt)), nil
+}
+
+// getFailoverForecast tries each provider in order, returning the first successful forecast
+func (ws *WeatherService) getFailoverForecast(ctx context.Context, city string) (*storage.Forecast, error) {
+	var err error
+	for _, source := range ws.forecastSources() {
+		var forecast *storage.Forecast
+		forecast, err = source.fetch(ctx, city)
+		if err == nil {
+			return forecast, nil
+		}
+		if ws.logger != nil {
+			ws.logger.Errorf("%s GetForecast error: %v\n", source.name, err)
+		}
+	}
+	return nil, err
+}
+
+// forecastIsFresh reports whether the forecast was fetched within the forecast TTL
+func (ws *WeatherService) forecastIsFresh(forecast *storage.Forecast) bool {
+	if forecast == nil {
+		return false
+	}
+
+	ttl := ws.forecastTTL
+	if ttl <= 0 {
+		ttl = FORECAST_CACHE_SECONDS * time.Second
+	}
+	return time.Now().UTC().Sub(forecast.FetchedAt.UTC()) <= ttl
+}
+
+// forecastServableWhenStale reports whether a cached forecast can still be served when it can't be refreshed
+func forecastServableWhenStale(forecast *storage.Forecast) bool {
+	if forecast == nil {
+		return false
+	}
+	return time.Now().UTC().Sub(forecast.FetchedAt.UTC()) <= MAX_FORECAST_STALE_SECONDS*time.Second
+}
+
+// mapForecast returns the hourly periods of the next days days, or the days from today on
+func mapForecast(forecast *storage.Forecast, granularity string, days int, now time.Time) *GetForecastResponse {
+	var periods []storage.ForecastPeriod
+	if granularity == GranularityHourly {
+		periods = upcomingPeriods(forecast.Hourly, now)
+		until := now.Add(time.Duration(days) * 24 * time.Hour)
+		for i, period := range periods {
+			if !period.Time.Before(until) {
+				periods = periods[:i]
+				break
+			}
+		}
+	} else {
+		periods = upcomingPeriods(forecast.Daily, now)
+		if len(periods) > days {
+			periods = periods[:days]
+		}
+	}
+
+	out := &GetForecastResponse{
+		City:        forecast.City,
+		Granularity: granularity,
+		FetchedAt:   forecast.FetchedAt.UTC(),
+		Periods:     []ForecastPeriod{},
+	}
+	for _, period := range periods {
+		out.Periods = append(out.Periods, ForecastPeriod{
+			Time:                period.Time.UTC(),
+			Temperature:         period.Temperature,
+			TemperatureMin:      period.TemperatureMin,
+			TemperatureMax:      period.TemperatureMax,
+			WindSpeed:           period.WindSpeed,
+			PrecipitationChance: period.PrecipitationChance,
+			Description:         period.Description,
+		})
+	}
+	return out
+}
+
+// upcomingPeriods drops the periods that ended before now. A period ends when the next one starts, the last one after
+// as long as the one before it.
+func upcomingPeriods(periods []storage.ForecastPeriod, now time.Time) []storage.ForecastPeriod {
+	for i := range periods {
+		var end time.Time
+		if i+1 < len(periods) {
+			end = periods[i+1].Time
+		} else if i > 0 {
+			end = periods[i].Time.Add(periods[i].Time.Sub(periods[i-1].Time))
+		} else {
+			end = periods[i].Time.Add(24 * time.Hour)
+		}
+		if end.After(now) {
+			return periods[i:]
+		}
+	}
+	return nil
+}
+
+// mapOpenWeatherMapForecast normalizes the 3 hourly forecast, wind speed is converted from m/s to km/h to match
+// weatherstack. Daily periods are aggregated from the steps of each local day.
+func mapOpenWeatherMapForecast(city string, resp *openweathermap.ForecastResponse) *storage.Forecast {
+	forecast := &storage.Forecast{
+		DataSource: OpenWeatherMapSource,
+		City:       city,
+		FetchedAt:  time.Now().UTC(),
+		Hourly:     []storage.ForecastPeriod{},
+		Daily:      []storage.ForecastPeriod{},
+	}
+
+	location := resp.Location()
+	var day *storage.ForecastPeriod
+	var daySteps int
+	var dayTemperature float64
+	var dayNoon time.Duration
+	for _, step := range resp.List {
+		period := storage.ForecastPeriod{
+			Time:                time.Unix(int64(step.Dt), 0).UTC(),
+			Temperature:         int(math.Round(step.Main.Temp)),
+			TemperatureMin:      int(math.Round(step.Main.TempMin)),
+			TemperatureMax:      int(math.Round(step.Main.TempMax)),
+			WindSpeed:           int(math.Round(step.Wind.Speed * 3.6)),
+			PrecipitationChance: int(math.Round(step.Pop * 100)),
+		}
+		if len(step.Weather) > 0 {
+			period.Description = step.Weather[0].Description
+		}
+		forecast.Hourly = append(forecast.Hourly, period)
+
+		local := period.Time.In(location)
+		year, month, date := local.Date()
+		midnight := time.Date(year, month, date, 0, 0, 0, 0, location).UTC()
+		if day == nil || !day.Time.Equal(midnight) {
+			forecast.Daily = append(forecast.Daily, storage.ForecastPeriod{
+				Time:           midnight,
+				TemperatureMin: period.TemperatureMin,
+				TemperatureMax: period.TemperatureMax,
+			})
+			day = &forecast.Daily[len(forecast.Daily)-1]
+			daySteps = 0
+			dayTemperature = 0
+			dayNoon = 24 * time.Hour
+		}
+
+		daySteps++
+		dayTemperature += step.Main.Temp
+		day.Temperature = int(math.Round(dayTemperature / float64(daySteps)))
+		day.TemperatureMin = minInt(day.TemperatureMin, period.TemperatureMin)
+		day.TemperatureMax = maxInt(day.TemperatureMax, period.TemperatureMax)
+		day.WindSpeed = maxInt(day.WindSpeed, period.WindSpeed)
+		day.PrecipitationChance = maxInt(day.PrecipitationChance, period.PrecipitationChance)
+		// The day is described by its step closest to midday
+		if fromNoon := absDuration(period.Time.Sub(midnight) - 12*time.Hour); fromNoon < dayNoon {
+			dayNoon = fromNoon
+			day.Description = period.Description
+		}
+	}
+
+	return forecast
+}
+
+// mapWeatherStackForecast normalizes the daily forecast and its hourly steps
+func mapWeatherStackForecast(city string, resp *weatherstack.ForecastResponse) (*storage.Forecast, error) {
+	forecast := &storage.Forecast{
+		DataSource: WeatherStackSource,
+		City:       city,
+		FetchedAt:  time.Now().UTC(),
+		Hourly:     []storage.ForecastPeriod{},
+		Daily:      []storage.ForecastPeriod{},
+	}
+
+	for _, forecastDay := range resp.Days() {
+		start, err := resp.DayStart(forecastDay)
+		if err != nil {
+			return nil, err
+		}
+		day := storage.ForecastPeriod{
+			Time:           start,
+			Temperature:    forecastDay.AvgTemp,
+			TemperatureMin: forecastDay.MinTemp,
+			TemperatureMax: forecastDay.MaxTemp,
+		}
+
+		dayNoon := 24 * time.Hour
+		for _, hour := range forecastDay.Hourly {
+			hourStart, err := resp.HourStart(forecastDay, hour)
+			if err != nil {
+				return nil, err
+			}
+			period := storage.ForecastPeriod{
+				Time:                hourStart,
+				Temperature:         hour.Temperature,
+				TemperatureMin:      hour.Temperature,
+				TemperatureMax:      hour.Temperature,
+				WindSpeed:           hour.WindSpeed,
+				PrecipitationChance: maxInt(hour.ChanceOfRain, hour.ChanceOfSnow),
+			}
+			if len(hour.WeatherDescriptions) > 0 {
+				period.Description = hour.WeatherDescriptions[0]
+			}
+			forecast.Hourly = append(forecast.Hourly, period)
+
+			day.WindSpeed = maxInt(day.WindSpeed, period.WindSpeed)
+			day.PrecipitationChance = maxInt(day.PrecipitationChance, period.PrecipitationChance)
+			// The day is described by its step closest to midday
+			if fromNoon := absDuration(hourStart.Sub(start) - 12*time.Hour); fromNoon < dayNoon {
+				dayNoon = fromNoon
+				day.Description = period.Description
+			}
+		}
+		forecast.Daily = append(forecast.Daily, day)
+	}
+
+	return forecast, nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
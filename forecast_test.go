@@ -0,0 +1,326 @@
+package weatherapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	weatherapi "github.com/TomSED/weather-api"
+	"github.com/TomSED/weather-api/mocks"
+	"github.com/TomSED/weather-api/pkg/openweathermap"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/TomSED/weather-api/pkg/weatherstack"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeForecastResponse(t *testing.T, body string) *weatherapi.GetForecastResponse {
+	forecast := &weatherapi.GetForecastResponse{}
+	err := json.Unmarshal([]byte(body), forecast)
+	if !assert.Nil(t, err) {
+		t.Fatal(err)
+	}
+	return forecast
+}
+
+// openWeatherMapForecast returns 3 hourly steps in UTC from start, warmest at midday
+func openWeatherMapForecast(start time.Time, steps int) *openweathermap.ForecastResponse {
+	resp := &openweathermap.ForecastResponse{}
+	for i := 0; i < steps; i++ {
+		step := openweathermap.ForecastStep{Dt: int(start.Add(time.Duration(i) * 3 * time.Hour).Unix())}
+		hour := (i * 3) % 24
+		step.Main.Temp = float64(20 - absInt(hour-12))
+		step.Main.TempMin = step.Main.Temp
+		step.Main.TempMax = step.Main.Temp
+		step.Wind.Speed = float64(i)
+		step.Pop = float64(i%8) / 10
+		step.Weather = append(step.Weather, struct {
+			ID          int    `json:"id"`
+			Main        string `json:"main"`
+			Description string `json:"description"`
+			Icon        string `json:"icon"`
+		}{Description: "clear sky"})
+		if hour == 12 {
+			step.Weather[0].Description = "few clouds"
+		}
+		resp.List = append(resp.List, step)
+	}
+	return resp
+}
+
+func absInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func TestGetForecast(t *testing.T) {
+
+	tomorrow := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+
+	t.Run("On a miss it should fetch openweathermap's forecast, store it and return daily periods", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetForecastFunc: func(ctx context.Context, city string) (*storage.Forecast, error) {
+				return nil, nil
+			},
+			InsertForecastFunc: func(ctx context.Context, forecast *storage.Forecast) error {
+				return nil
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetForecastFunc: func(city string, days int) (*openweathermap.ForecastResponse, error) {
+				return openWeatherMapForecast(tomorrow, 16), nil
+			},
+		}
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.GetForecast(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+				"days": "1",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		forecast := decodeForecastResponse(t, resp.Body)
+		assert.Equal(t, "Sydney", forecast.City)
+		assert.Equal(t, weatherapi.GranularityDaily, forecast.Granularity)
+		if assert.Len(t, forecast.Periods, 1) {
+			day := forecast.Periods[0]
+			assert.True(t, tomorrow.Equal(day.Time))
+			assert.Equal(t, 8, day.TemperatureMin)
+			assert.Equal(t, 20, day.TemperatureMax)
+			// 7 m/s
+			assert.Equal(t, 25, day.WindSpeed)
+			assert.Equal(t, 70, day.PrecipitationChance)
+			assert.Equal(t, "few clouds", day.Description)
+		}
+
+		if assert.Len(t, mockStore.InsertForecastCalls(), 1) {
+			stored := mockStore.InsertForecastCalls()[0].Forecast
+			assert.Equal(t, weatherapi.OpenWeatherMapSource, stored.DataSource)
+			assert.Len(t, stored.Hourly, 16)
+			assert.Len(t, stored.Daily, 2)
+		}
+		if assert.Len(t, mockOpenWeatherMapClient.GetForecastCalls(), 1) {
+			assert.Equal(t, weatherapi.MAX_FORECAST_DAYS, mockOpenWeatherMapClient.GetForecastCalls()[0].Days)
+		}
+		assert.Len(t, mockWeatherStackClient.GetForecastCalls(), 0)
+	})
+
+	t.Run("It should return the hourly periods of the requested days", func(t *testing.T) {
+		now := time.Now().UTC()
+		hourly := []storage.ForecastPeriod{}
+		for i := -1; i < 16; i++ {
+			hourly = append(hourly, storage.ForecastPeriod{Time: now.Truncate(3 * time.Hour).Add(time.Duration(i) * 3 * time.Hour), Temperature: i})
+		}
+		mockStore := &mocks.StoreMock{
+			GetForecastFunc: func(ctx context.Context, city string) (*storage.Forecast, error) {
+				return &storage.Forecast{DataSource: weatherapi.OpenWeatherMapSource, City: city, FetchedAt: now, Hourly: hourly}, nil
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{}
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.GetForecast(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city":        "Sydney",
+				"days":        "1",
+				"granularity": "hourly",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		forecast := decodeForecastResponse(t, resp.Body)
+		assert.Equal(t, weatherapi.GranularityHourly, forecast.Granularity)
+		// The current step and the rest of the next 24 hours
+		if assert.Len(t, forecast.Periods, 9) {
+			assert.Equal(t, 0, forecast.Periods[0].Temperature)
+			assert.Equal(t, 8, forecast.Periods[8].Temperature)
+		}
+		assert.Len(t, mockOpenWeatherMapClient.GetForecastCalls(), 0)
+		assert.Len(t, mockStore.InsertForecastCalls(), 0)
+	})
+
+	t.Run("It should fail over to weatherstack", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetForecastFunc: func(ctx context.Context, city string) (*storage.Forecast, error) {
+				return nil, errors.New("db error")
+			},
+			InsertForecastFunc: func(ctx context.Context, forecast *storage.Forecast) error {
+				return errors.New("db error")
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetForecastFunc: func(city string, days int) (*openweathermap.ForecastResponse, error) {
+				return nil, errors.New("timeout")
+			},
+		}
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetForecastFunc: func(city string, days int) (*weatherstack.ForecastResponse, error) {
+				date := tomorrow.Format("2006-01-02")
+				resp := &weatherstack.ForecastResponse{Forecast: map[string]weatherstack.ForecastDay{
+					date: {
+						Date:    date,
+						MinTemp: 11,
+						MaxTemp: 19,
+						AvgTemp: 15,
+						Hourly: []weatherstack.ForecastHour{
+							{Time: "0", Temperature: 11, WindSpeed: 9, WeatherDescriptions: []string{"Clear"}},
+							{Time: "1200", Temperature: 19, WindSpeed: 20, WeatherDescriptions: []string{"Light rain"}, ChanceOfRain: 60},
+						},
+					},
+				}}
+				resp.Location.UtcOffset = "10.0"
+				return resp, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.GetForecast(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		forecast := decodeForecastResponse(t, resp.Body)
+		if assert.Len(t, forecast.Periods, 1) {
+			day := forecast.Periods[0]
+			assert.True(t, tomorrow.Add(-10*time.Hour).Equal(day.Time))
+			assert.Equal(t, 15, day.Temperature)
+			assert.Equal(t, 20, day.WindSpeed)
+			assert.Equal(t, 60, day.PrecipitationChance)
+			assert.Equal(t, "Light rain", day.Description)
+		}
+		assert.Len(t, mockOpenWeatherMapClient.GetForecastCalls(), 1)
+		assert.Len(t, mockWeatherStackClient.GetForecastCalls(), 1)
+	})
+
+	t.Run("When providers fail it should serve a stale forecast while it's young enough", func(t *testing.T) {
+		fetchedAt := time.Now().UTC().Add(-2 * time.Hour)
+		mockStore := &mocks.StoreMock{
+			GetForecastFunc: func(ctx context.Context, city string) (*storage.Forecast, error) {
+				return &storage.Forecast{
+					DataSource: weatherapi.OpenWeatherMapSource,
+					City:       city,
+					FetchedAt:  fetchedAt,
+					Daily:      []storage.ForecastPeriod{{Time: tomorrow, Temperature: 16}},
+				}, nil
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetForecastFunc: func(city string, days int) (*openweathermap.ForecastResponse, error) {
+				return nil, errors.New("timeout")
+			},
+		}
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetForecastFunc: func(city string, days int) (*weatherstack.ForecastResponse, error) {
+				return nil, errors.New("function_access_restricted")
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.GetForecast(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		forecast := decodeForecastResponse(t, resp.Body)
+		assert.True(t, fetchedAt.Truncate(time.Second).Equal(forecast.FetchedAt.Truncate(time.Second)))
+		if assert.Len(t, forecast.Periods, 1) {
+			assert.Equal(t, 16, forecast.Periods[0].Temperature)
+		}
+		assert.Len(t, mockStore.InsertForecastCalls(), 0)
+
+		fetchedAt = time.Now().UTC().Add(-(weatherapi.MAX_FORECAST_STALE_SECONDS + 60) * time.Second)
+		resp, err = mockWeatherService.GetForecast(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 500, resp.StatusCode)
+	})
+
+	t.Run("It should refetch forecasts older than the forecast TTL", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetForecastFunc: func(ctx context.Context, city string) (*storage.Forecast, error) {
+				return &storage.Forecast{City: city, FetchedAt: time.Now().UTC().Add(-2 * time.Minute)}, nil
+			},
+			InsertForecastFunc: func(ctx context.Context, forecast *storage.Forecast) error {
+				return nil
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetForecastFunc: func(city string, days int) (*openweathermap.ForecastResponse, error) {
+				return openWeatherMapForecast(tomorrow, 8), nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, mockStore)
+		mockWeatherService.SetForecastTTL(time.Minute)
+
+		resp, err := mockWeatherService.GetForecast(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Len(t, mockOpenWeatherMapClient.GetForecastCalls(), 1)
+		assert.Len(t, mockStore.InsertForecastCalls(), 1)
+	})
+
+	t.Run("It should return bad request for invalid parameters", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{}
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		for _, params := range []map[string]string{
+			{},
+			{"city": "Sydney", "days": "0"},
+			{"city": "Sydney", "days": "6"},
+			{"city": "Sydney", "days": "three"},
+			{"city": "Sydney", "granularity": "weekly"},
+		} {
+			resp, err := mockWeatherService.GetForecast(context.Background(), events.APIGatewayProxyRequest{
+				QueryStringParameters: params,
+			})
+			if !assert.Nil(t, err) {
+				t.Fatal(err)
+			}
+			assert.Equal(t, 400, resp.StatusCode, "params %v", params)
+		}
+		assert.Len(t, mockStore.GetForecastCalls(), 0)
+	})
+}
@@ -0,0 +1,331 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	weatherapi "github.com/TomSED/weather-api"
+	"github.com/TomSED/weather-api/pkg/cache"
+	"github.com/TomSED/weather-api/pkg/dynamo"
+	"github.com/TomSED/weather-api/pkg/fileprovider"
+	"github.com/TomSED/weather-api/pkg/nws"
+	"github.com/TomSED/weather-api/pkg/openweathermap"
+	"github.com/TomSED/weather-api/pkg/postgres"
+	"github.com/TomSED/weather-api/pkg/ratelimit"
+	"github.com/TomSED/weather-api/pkg/redis"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/TomSED/weather-api/pkg/storage/memory"
+	"github.com/TomSED/weather-api/pkg/storage/sqlite"
+	"github.com/TomSED/weather-api/pkg/weatherstack"
+	"github.com/sirupsen/logrus"
+)
+
+// NewWeatherService builds the WeatherService shared by the lambda workers from env
+func NewWeatherService(logger *logrus.Logger) (*weatherapi.WeatherService, error) {
+
+	var weatherStackClient weatherapi.WeatherStackClient
+	var openWeatherMapClient weatherapi.OpenWeatherMapClient
+	var nwsClient weatherapi.NWSClient
+	switch os.Getenv("WEATHER_PROVIDER") {
+	case "file":
+		// Serve fixtures instead of calling the real apis
+		fixturesDir := os.Getenv("WEATHER_FIXTURES_DIR")
+		if fixturesDir == "" {
+			fixturesDir = "fixtures"
+		}
+		fileProvider, err := fileprovider.NewClient(fixturesDir)
+		if err != nil {
+			return nil, fmt.Errorf("fileprovider.NewClient error: %v", err)
+		}
+		weatherStackClient = fileProvider.WeatherStack()
+		openWeatherMapClient = fileProvider.OpenWeatherMap()
+	default:
+		weatherStackClient = weatherstack.NewClient("", os.Getenv("WEATHERSTACK_API_KEY"))
+		openWeatherMapClient = openweathermap.NewClient("", os.Getenv("OPENWEATHERMAP_API_KEY"))
+		nwsClient = nws.NewClient("", os.Getenv("NWS_USER_AGENT"))
+	}
+
+	store, err := NewStore(logger)
+	if err != nil {
+		return nil, fmt.Errorf("NewStore error: %v", err)
+	}
+
+	store, err = cacheConfig(store, logger)
+	if err != nil {
+		return nil, fmt.Errorf("cacheConfig error: %v", err)
+	}
+
+	ws := weatherapi.NewWeatherService(weatherStackClient, openWeatherMapClient, store)
+	ws.SetLogger(logger)
+	if nwsClient != nil {
+		ws.SetNWSClient(nwsClient)
+	}
+
+	if method := os.Getenv("WEATHER_CONSENSUS"); method != "" {
+		consensus, err := consensusConfig(method)
+		if err != nil {
+			return nil, fmt.Errorf("consensusConfig error: %v", err)
+		}
+		ws.SetConsensus(consensus)
+	}
+
+	quotas, err := quotaConfig()
+	if err != nil {
+		return nil, fmt.Errorf("quotaConfig error: %v", err)
+	}
+	ws.SetQuotas(quotas)
+
+	limiters, err := rateLimitConfig()
+	if err != nil {
+		return nil, fmt.Errorf("rateLimitConfig error: %v", err)
+	}
+	ws.SetRateLimiters(limiters)
+
+	retention, err := retentionConfig()
+	if err != nil {
+		return nil, fmt.Errorf("retentionConfig error: %v", err)
+	}
+	ws.SetRetentionPolicy(retention)
+
+	if ttl := os.Getenv("FORECAST_TTL"); ttl != "" {
+		forecastTTL, err := time.ParseDuration(ttl)
+		if err != nil {
+			return nil, fmt.Errorf("FORECAST_TTL error: %v", err)
+		}
+		ws.SetForecastTTL(forecastTTL)
+	}
+
+	if ttl := os.Getenv("AIR_QUALITY_TTL"); ttl != "" {
+		airQualityTTL, err := time.ParseDuration(ttl)
+		if err != nil {
+			return nil, fmt.Errorf("AIR_QUALITY_TTL error: %v", err)
+		}
+		ws.SetAirQualityTTL(airQualityTTL)
+	}
+
+	ws.SetWeatherStackLanguage(os.Getenv("WEATHERSTACK_LANGUAGE") == "true")
+
+	return ws, nil
+}
+
+// NewStore opens the storage backend selected by WEATHER_STORE, postgres by default
+func NewStore(logger *logrus.Logger) (storage.Store, error) {
+	switch os.Getenv("WEATHER_STORE") {
+	case "", "postgres":
+		config, err := postgres.LoadConfig(os.Getenv)
+		if err != nil {
+			return nil, fmt.Errorf("postgres.LoadConfig error: %v", err)
+		}
+		if logger != nil {
+			for _, warning := range config.Warnings {
+				logger.Warnf("postgres.LoadConfig: %s\n", warning)
+			}
+		}
+		// Connects lazily, a database that's down at cold start leaves the service running without its cache
+		postgresClient, err := postgres.NewClient(config)
+		if err != nil {
+			return nil, fmt.Errorf("postgres.NewClient error: %v", err)
+		}
+		return postgresClient, nil
+	case "sqlite":
+		sqlitePath := os.Getenv("SQLITE_PATH")
+		if sqlitePath == "" {
+			sqlitePath = "weather.db"
+		}
+		sqliteClient, err := sqlite.NewClient(sqlitePath)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite.NewClient error: %v", err)
+		}
+		return sqliteClient, nil
+	case "dynamodb":
+		api, err := dynamo.NewDynamoDB(context.Background(), os.Getenv("DYNAMODB_ENDPOINT"))
+		if err != nil {
+			return nil, fmt.Errorf("dynamo.NewDynamoDB error: %v", err)
+		}
+		table := os.Getenv("DYNAMODB_TABLE")
+		if table == "" {
+			table = "weather"
+		}
+		dynamoClient := dynamo.NewClient(api, table)
+		if ttl := os.Getenv("DYNAMODB_LATEST_TTL"); ttl != "" {
+			latestTTL, err := time.ParseDuration(ttl)
+			if err != nil {
+				return nil, fmt.Errorf("DYNAMODB_LATEST_TTL error: %v", err)
+			}
+			dynamoClient.SetLatestTTL(latestTTL)
+		}
+		return dynamoClient, nil
+	case "memory":
+		return memory.NewStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown WEATHER_STORE %q", os.Getenv("WEATHER_STORE"))
+	}
+}
+
+// cacheConfig puts the optional memory (CACHE_MEMORY_TTL) and redis (REDIS_URL) cache tiers in front of the store
+func cacheConfig(store storage.Store, logger *logrus.Logger) (storage.Store, error) {
+	tiers := []cache.Tier{}
+
+	if ttl := os.Getenv("CACHE_MEMORY_TTL"); ttl != "" {
+		memoryTTL, err := time.ParseDuration(ttl)
+		if err != nil {
+			return nil, fmt.Errorf("CACHE_MEMORY_TTL error: %v", err)
+		}
+		tiers = append(tiers, cache.NewMemory(memoryTTL))
+	}
+
+	if redisURL := os.Getenv("REDIS_URL"); redisURL != "" {
+		client, err := redis.ParseURL(redisURL)
+		if err != nil {
+			return nil, fmt.Errorf("redis.ParseURL error: %v", err)
+		}
+
+		// Long enough to serve stale data from when providers can't be used
+		redisTTL := weatherapi.MAX_STALE_SECONDS * time.Second
+		if ttl := os.Getenv("REDIS_TTL"); ttl != "" {
+			redisTTL, err = time.ParseDuration(ttl)
+			if err != nil {
+				return nil, fmt.Errorf("REDIS_TTL error: %v", err)
+			}
+		}
+		tiers = append(tiers, cache.NewRedis(client, redisTTL))
+	}
+
+	if len(tiers) == 0 {
+		return store, nil
+	}
+
+	cachedStore := cache.NewStore(store, tiers...)
+	cachedStore.SetLogger(logger)
+	return cachedStore, nil
+}
+
+// providerEnvPrefixes are the env var prefixes of each provider's settings
+var providerEnvPrefixes = map[string]string{
+	weatherapi.WeatherStackSource:   "WEATHERSTACK",
+	weatherapi.OpenWeatherMapSource: "OPENWEATHERMAP",
+}
+
+// quotaConfig reads each provider's monthly limit and soft limit from env, unset is unlimited
+func quotaConfig() (map[string]weatherapi.ProviderQuota, error) {
+	quotas := map[string]weatherapi.ProviderQuota{}
+	for source, prefix := range providerEnvPrefixes {
+		quota := weatherapi.ProviderQuota{}
+		var err error
+		if limit := os.Getenv(prefix + "_MONTHLY_LIMIT"); limit != "" {
+			quota.Limit, err = strconv.Atoi(limit)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if softLimit := os.Getenv(prefix + "_SOFT_LIMIT"); softLimit != "" {
+			quota.SoftLimit, err = strconv.Atoi(softLimit)
+			if err != nil {
+				return nil, err
+			}
+		}
+		quotas[source] = quota
+	}
+
+	return quotas, nil
+}
+
+// consensusConfig builds the consensus config from env,
+// weights are formatted as source=weight pairs (e.g. weatherstack=2,openweathermap=1)
+func consensusConfig(method string) (*weatherapi.ConsensusConfig, error) {
+	if method != weatherapi.ConsensusMedian && method != weatherapi.ConsensusWeighted {
+		return nil, fmt.Errorf("unknown consensus method %q", method)
+	}
+	config := &weatherapi.ConsensusConfig{
+		Method:  method,
+		Weights: map[string]float64{},
+	}
+
+	if weights := os.Getenv("WEATHER_CONSENSUS_WEIGHTS"); weights != "" {
+		for _, pair := range strings.Split(weights, ",") {
+			parts := strings.SplitN(pair, "=", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("invalid weight %q", pair)
+			}
+			weight, err := strconv.ParseFloat(parts[1], 64)
+			if err != nil {
+				return nil, err
+			}
+			config.Weights[strings.TrimSpace(parts[0])] = weight
+		}
+	}
+
+	var err error
+	if tolerance := os.Getenv("WEATHER_CONSENSUS_TEMPERATURE_TOLERANCE"); tolerance != "" {
+		config.TemperatureTolerance, err = strconv.ParseFloat(tolerance, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if tolerance := os.Getenv("WEATHER_CONSENSUS_WINDSPEED_TOLERANCE"); tolerance != "" {
+		config.WindSpeedTolerance, err = strconv.ParseFloat(tolerance, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return config, nil
+}
+
+// rateLimitConfig builds a limiter for each provider with a rate (e.g. 60/m) set in env
+func rateLimitConfig() (map[string]*ratelimit.Limiter, error) {
+	limiters := map[string]*ratelimit.Limiter{}
+	for source, prefix := range providerEnvPrefixes {
+		rate := os.Getenv(prefix + "_RATE_LIMIT")
+		if rate == "" {
+			continue
+		}
+
+		limit, per, err := ratelimit.ParseRate(rate)
+		if err != nil {
+			return nil, err
+		}
+		limiter := ratelimit.NewLimiter(limit, per)
+
+		if burst := os.Getenv(prefix + "_RATE_LIMIT_BURST"); burst != "" {
+			n, err := strconv.Atoi(burst)
+			if err != nil {
+				return nil, err
+			}
+			limiter.SetBurst(n)
+		}
+		limiter.SetWait(os.Getenv(prefix+"_RATE_LIMIT_WAIT") == "true")
+
+		limiters[source] = limiter
+	}
+
+	return limiters, nil
+}
+
+// retentionConfig reads the weather history retention policy from env, defaulting to 30 days of raw rows rolled up into daily aggregates
+func retentionConfig() (storage.RetentionPolicy, error) {
+	policy := storage.RetentionPolicy{
+		RawRetention: weatherapi.DEFAULT_RAW_RETENTION,
+		Rollup:       os.Getenv("WEATHER_RETENTION_ROLLUP") != "false",
+	}
+
+	var err error
+	if retention := os.Getenv("WEATHER_RAW_RETENTION"); retention != "" {
+		policy.RawRetention, err = time.ParseDuration(retention)
+		if err != nil {
+			return policy, err
+		}
+	}
+	if batchSize := os.Getenv("WEATHER_PRUNE_BATCH_SIZE"); batchSize != "" {
+		policy.BatchSize, err = strconv.Atoi(batchSize)
+		if err != nil {
+			return policy, err
+		}
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,117 @@
+package weatherapi
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/TomSED/weather-api/pkg/conditions"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// SetWeatherStackLanguage forwards requested languages to weatherstack, which only localizes on paid plans
+func (ws *WeatherService) SetWeatherStackLanguage(enabled bool) {
+	ws.weatherStackLanguage = enabled
+}
+
+// requestLanguage returns the language of the lang query parameter, otherwise the preferred language of the
+// Accept-Language header conditions can be described in, otherwise English. Languages are ISO 639-1 codes, region
+// subtags are dropped. ok is false if lang isn't a language tag.
+func requestLanguage(e events.APIGatewayProxyRequest) (_ string, ok bool) {
+	if lang, exist := e.QueryStringParameters["lang"]; exist && lang != "" {
+		language := primaryLanguage(lang)
+		return language, language != ""
+	}
+
+	for name, value := range e.Headers {
+		if strings.EqualFold(name, "Accept-Language") {
+			return acceptLanguage(value), true
+		}
+	}
+	return conditions.English, true
+}
+
+// acceptLanguage returns the supported language an Accept-Language header prefers most, English if there is none
+func acceptLanguage(header string) string {
+	type preference struct {
+		language string
+		quality  float64
+	}
+
+	preferences := []preference{}
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		language := primaryLanguage(fields[0])
+		if !conditions.Supported(language) {
+			continue
+		}
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+				if err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			preferences = append(preferences, preference{language: language, quality: quality})
+		}
+	}
+	if len(preferences) == 0 {
+		return conditions.English
+	}
+
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].quality > preferences[j].quality
+	})
+	return preferences[0].language
+}
+
+// primaryLanguage returns the lowercased primary subtag of a language tag, e.g. "pt" of "pt-BR", empty if it isn't one
+func primaryLanguage(tag string) string {
+	tag = strings.TrimSpace(tag)
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if len(tag) < 2 || len(tag) > 3 {
+		return ""
+	}
+	for _, r := range tag {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return ""
+		}
+	}
+	return strings.ToLower(tag)
+}
+
+// providerLanguage is the language to request from the providers, empty for their default of English
+func providerLanguage(language string) string {
+	if language == conditions.English {
+		return ""
+	}
+	return language
+}
+
+// describeCondition returns the observation's condition described in the language from the catalogs, otherwise the
+// provider's description if it's in the language, otherwise in English. Conditions the catalogs don't know fall back
+// to the provider's description in whatever language it's in. language is the language described in.
+func describeCondition(data *storage.WeatherData, requested string) (text string, language string) {
+	condition := conditions.Condition(data.Condition)
+
+	if text := condition.TextIn(requested, data.Night); text != "" {
+		return text, requested
+	}
+	if data.Description != "" && data.DescriptionLanguage == requested {
+		return data.Description, requested
+	}
+	if text := condition.Text(data.Night); text != "" {
+		return text, conditions.English
+	}
+	if data.Description != "" && data.DescriptionLanguage != "" {
+		return data.Description, data.DescriptionLanguage
+	}
+	return "", conditions.English
+}
@@ -0,0 +1,218 @@
+package weatherapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	weatherapi "github.com/TomSED/weather-api"
+	"github.com/TomSED/weather-api/mocks"
+	"github.com/TomSED/weather-api/pkg/openweathermap"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/TomSED/weather-api/pkg/weatherstack"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func cachedStore(data *storage.WeatherData) *mocks.StoreMock {
+	return &mocks.StoreMock{
+		GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+			return data, nil
+		},
+		InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+			return true, nil
+		},
+	}
+}
+
+func TestGetWeatherLanguage(t *testing.T) {
+
+	sunny := &storage.WeatherData{DataSource: "weatherstack", Condition: "clear", Description: "Sunny", DescriptionLanguage: "en", UpdatedDate: time.Now()}
+
+	t.Run("It should describe the condition in the lang query parameter from the catalogs", func(t *testing.T) {
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, cachedStore(sunny))
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+				"lang": "fr-CA",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		weather := decodeWeatherResponse(t, resp.Body)
+		assert.Equal(t, "Ensoleillé", weather.ConditionText)
+		assert.Equal(t, "fr", weather.Language)
+	})
+
+	t.Run("Without lang, it should use the most preferred supported language of the Accept-Language header", func(t *testing.T) {
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, cachedStore(sunny))
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+			Headers: map[string]string{
+				"accept-language": "sv-SE, en;q=0.5, de;q=0.8, fr;q=0",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		weather := decodeWeatherResponse(t, resp.Body)
+		assert.Equal(t, "Sonnig", weather.ConditionText)
+		assert.Equal(t, "de", weather.Language)
+	})
+
+	t.Run("It should default to English", func(t *testing.T) {
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, cachedStore(sunny))
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		weather := decodeWeatherResponse(t, resp.Body)
+		assert.Equal(t, "Sunny", weather.ConditionText)
+		assert.Equal(t, "en", weather.Language)
+	})
+
+	t.Run("For languages without a catalog, it should use the provider's localized description", func(t *testing.T) {
+		var inserted *storage.WeatherData
+		mockStore := cachedStore(nil)
+		mockStore.InsertWeatherDataFunc = func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+			inserted = in1
+			return true, nil
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				return nil, errors.New("weatherstack error")
+			},
+		}
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				resp := &openweathermap.APIResponse{}
+				err := json.Unmarshal([]byte(`{"weather":[{"id":800,"main":"Clear","description":"klar himmel","icon":"01d"}]}`), resp)
+				return resp, err
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+				"lang": "sv",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		weather := decodeWeatherResponse(t, resp.Body)
+		assert.Equal(t, "klar himmel", weather.ConditionText)
+		assert.Equal(t, "sv", weather.Language)
+
+		// weatherstack only localizes on paid plans
+		if assert.Len(t, mockWeatherStackClient.GetWeatherCalls(), 1) {
+			assert.Equal(t, "", mockWeatherStackClient.GetWeatherCalls()[0].Language)
+		}
+		if assert.Len(t, mockOpenWeatherMapClient.GetWeatherCalls(), 1) {
+			assert.Equal(t, "sv", mockOpenWeatherMapClient.GetWeatherCalls()[0].Language)
+		}
+		if assert.NotNil(t, inserted) {
+			assert.Equal(t, "klar himmel", inserted.Description)
+			assert.Equal(t, "sv", inserted.DescriptionLanguage)
+		}
+	})
+
+	t.Run("If the description isn't in a language without a catalog, it should fall back to English", func(t *testing.T) {
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, cachedStore(sunny))
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+				"lang": "sv",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		weather := decodeWeatherResponse(t, resp.Body)
+		assert.Equal(t, "Sunny", weather.ConditionText)
+		assert.Equal(t, "en", weather.Language)
+	})
+
+	t.Run("If the catalogs don't know the condition, it should use the provider's description", func(t *testing.T) {
+		volcanicAsh := &storage.WeatherData{DataSource: "openweathermap", Condition: "volcanic_ash", Description: "volcanic ash", DescriptionLanguage: "en", UpdatedDate: time.Now()}
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, cachedStore(volcanicAsh))
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+				"lang": "fr",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		weather := decodeWeatherResponse(t, resp.Body)
+		assert.Equal(t, "volcanic ash", weather.ConditionText)
+		assert.Equal(t, "en", weather.Language)
+	})
+
+	t.Run("If enabled, it should forward the language to weatherstack", func(t *testing.T) {
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				resp := &weatherstack.APIResponse{}
+				resp.Request.Language = language
+				resp.Current.WeatherCode = 113
+				resp.Current.WeatherDescriptions = []string{"Soleado"}
+				return resp, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, &mocks.OpenWeatherMapClientMock{}, cachedStore(nil))
+		mockWeatherService.SetWeatherStackLanguage(true)
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+				"lang": "es",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		if assert.Len(t, mockWeatherStackClient.GetWeatherCalls(), 1) {
+			assert.Equal(t, "es", mockWeatherStackClient.GetWeatherCalls()[0].Language)
+		}
+		assert.Equal(t, "es", decodeWeatherResponse(t, resp.Body).Language)
+	})
+
+	t.Run("If lang isn't a language tag, it should return a 400 error", func(t *testing.T) {
+		mockStore := cachedStore(sunny)
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+				"lang": "f1",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 400, resp.StatusCode)
+		assert.Len(t, mockStore.GetLatestWeatherDataCalls(), 0)
+	})
+}
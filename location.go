@@ -0,0 +1,52 @@
+package weatherapi
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+)
+
+// locate returns where the city is, geocoding it with openweathermap (counted against its quota) the first time and
+// storing the result
+func (ws *WeatherService) locate(ctx context.Context, city string) (*storage.Location, error) {
+	key := strings.ToLower(city)
+
+	location, err := ws.store.GetLocation(ctx, key)
+	if err != nil {
+		ws.logStoreError("GetLocation", err)
+	} else if location != nil {
+		return location, nil
+	}
+
+	err = ws.beforeCall(ctx, OpenWeatherMapSource)
+	if err != nil {
+		if ws.logger != nil {
+			ws.logger.Errorf("%s Geocode error: %v\n", OpenWeatherMapSource, err)
+		}
+		return nil, err
+	}
+	place, err := ws.openWeatherMapClient.Geocode(city)
+	if err != nil {
+		if ws.logger != nil {
+			ws.logger.Errorf("%s Geocode error: %v\n", OpenWeatherMapSource, err)
+		}
+		return nil, err
+	}
+
+	location = &storage.Location{
+		City:       key,
+		Name:       place.Name,
+		Country:    place.Country,
+		Lat:        place.Lat,
+		Lon:        place.Lon,
+		GeocodedAt: time.Now().UTC(),
+	}
+	err = ws.store.InsertLocation(ctx, location)
+	if err != nil {
+		// Non-blocking error, the city is geocoded again next time
+		ws.logStoreError("InsertLocation", err)
+	}
+	return location, nil
+}
@@ -0,0 +1,593 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mocks
+
+import (
+	"context"
+	"github.com/TomSED/weather-api/pkg/dynamo"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"sync"
+)
+
+// Ensure, that DynamoDBAPIMock does implement dynamo.DynamoDBAPI.
+// If this is not the case, regenerate this file with moq.
+var _ dynamo.DynamoDBAPI = &DynamoDBAPIMock{}
+
+// DynamoDBAPIMock is a mock implementation of dynamo.DynamoDBAPI.
+//
+//	func TestSomethingThatUsesDynamoDBAPI(t *testing.T) {
+//
+//		// make and configure a mocked dynamo.DynamoDBAPI
+//		mockedDynamoDBAPI := &DynamoDBAPIMock{
+//			BatchWriteItemFunc: func(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+//				panic("mock out the BatchWriteItem method")
+//			},
+//			CreateTableFunc: func(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+//				panic("mock out the CreateTable method")
+//			},
+//			DescribeTableFunc: func(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+//				panic("mock out the DescribeTable method")
+//			},
+//			DescribeTimeToLiveFunc: func(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+//				panic("mock out the DescribeTimeToLive method")
+//			},
+//			GetItemFunc: func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+//				panic("mock out the GetItem method")
+//			},
+//			PutItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+//				panic("mock out the PutItem method")
+//			},
+//			QueryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+//				panic("mock out the Query method")
+//			},
+//			ScanFunc: func(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+//				panic("mock out the Scan method")
+//			},
+//			UpdateItemFunc: func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+//				panic("mock out the UpdateItem method")
+//			},
+//			UpdateTimeToLiveFunc: func(ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error) {
+//				panic("mock out the UpdateTimeToLive method")
+//			},
+//		}
+//
+//		// use mockedDynamoDBAPI in code that requires dynamo.DynamoDBAPI
+//		// and then make assertions.
+//
+//	}
+type DynamoDBAPIMock struct {
+	// BatchWriteItemFunc mocks the BatchWriteItem method.
+	BatchWriteItemFunc func(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+
+	// CreateTableFunc mocks the CreateTable method.
+	CreateTableFunc func(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error)
+
+	// DescribeTableFunc mocks the DescribeTable method.
+	DescribeTableFunc func(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+
+	// DescribeTimeToLiveFunc mocks the DescribeTimeToLive method.
+	DescribeTimeToLiveFunc func(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error)
+
+	// GetItemFunc mocks the GetItem method.
+	GetItemFunc func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+
+	// PutItemFunc mocks the PutItem method.
+	PutItemFunc func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+
+	// QueryFunc mocks the Query method.
+	QueryFunc func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+
+	// ScanFunc mocks the Scan method.
+	ScanFunc func(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+
+	// UpdateItemFunc mocks the UpdateItem method.
+	UpdateItemFunc func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+
+	// UpdateTimeToLiveFunc mocks the UpdateTimeToLive method.
+	UpdateTimeToLiveFunc func(ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// BatchWriteItem holds details about calls to the BatchWriteItem method.
+		BatchWriteItem []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Params is the params argument value.
+			Params *dynamodb.BatchWriteItemInput
+			// OptFns is the optFns argument value.
+			OptFns []func(*dynamodb.Options)
+		}
+		// CreateTable holds details about calls to the CreateTable method.
+		CreateTable []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Params is the params argument value.
+			Params *dynamodb.CreateTableInput
+			// OptFns is the optFns argument value.
+			OptFns []func(*dynamodb.Options)
+		}
+		// DescribeTable holds details about calls to the DescribeTable method.
+		DescribeTable []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Params is the params argument value.
+			Params *dynamodb.DescribeTableInput
+			// OptFns is the optFns argument value.
+			OptFns []func(*dynamodb.Options)
+		}
+		// DescribeTimeToLive holds details about calls to the DescribeTimeToLive method.
+		DescribeTimeToLive []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Params is the params argument value.
+			Params *dynamodb.DescribeTimeToLiveInput
+			// OptFns is the optFns argument value.
+			OptFns []func(*dynamodb.Options)
+		}
+		// GetItem holds details about calls to the GetItem method.
+		GetItem []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Params is the params argument value.
+			Params *dynamodb.GetItemInput
+			// OptFns is the optFns argument value.
+			OptFns []func(*dynamodb.Options)
+		}
+		// PutItem holds details about calls to the PutItem method.
+		PutItem []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Params is the params argument value.
+			Params *dynamodb.PutItemInput
+			// OptFns is the optFns argument value.
+			OptFns []func(*dynamodb.Options)
+		}
+		// Query holds details about calls to the Query method.
+		Query []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Params is the params argument value.
+			Params *dynamodb.QueryInput
+			// OptFns is the optFns argument value.
+			OptFns []func(*dynamodb.Options)
+		}
+		// Scan holds details about calls to the Scan method.
+		Scan []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Params is the params argument value.
+			Params *dynamodb.ScanInput
+			// OptFns is the optFns argument value.
+			OptFns []func(*dynamodb.Options)
+		}
+		// UpdateItem holds details about calls to the UpdateItem method.
+		UpdateItem []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Params is the params argument value.
+			Params *dynamodb.UpdateItemInput
+			// OptFns is the optFns argument value.
+			OptFns []func(*dynamodb.Options)
+		}
+		// UpdateTimeToLive holds details about calls to the UpdateTimeToLive method.
+		UpdateTimeToLive []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Params is the params argument value.
+			Params *dynamodb.UpdateTimeToLiveInput
+			// OptFns is the optFns argument value.
+			OptFns []func(*dynamodb.Options)
+		}
+	}
+	lockBatchWriteItem     sync.RWMutex
+	lockCreateTable        sync.RWMutex
+	lockDescribeTable      sync.RWMutex
+	lockDescribeTimeToLive sync.RWMutex
+	lockGetItem            sync.RWMutex
+	lockPutItem            sync.RWMutex
+	lockQuery              sync.RWMutex
+	lockScan               sync.RWMutex
+	lockUpdateItem         sync.RWMutex
+	lockUpdateTimeToLive   sync.RWMutex
+}
+
+// BatchWriteItem calls BatchWriteItemFunc.
+func (mock *DynamoDBAPIMock) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	if mock.BatchWriteItemFunc == nil {
+		panic("DynamoDBAPIMock.BatchWriteItemFunc: method is nil but DynamoDBAPI.BatchWriteItem was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Params *dynamodb.BatchWriteItemInput
+		OptFns []func(*dynamodb.Options)
+	}{
+		Ctx:    ctx,
+		Params: params,
+		OptFns: optFns,
+	}
+	mock.lockBatchWriteItem.Lock()
+	mock.calls.BatchWriteItem = append(mock.calls.BatchWriteItem, callInfo)
+	mock.lockBatchWriteItem.Unlock()
+	return mock.BatchWriteItemFunc(ctx, params, optFns...)
+}
+
+// BatchWriteItemCalls gets all the calls that were made to BatchWriteItem.
+// Check the length with:
+//
+//	len(mockedDynamoDBAPI.BatchWriteItemCalls())
+func (mock *DynamoDBAPIMock) BatchWriteItemCalls() []struct {
+	Ctx    context.Context
+	Params *dynamodb.BatchWriteItemInput
+	OptFns []func(*dynamodb.Options)
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Params *dynamodb.BatchWriteItemInput
+		OptFns []func(*dynamodb.Options)
+	}
+	mock.lockBatchWriteItem.RLock()
+	calls = mock.calls.BatchWriteItem
+	mock.lockBatchWriteItem.RUnlock()
+	return calls
+}
+
+// CreateTable calls CreateTableFunc.
+func (mock *DynamoDBAPIMock) CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	if mock.CreateTableFunc == nil {
+		panic("DynamoDBAPIMock.CreateTableFunc: method is nil but DynamoDBAPI.CreateTable was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Params *dynamodb.CreateTableInput
+		OptFns []func(*dynamodb.Options)
+	}{
+		Ctx:    ctx,
+		Params: params,
+		OptFns: optFns,
+	}
+	mock.lockCreateTable.Lock()
+	mock.calls.CreateTable = append(mock.calls.CreateTable, callInfo)
+	mock.lockCreateTable.Unlock()
+	return mock.CreateTableFunc(ctx, params, optFns...)
+}
+
+// CreateTableCalls gets all the calls that were made to CreateTable.
+// Check the length with:
+//
+//	len(mockedDynamoDBAPI.CreateTableCalls())
+func (mock *DynamoDBAPIMock) CreateTableCalls() []struct {
+	Ctx    context.Context
+	Params *dynamodb.CreateTableInput
+	OptFns []func(*dynamodb.Options)
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Params *dynamodb.CreateTableInput
+		OptFns []func(*dynamodb.Options)
+	}
+	mock.lockCreateTable.RLock()
+	calls = mock.calls.CreateTable
+	mock.lockCreateTable.RUnlock()
+	return calls
+}
+
+// DescribeTable calls DescribeTableFunc.
+func (mock *DynamoDBAPIMock) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	if mock.DescribeTableFunc == nil {
+		panic("DynamoDBAPIMock.DescribeTableFunc: method is nil but DynamoDBAPI.DescribeTable was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Params *dynamodb.DescribeTableInput
+		OptFns []func(*dynamodb.Options)
+	}{
+		Ctx:    ctx,
+		Params: params,
+		OptFns: optFns,
+	}
+	mock.lockDescribeTable.Lock()
+	mock.calls.DescribeTable = append(mock.calls.DescribeTable, callInfo)
+	mock.lockDescribeTable.Unlock()
+	return mock.DescribeTableFunc(ctx, params, optFns...)
+}
+
+// DescribeTableCalls gets all the calls that were made to DescribeTable.
+// Check the length with:
+//
+//	len(mockedDynamoDBAPI.DescribeTableCalls())
+func (mock *DynamoDBAPIMock) DescribeTableCalls() []struct {
+	Ctx    context.Context
+	Params *dynamodb.DescribeTableInput
+	OptFns []func(*dynamodb.Options)
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Params *dynamodb.DescribeTableInput
+		OptFns []func(*dynamodb.Options)
+	}
+	mock.lockDescribeTable.RLock()
+	calls = mock.calls.DescribeTable
+	mock.lockDescribeTable.RUnlock()
+	return calls
+}
+
+// DescribeTimeToLive calls DescribeTimeToLiveFunc.
+func (mock *DynamoDBAPIMock) DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	if mock.DescribeTimeToLiveFunc == nil {
+		panic("DynamoDBAPIMock.DescribeTimeToLiveFunc: method is nil but DynamoDBAPI.DescribeTimeToLive was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Params *dynamodb.DescribeTimeToLiveInput
+		OptFns []func(*dynamodb.Options)
+	}{
+		Ctx:    ctx,
+		Params: params,
+		OptFns: optFns,
+	}
+	mock.lockDescribeTimeToLive.Lock()
+	mock.calls.DescribeTimeToLive = append(mock.calls.DescribeTimeToLive, callInfo)
+	mock.lockDescribeTimeToLive.Unlock()
+	return mock.DescribeTimeToLiveFunc(ctx, params, optFns...)
+}
+
+// DescribeTimeToLiveCalls gets all the calls that were made to DescribeTimeToLive.
+// Check the length with:
+//
+//	len(mockedDynamoDBAPI.DescribeTimeToLiveCalls())
+func (mock *DynamoDBAPIMock) DescribeTimeToLiveCalls() []struct {
+	Ctx    context.Context
+	Params *dynamodb.DescribeTimeToLiveInput
+	OptFns []func(*dynamodb.Options)
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Params *dynamodb.DescribeTimeToLiveInput
+		OptFns []func(*dynamodb.Options)
+	}
+	mock.lockDescribeTimeToLive.RLock()
+	calls = mock.calls.DescribeTimeToLive
+	mock.lockDescribeTimeToLive.RUnlock()
+	return calls
+}
+
+// GetItem calls GetItemFunc.
+func (mock *DynamoDBAPIMock) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	if mock.GetItemFunc == nil {
+		panic("DynamoDBAPIMock.GetItemFunc: method is nil but DynamoDBAPI.GetItem was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Params *dynamodb.GetItemInput
+		OptFns []func(*dynamodb.Options)
+	}{
+		Ctx:    ctx,
+		Params: params,
+		OptFns: optFns,
+	}
+	mock.lockGetItem.Lock()
+	mock.calls.GetItem = append(mock.calls.GetItem, callInfo)
+	mock.lockGetItem.Unlock()
+	return mock.GetItemFunc(ctx, params, optFns...)
+}
+
+// GetItemCalls gets all the calls that were made to GetItem.
+// Check the length with:
+//
+//	len(mockedDynamoDBAPI.GetItemCalls())
+func (mock *DynamoDBAPIMock) GetItemCalls() []struct {
+	Ctx    context.Context
+	Params *dynamodb.GetItemInput
+	OptFns []func(*dynamodb.Options)
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Params *dynamodb.GetItemInput
+		OptFns []func(*dynamodb.Options)
+	}
+	mock.lockGetItem.RLock()
+	calls = mock.calls.GetItem
+	mock.lockGetItem.RUnlock()
+	return calls
+}
+
+// PutItem calls PutItemFunc.
+func (mock *DynamoDBAPIMock) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if mock.PutItemFunc == nil {
+		panic("DynamoDBAPIMock.PutItemFunc: method is nil but DynamoDBAPI.PutItem was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Params *dynamodb.PutItemInput
+		OptFns []func(*dynamodb.Options)
+	}{
+		Ctx:    ctx,
+		Params: params,
+		OptFns: optFns,
+	}
+	mock.lockPutItem.Lock()
+	mock.calls.PutItem = append(mock.calls.PutItem, callInfo)
+	mock.lockPutItem.Unlock()
+	return mock.PutItemFunc(ctx, params, optFns...)
+}
+
+// PutItemCalls gets all the calls that were made to PutItem.
+// Check the length with:
+//
+//	len(mockedDynamoDBAPI.PutItemCalls())
+func (mock *DynamoDBAPIMock) PutItemCalls() []struct {
+	Ctx    context.Context
+	Params *dynamodb.PutItemInput
+	OptFns []func(*dynamodb.Options)
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Params *dynamodb.PutItemInput
+		OptFns []func(*dynamodb.Options)
+	}
+	mock.lockPutItem.RLock()
+	calls = mock.calls.PutItem
+	mock.lockPutItem.RUnlock()
+	return calls
+}
+
+// Query calls QueryFunc.
+func (mock *DynamoDBAPIMock) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	if mock.QueryFunc == nil {
+		panic("DynamoDBAPIMock.QueryFunc: method is nil but DynamoDBAPI.Query was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Params *dynamodb.QueryInput
+		OptFns []func(*dynamodb.Options)
+	}{
+		Ctx:    ctx,
+		Params: params,
+		OptFns: optFns,
+	}
+	mock.lockQuery.Lock()
+	mock.calls.Query = append(mock.calls.Query, callInfo)
+	mock.lockQuery.Unlock()
+	return mock.QueryFunc(ctx, params, optFns...)
+}
+
+// QueryCalls gets all the calls that were made to Query.
+// Check the length with:
+//
+//	len(mockedDynamoDBAPI.QueryCalls())
+func (mock *DynamoDBAPIMock) QueryCalls() []struct {
+	Ctx    context.Context
+	Params *dynamodb.QueryInput
+	OptFns []func(*dynamodb.Options)
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Params *dynamodb.QueryInput
+		OptFns []func(*dynamodb.Options)
+	}
+	mock.lockQuery.RLock()
+	calls = mock.calls.Query
+	mock.lockQuery.RUnlock()
+	return calls
+}
+
+// Scan calls ScanFunc.
+func (mock *DynamoDBAPIMock) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	if mock.ScanFunc == nil {
+		panic("DynamoDBAPIMock.ScanFunc: method is nil but DynamoDBAPI.Scan was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Params *dynamodb.ScanInput
+		OptFns []func(*dynamodb.Options)
+	}{
+		Ctx:    ctx,
+		Params: params,
+		OptFns: optFns,
+	}
+	mock.lockScan.Lock()
+	mock.calls.Scan = append(mock.calls.Scan, callInfo)
+	mock.lockScan.Unlock()
+	return mock.ScanFunc(ctx, params, optFns...)
+}
+
+// ScanCalls gets all the calls that were made to Scan.
+// Check the length with:
+//
+//	len(mockedDynamoDBAPI.ScanCalls())
+func (mock *DynamoDBAPIMock) ScanCalls() []struct {
+	Ctx    context.Context
+	Params *dynamodb.ScanInput
+	OptFns []func(*dynamodb.Options)
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Params *dynamodb.ScanInput
+		OptFns []func(*dynamodb.Options)
+	}
+	mock.lockScan.RLock()
+	calls = mock.calls.Scan
+	mock.lockScan.RUnlock()
+	return calls
+}
+
+// UpdateItem calls UpdateItemFunc.
+func (mock *DynamoDBAPIMock) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	if mock.UpdateItemFunc == nil {
+		panic("DynamoDBAPIMock.UpdateItemFunc: method is nil but DynamoDBAPI.UpdateItem was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Params *dynamodb.UpdateItemInput
+		OptFns []func(*dynamodb.Options)
+	}{
+		Ctx:    ctx,
+		Params: params,
+		OptFns: optFns,
+	}
+	mock.lockUpdateItem.Lock()
+	mock.calls.UpdateItem = append(mock.calls.UpdateItem, callInfo)
+	mock.lockUpdateItem.Unlock()
+	return mock.UpdateItemFunc(ctx, params, optFns...)
+}
+
+// UpdateItemCalls gets all the calls that were made to UpdateItem.
+// Check the length with:
+//
+//	len(mockedDynamoDBAPI.UpdateItemCalls())
+func (mock *DynamoDBAPIMock) UpdateItemCalls() []struct {
+	Ctx    context.Context
+	Params *dynamodb.UpdateItemInput
+	OptFns []func(*dynamodb.Options)
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Params *dynamodb.UpdateItemInput
+		OptFns []func(*dynamodb.Options)
+	}
+	mock.lockUpdateItem.RLock()
+	calls = mock.calls.UpdateItem
+	mock.lockUpdateItem.RUnlock()
+	return calls
+}
+
+// UpdateTimeToLive calls UpdateTimeToLiveFunc.
+func (mock *DynamoDBAPIMock) UpdateTimeToLive(ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	if mock.UpdateTimeToLiveFunc == nil {
+		panic("DynamoDBAPIMock.UpdateTimeToLiveFunc: method is nil but DynamoDBAPI.UpdateTimeToLive was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Params *dynamodb.UpdateTimeToLiveInput
+		OptFns []func(*dynamodb.Options)
+	}{
+		Ctx:    ctx,
+		Params: params,
+		OptFns: optFns,
+	}
+	mock.lockUpdateTimeToLive.Lock()
+	mock.calls.UpdateTimeToLive = append(mock.calls.UpdateTimeToLive, callInfo)
+	mock.lockUpdateTimeToLive.Unlock()
+	return mock.UpdateTimeToLiveFunc(ctx, params, optFns...)
+}
+
+// UpdateTimeToLiveCalls gets all the calls that were made to UpdateTimeToLive.
+// Check the length with:
+//
+//	len(mockedDynamoDBAPI.UpdateTimeToLiveCalls())
+func (mock *DynamoDBAPIMock) UpdateTimeToLiveCalls() []struct {
+	Ctx    context.Context
+	Params *dynamodb.UpdateTimeToLiveInput
+	OptFns []func(*dynamodb.Options)
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Params *dynamodb.UpdateTimeToLiveInput
+		OptFns []func(*dynamodb.Options)
+	}
+	mock.lockUpdateTimeToLive.RLock()
+	calls = mock.calls.UpdateTimeToLive
+	mock.lockUpdateTimeToLive.RUnlock()
+	return calls
+}
@@ -0,0 +1,82 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mocks
+
+import (
+	"github.com/TomSED/weather-api"
+	"github.com/TomSED/weather-api/pkg/nws"
+	"sync"
+)
+
+// Ensure, that NWSClientMock does implement weatherapi.NWSClient.
+// If this is not the case, regenerate this file with moq.
+var _ weatherapi.NWSClient = &NWSClientMock{}
+
+// NWSClientMock is a mock implementation of weatherapi.NWSClient.
+//
+//	func TestSomethingThatUsesNWSClient(t *testing.T) {
+//
+//		// make and configure a mocked weatherapi.NWSClient
+//		mockedNWSClient := &NWSClientMock{
+//			GetActiveAlertsFunc: func(lat float64, lon float64) (*nws.AlertsResponse, error) {
+//				panic("mock out the GetActiveAlerts method")
+//			},
+//		}
+//
+//		// use mockedNWSClient in code that requires weatherapi.NWSClient
+//		// and then make assertions.
+//
+//	}
+type NWSClientMock struct {
+	// GetActiveAlertsFunc mocks the GetActiveAlerts method.
+	GetActiveAlertsFunc func(lat float64, lon float64) (*nws.AlertsResponse, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// GetActiveAlerts holds details about calls to the GetActiveAlerts method.
+		GetActiveAlerts []struct {
+			// Lat is the lat argument value.
+			Lat float64
+			// Lon is the lon argument value.
+			Lon float64
+		}
+	}
+	lockGetActiveAlerts sync.RWMutex
+}
+
+// GetActiveAlerts calls GetActiveAlertsFunc.
+func (mock *NWSClientMock) GetActiveAlerts(lat float64, lon float64) (*nws.AlertsResponse, error) {
+	if mock.GetActiveAlertsFunc == nil {
+		panic("NWSClientMock.GetActiveAlertsFunc: method is nil but NWSClient.GetActiveAlerts was just called")
+	}
+	callInfo := struct {
+		Lat float64
+		Lon float64
+	}{
+		Lat: lat,
+		Lon: lon,
+	}
+	mock.lockGetActiveAlerts.Lock()
+	mock.calls.GetActiveAlerts = append(mock.calls.GetActiveAlerts, callInfo)
+	mock.lockGetActiveAlerts.Unlock()
+	return mock.GetActiveAlertsFunc(lat, lon)
+}
+
+// GetActiveAlertsCalls gets all the calls that were made to GetActiveAlerts.
+// Check the length with:
+//
+//	len(mockedNWSClient.GetActiveAlertsCalls())
+func (mock *NWSClientMock) GetActiveAlertsCalls() []struct {
+	Lat float64
+	Lon float64
+} {
+	var calls []struct {
+		Lat float64
+		Lon float64
+	}
+	mock.lockGetActiveAlerts.RLock()
+	calls = mock.calls.GetActiveAlerts
+	mock.lockGetActiveAlerts.RUnlock()
+	return calls
+}
@@ -9,70 +9,268 @@ import (
 	"sync"
 )
 
-var (
-	lockOpenWeatherMapClientMockGetWeather sync.RWMutex
-)
-
 // Ensure, that OpenWeatherMapClientMock does implement weatherapi.OpenWeatherMapClient.
 // If this is not the case, regenerate this file with moq.
 var _ weatherapi.OpenWeatherMapClient = &OpenWeatherMapClientMock{}
 
 // OpenWeatherMapClientMock is a mock implementation of weatherapi.OpenWeatherMapClient.
 //
-//     func TestSomethingThatUsesOpenWeatherMapClient(t *testing.T) {
+//	func TestSomethingThatUsesOpenWeatherMapClient(t *testing.T) {
 //
-//         // make and configure a mocked weatherapi.OpenWeatherMapClient
-//         mockedOpenWeatherMapClient := &OpenWeatherMapClientMock{
-//             GetWeatherFunc: func(city string) (*openweathermap.APIResponse, error) {
-// 	               panic("mock out the GetWeather method")
-//             },
-//         }
+//		// make and configure a mocked weatherapi.OpenWeatherMapClient
+//		mockedOpenWeatherMapClient := &OpenWeatherMapClientMock{
+//			GeocodeFunc: func(city string) (*openweathermap.GeocodeResult, error) {
+//				panic("mock out the Geocode method")
+//			},
+//			GetAirPollutionFunc: func(lat float64, lon float64) (*openweathermap.AirPollutionResponse, error) {
+//				panic("mock out the GetAirPollution method")
+//			},
+//			GetAlertsFunc: func(lat float64, lon float64) (*openweathermap.AlertsResponse, error) {
+//				panic("mock out the GetAlerts method")
+//			},
+//			GetForecastFunc: func(city string, days int) (*openweathermap.ForecastResponse, error) {
+//				panic("mock out the GetForecast method")
+//			},
+//			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+//				panic("mock out the GetWeather method")
+//			},
+//		}
 //
-//         // use mockedOpenWeatherMapClient in code that requires weatherapi.OpenWeatherMapClient
-//         // and then make assertions.
+//		// use mockedOpenWeatherMapClient in code that requires weatherapi.OpenWeatherMapClient
+//		// and then make assertions.
 //
-//     }
+//	}
 type OpenWeatherMapClientMock struct {
+	// GeocodeFunc mocks the Geocode method.
+	GeocodeFunc func(city string) (*openweathermap.GeocodeResult, error)
+
+	// GetAirPollutionFunc mocks the GetAirPollution method.
+	GetAirPollutionFunc func(lat float64, lon float64) (*openweathermap.AirPollutionResponse, error)
+
+	// GetAlertsFunc mocks the GetAlerts method.
+	GetAlertsFunc func(lat float64, lon float64) (*openweathermap.AlertsResponse, error)
+
+	// GetForecastFunc mocks the GetForecast method.
+	GetForecastFunc func(city string, days int) (*openweathermap.ForecastResponse, error)
+
 	// GetWeatherFunc mocks the GetWeather method.
-	GetWeatherFunc func(city string) (*openweathermap.APIResponse, error)
+	GetWeatherFunc func(city string, language string) (*openweathermap.APIResponse, error)
 
 	// calls tracks calls to the methods.
 	calls struct {
+		// Geocode holds details about calls to the Geocode method.
+		Geocode []struct {
+			// City is the city argument value.
+			City string
+		}
+		// GetAirPollution holds details about calls to the GetAirPollution method.
+		GetAirPollution []struct {
+			// Lat is the lat argument value.
+			Lat float64
+			// Lon is the lon argument value.
+			Lon float64
+		}
+		// GetAlerts holds details about calls to the GetAlerts method.
+		GetAlerts []struct {
+			// Lat is the lat argument value.
+			Lat float64
+			// Lon is the lon argument value.
+			Lon float64
+		}
+		// GetForecast holds details about calls to the GetForecast method.
+		GetForecast []struct {
+			// City is the city argument value.
+			City string
+			// Days is the days argument value.
+			Days int
+		}
 		// GetWeather holds details about calls to the GetWeather method.
 		GetWeather []struct {
 			// City is the city argument value.
 			City string
+			// Language is the language argument value.
+			Language string
 		}
 	}
+	lockGeocode         sync.RWMutex
+	lockGetAirPollution sync.RWMutex
+	lockGetAlerts       sync.RWMutex
+	lockGetForecast     sync.RWMutex
+	lockGetWeather      sync.RWMutex
+}
+
+// Geocode calls GeocodeFunc.
+func (mock *OpenWeatherMapClientMock) Geocode(city string) (*openweathermap.GeocodeResult, error) {
+	if mock.GeocodeFunc == nil {
+		panic("OpenWeatherMapClientMock.GeocodeFunc: method is nil but OpenWeatherMapClient.Geocode was just called")
+	}
+	callInfo := struct {
+		City string
+	}{
+		City: city,
+	}
+	mock.lockGeocode.Lock()
+	mock.calls.Geocode = append(mock.calls.Geocode, callInfo)
+	mock.lockGeocode.Unlock()
+	return mock.GeocodeFunc(city)
+}
+
+// GeocodeCalls gets all the calls that were made to Geocode.
+// Check the length with:
+//
+//	len(mockedOpenWeatherMapClient.GeocodeCalls())
+func (mock *OpenWeatherMapClientMock) GeocodeCalls() []struct {
+	City string
+} {
+	var calls []struct {
+		City string
+	}
+	mock.lockGeocode.RLock()
+	calls = mock.calls.Geocode
+	mock.lockGeocode.RUnlock()
+	return calls
+}
+
+// GetAirPollution calls GetAirPollutionFunc.
+func (mock *OpenWeatherMapClientMock) GetAirPollution(lat float64, lon float64) (*openweathermap.AirPollutionResponse, error) {
+	if mock.GetAirPollutionFunc == nil {
+		panic("OpenWeatherMapClientMock.GetAirPollutionFunc: method is nil but OpenWeatherMapClient.GetAirPollution was just called")
+	}
+	callInfo := struct {
+		Lat float64
+		Lon float64
+	}{
+		Lat: lat,
+		Lon: lon,
+	}
+	mock.lockGetAirPollution.Lock()
+	mock.calls.GetAirPollution = append(mock.calls.GetAirPollution, callInfo)
+	mock.lockGetAirPollution.Unlock()
+	return mock.GetAirPollutionFunc(lat, lon)
+}
+
+// GetAirPollutionCalls gets all the calls that were made to GetAirPollution.
+// Check the length with:
+//
+//	len(mockedOpenWeatherMapClient.GetAirPollutionCalls())
+func (mock *OpenWeatherMapClientMock) GetAirPollutionCalls() []struct {
+	Lat float64
+	Lon float64
+} {
+	var calls []struct {
+		Lat float64
+		Lon float64
+	}
+	mock.lockGetAirPollution.RLock()
+	calls = mock.calls.GetAirPollution
+	mock.lockGetAirPollution.RUnlock()
+	return calls
+}
+
+// GetAlerts calls GetAlertsFunc.
+func (mock *OpenWeatherMapClientMock) GetAlerts(lat float64, lon float64) (*openweathermap.AlertsResponse, error) {
+	if mock.GetAlertsFunc == nil {
+		panic("OpenWeatherMapClientMock.GetAlertsFunc: method is nil but OpenWeatherMapClient.GetAlerts was just called")
+	}
+	callInfo := struct {
+		Lat float64
+		Lon float64
+	}{
+		Lat: lat,
+		Lon: lon,
+	}
+	mock.lockGetAlerts.Lock()
+	mock.calls.GetAlerts = append(mock.calls.GetAlerts, callInfo)
+	mock.lockGetAlerts.Unlock()
+	return mock.GetAlertsFunc(lat, lon)
+}
+
+// GetAlertsCalls gets all the calls that were made to GetAlerts.
+// Check the length with:
+//
+//	len(mockedOpenWeatherMapClient.GetAlertsCalls())
+func (mock *OpenWeatherMapClientMock) GetAlertsCalls() []struct {
+	Lat float64
+	Lon float64
+} {
+	var calls []struct {
+		Lat float64
+		Lon float64
+	}
+	mock.lockGetAlerts.RLock()
+	calls = mock.calls.GetAlerts
+	mock.lockGetAlerts.RUnlock()
+	return calls
+}
+
+// GetForecast calls GetForecastFunc.
+func (mock *OpenWeatherMapClientMock) GetForecast(city string, days int) (*openweathermap.ForecastResponse, error) {
+	if mock.GetForecastFunc == nil {
+		panic("OpenWeatherMapClientMock.GetForecastFunc: method is nil but OpenWeatherMapClient.GetForecast was just called")
+	}
+	callInfo := struct {
+		City string
+		Days int
+	}{
+		City: city,
+		Days: days,
+	}
+	mock.lockGetForecast.Lock()
+	mock.calls.GetForecast = append(mock.calls.GetForecast, callInfo)
+	mock.lockGetForecast.Unlock()
+	return mock.GetForecastFunc(city, days)
+}
+
+// GetForecastCalls gets all the calls that were made to GetForecast.
+// Check the length with:
+//
+//	len(mockedOpenWeatherMapClient.GetForecastCalls())
+func (mock *OpenWeatherMapClientMock) GetForecastCalls() []struct {
+	City string
+	Days int
+} {
+	var calls []struct {
+		City string
+		Days int
+	}
+	mock.lockGetForecast.RLock()
+	calls = mock.calls.GetForecast
+	mock.lockGetForecast.RUnlock()
+	return calls
 }
 
 // GetWeather calls GetWeatherFunc.
-func (mock *OpenWeatherMapClientMock) GetWeather(city string) (*openweathermap.APIResponse, error) {
+func (mock *OpenWeatherMapClientMock) GetWeather(city string, language string) (*openweathermap.APIResponse, error) {
 	if mock.GetWeatherFunc == nil {
 		panic("OpenWeatherMapClientMock.GetWeatherFunc: method is nil but OpenWeatherMapClient.GetWeather was just called")
 	}
 	callInfo := struct {
-		City string
+		City     string
+		Language string
 	}{
-		City: city,
+		City:     city,
+		Language: language,
 	}
-	lockOpenWeatherMapClientMockGetWeather.Lock()
+	mock.lockGetWeather.Lock()
 	mock.calls.GetWeather = append(mock.calls.GetWeather, callInfo)
-	lockOpenWeatherMapClientMockGetWeather.Unlock()
-	return mock.GetWeatherFunc(city)
+	mock.lockGetWeather.Unlock()
+	return mock.GetWeatherFunc(city, language)
 }
 
 // GetWeatherCalls gets all the calls that were made to GetWeather.
 // Check the length with:
-//     len(mockedOpenWeatherMapClient.GetWeatherCalls())
+//
+//	len(mockedOpenWeatherMapClient.GetWeatherCalls())
 func (mock *OpenWeatherMapClientMock) GetWeatherCalls() []struct {
-	City string
+	City     string
+	Language string
 } {
 	var calls []struct {
-		City string
+		City     string
+		Language string
 	}
-	lockOpenWeatherMapClientMockGetWeather.RLock()
+	mock.lockGetWeather.RLock()
 	calls = mock.calls.GetWeather
-	lockOpenWeatherMapClientMockGetWeather.RUnlock()
+	mock.lockGetWeather.RUnlock()
 	return calls
 }
@@ -0,0 +1,906 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mocks
+
+import (
+	"context"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"sync"
+)
+
+// Ensure, that StoreMock does implement storage.Store.
+// If this is not the case, regenerate this file with moq.
+var _ storage.Store = &StoreMock{}
+
+// StoreMock is a mock implementation of storage.Store.
+//
+//	func TestSomethingThatUsesStore(t *testing.T) {
+//
+//		// make and configure a mocked storage.Store
+//		mockedStore := &StoreMock{
+//			GetAirQualityFunc: func(ctx context.Context, city string) (*storage.AirQuality, error) {
+//				panic("mock out the GetAirQuality method")
+//			},
+//			GetAlertsFunc: func(ctx context.Context, key string) (*storage.Alerts, error) {
+//				panic("mock out the GetAlerts method")
+//			},
+//			GetForecastFunc: func(ctx context.Context, city string) (*storage.Forecast, error) {
+//				panic("mock out the GetForecast method")
+//			},
+//			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+//				panic("mock out the GetLatestWeatherData method")
+//			},
+//			GetLatestWeatherDataBatchFunc: func(ctx context.Context, cities []string) (map[string]*storage.WeatherData, error) {
+//				panic("mock out the GetLatestWeatherDataBatch method")
+//			},
+//			GetLocationFunc: func(ctx context.Context, city string) (*storage.Location, error) {
+//				panic("mock out the GetLocation method")
+//			},
+//			GetLocationBatchFunc: func(ctx context.Context, cities []string) (map[string]*storage.Location, error) {
+//				panic("mock out the GetLocationBatch method")
+//			},
+//			GetProviderUsageFunc: func(ctx context.Context, provider string, period string) (int, error) {
+//				panic("mock out the GetProviderUsage method")
+//			},
+//			InsertAirQualityFunc: func(ctx context.Context, airQuality *storage.AirQuality) error {
+//				panic("mock out the InsertAirQuality method")
+//			},
+//			InsertAlertsFunc: func(ctx context.Context, alerts *storage.Alerts) error {
+//				panic("mock out the InsertAlerts method")
+//			},
+//			InsertForecastFunc: func(ctx context.Context, forecast *storage.Forecast) error {
+//				panic("mock out the InsertForecast method")
+//			},
+//			InsertLocationFunc: func(ctx context.Context, location *storage.Location) error {
+//				panic("mock out the InsertLocation method")
+//			},
+//			InsertWeatherDataFunc: func(ctx context.Context, weatherData *storage.WeatherData) (bool, error) {
+//				panic("mock out the InsertWeatherData method")
+//			},
+//			InsertWeatherDataBatchFunc: func(ctx context.Context, weatherData []*storage.WeatherData) (int, error) {
+//				panic("mock out the InsertWeatherDataBatch method")
+//			},
+//			PruneWeatherDataFunc: func(ctx context.Context, policy storage.RetentionPolicy) (*storage.PruneResult, error) {
+//				panic("mock out the PruneWeatherData method")
+//			},
+//			ReleaseProviderCallFunc: func(ctx context.Context, provider string, period string) error {
+//				panic("mock out the ReleaseProviderCall method")
+//			},
+//			ReserveProviderCallFunc: func(ctx context.Context, provider string, period string, limit int) (bool, error) {
+//				panic("mock out the ReserveProviderCall method")
+//			},
+//		}
+//
+//		// use mockedStore in code that requires storage.Store
+//		// and then make assertions.
+//
+//	}
+type StoreMock struct {
+	// GetAirQualityFunc mocks the GetAirQuality method.
+	GetAirQualityFunc func(ctx context.Context, city string) (*storage.AirQuality, error)
+
+	// GetAlertsFunc mocks the GetAlerts method.
+	GetAlertsFunc func(ctx context.Context, key string) (*storage.Alerts, error)
+
+	// GetForecastFunc mocks the GetForecast method.
+	GetForecastFunc func(ctx context.Context, city string) (*storage.Forecast, error)
+
+	// GetLatestWeatherDataFunc mocks the GetLatestWeatherData method.
+	GetLatestWeatherDataFunc func(ctx context.Context, city string) (*storage.WeatherData, error)
+
+	// GetLatestWeatherDataBatchFunc mocks the GetLatestWeatherDataBatch method.
+	GetLatestWeatherDataBatchFunc func(ctx context.Context, cities []string) (map[string]*storage.WeatherData, error)
+
+	// GetLocationFunc mocks the GetLocation method.
+	GetLocationFunc func(ctx context.Context, city string) (*storage.Location, error)
+
+	// GetLocationBatchFunc mocks the GetLocationBatch method.
+	GetLocationBatchFunc func(ctx context.Context, cities []string) (map[string]*storage.Location, error)
+
+	// GetProviderUsageFunc mocks the GetProviderUsage method.
+	GetProviderUsageFunc func(ctx context.Context, provider string, period string) (int, error)
+
+	// InsertAirQualityFunc mocks the InsertAirQuality method.
+	InsertAirQualityFunc func(ctx context.Context, airQuality *storage.AirQuality) error
+
+	// InsertAlertsFunc mocks the InsertAlerts method.
+	InsertAlertsFunc func(ctx context.Context, alerts *storage.Alerts) error
+
+	// InsertForecastFunc mocks the InsertForecast method.
+	InsertForecastFunc func(ctx context.Context, forecast *storage.Forecast) error
+
+	// InsertLocationFunc mocks the InsertLocation method.
+	InsertLocationFunc func(ctx context.Context, location *storage.Location) error
+
+	// InsertWeatherDataFunc mocks the InsertWeatherData method.
+	InsertWeatherDataFunc func(ctx context.Context, weatherData *storage.WeatherData) (bool, error)
+
+	// InsertWeatherDataBatchFunc mocks the InsertWeatherDataBatch method.
+	InsertWeatherDataBatchFunc func(ctx context.Context, weatherData []*storage.WeatherData) (int, error)
+
+	// PruneWeatherDataFunc mocks the PruneWeatherData method.
+	PruneWeatherDataFunc func(ctx context.Context, policy storage.RetentionPolicy) (*storage.PruneResult, error)
+
+	// ReleaseProviderCallFunc mocks the ReleaseProviderCall method.
+	ReleaseProviderCallFunc func(ctx context.Context, provider string, period string) error
+
+	// ReserveProviderCallFunc mocks the ReserveProviderCall method.
+	ReserveProviderCallFunc func(ctx context.Context, provider string, period string, limit int) (bool, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// GetAirQuality holds details about calls to the GetAirQuality method.
+		GetAirQuality []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// City is the city argument value.
+			City string
+		}
+		// GetAlerts holds details about calls to the GetAlerts method.
+		GetAlerts []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Key is the key argument value.
+			Key string
+		}
+		// GetForecast holds details about calls to the GetForecast method.
+		GetForecast []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// City is the city argument value.
+			City string
+		}
+		// GetLatestWeatherData holds details about calls to the GetLatestWeatherData method.
+		GetLatestWeatherData []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// City is the city argument value.
+			City string
+		}
+		// GetLatestWeatherDataBatch holds details about calls to the GetLatestWeatherDataBatch method.
+		GetLatestWeatherDataBatch []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Cities is the cities argument value.
+			Cities []string
+		}
+		// GetLocation holds details about calls to the GetLocation method.
+		GetLocation []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// City is the city argument value.
+			City string
+		}
+		// GetLocationBatch holds details about calls to the GetLocationBatch method.
+		GetLocationBatch []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Cities is the cities argument value.
+			Cities []string
+		}
+		// GetProviderUsage holds details about calls to the GetProviderUsage method.
+		GetProviderUsage []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Provider is the provider argument value.
+			Provider string
+			// Period is the period argument value.
+			Period string
+		}
+		// InsertAirQuality holds details about calls to the InsertAirQuality method.
+		InsertAirQuality []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// AirQuality is the airQuality argument value.
+			AirQuality *storage.AirQuality
+		}
+		// InsertAlerts holds details about calls to the InsertAlerts method.
+		InsertAlerts []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Alerts is the alerts argument value.
+			Alerts *storage.Alerts
+		}
+		// InsertForecast holds details about calls to the InsertForecast method.
+		InsertForecast []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Forecast is the forecast argument value.
+			Forecast *storage.Forecast
+		}
+		// InsertLocation holds details about calls to the InsertLocation method.
+		InsertLocation []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Location is the location argument value.
+			Location *storage.Location
+		}
+		// InsertWeatherData holds details about calls to the InsertWeatherData method.
+		InsertWeatherData []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// WeatherData is the weatherData argument value.
+			WeatherData *storage.WeatherData
+		}
+		// InsertWeatherDataBatch holds details about calls to the InsertWeatherDataBatch method.
+		InsertWeatherDataBatch []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// WeatherData is the weatherData argument value.
+			WeatherData []*storage.WeatherData
+		}
+		// PruneWeatherData holds details about calls to the PruneWeatherData method.
+		PruneWeatherData []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Policy is the policy argument value.
+			Policy storage.RetentionPolicy
+		}
+		// ReleaseProviderCall holds details about calls to the ReleaseProviderCall method.
+		ReleaseProviderCall []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Provider is the provider argument value.
+			Provider string
+			// Period is the period argument value.
+			Period string
+		}
+		// ReserveProviderCall holds details about calls to the ReserveProviderCall method.
+		ReserveProviderCall []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Provider is the provider argument value.
+			Provider string
+			// Period is the period argument value.
+			Period string
+			// Limit is the limit argument value.
+			Limit int
+		}
+	}
+	lockGetAirQuality             sync.RWMutex
+	lockGetAlerts                 sync.RWMutex
+	lockGetForecast               sync.RWMutex
+	lockGetLatestWeatherData      sync.RWMutex
+	lockGetLatestWeatherDataBatch sync.RWMutex
+	lockGetLocation               sync.RWMutex
+	lockGetLocationBatch          sync.RWMutex
+	lockGetProviderUsage          sync.RWMutex
+	lockInsertAirQuality          sync.RWMutex
+	lockInsertAlerts              sync.RWMutex
+	lockInsertForecast            sync.RWMutex
+	lockInsertLocation            sync.RWMutex
+	lockInsertWeatherData         sync.RWMutex
+	lockInsertWeatherDataBatch    sync.RWMutex
+	lockPruneWeatherData          sync.RWMutex
+	lockReleaseProviderCall       sync.RWMutex
+	lockReserveProviderCall       sync.RWMutex
+}
+
+// GetAirQuality calls GetAirQualityFunc.
+func (mock *StoreMock) GetAirQuality(ctx context.Context, city string) (*storage.AirQuality, error) {
+	if mock.GetAirQualityFunc == nil {
+		panic("StoreMock.GetAirQualityFunc: method is nil but Store.GetAirQuality was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		City string
+	}{
+		Ctx:  ctx,
+		City: city,
+	}
+	mock.lockGetAirQuality.Lock()
+	mock.calls.GetAirQuality = append(mock.calls.GetAirQuality, callInfo)
+	mock.lockGetAirQuality.Unlock()
+	return mock.GetAirQualityFunc(ctx, city)
+}
+
+// GetAirQualityCalls gets all the calls that were made to GetAirQuality.
+// Check the length with:
+//
+//	len(mockedStore.GetAirQualityCalls())
+func (mock *StoreMock) GetAirQualityCalls() []struct {
+	Ctx  context.Context
+	City string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		City string
+	}
+	mock.lockGetAirQuality.RLock()
+	calls = mock.calls.GetAirQuality
+	mock.lockGetAirQuality.RUnlock()
+	return calls
+}
+
+// GetAlerts calls GetAlertsFunc.
+func (mock *StoreMock) GetAlerts(ctx context.Context, key string) (*storage.Alerts, error) {
+	if mock.GetAlertsFunc == nil {
+		panic("StoreMock.GetAlertsFunc: method is nil but Store.GetAlerts was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Key string
+	}{
+		Ctx: ctx,
+		Key: key,
+	}
+	mock.lockGetAlerts.Lock()
+	mock.calls.GetAlerts = append(mock.calls.GetAlerts, callInfo)
+	mock.lockGetAlerts.Unlock()
+	return mock.GetAlertsFunc(ctx, key)
+}
+
+// GetAlertsCalls gets all the calls that were made to GetAlerts.
+// Check the length with:
+//
+//	len(mockedStore.GetAlertsCalls())
+func (mock *StoreMock) GetAlertsCalls() []struct {
+	Ctx context.Context
+	Key string
+} {
+	var calls []struct {
+		Ctx context.Context
+		Key string
+	}
+	mock.lockGetAlerts.RLock()
+	calls = mock.calls.GetAlerts
+	mock.lockGetAlerts.RUnlock()
+	return calls
+}
+
+// GetForecast calls GetForecastFunc.
+func (mock *StoreMock) GetForecast(ctx context.Context, city string) (*storage.Forecast, error) {
+	if mock.GetForecastFunc == nil {
+		panic("StoreMock.GetForecastFunc: method is nil but Store.GetForecast was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		City string
+	}{
+		Ctx:  ctx,
+		City: city,
+	}
+	mock.lockGetForecast.Lock()
+	mock.calls.GetForecast = append(mock.calls.GetForecast, callInfo)
+	mock.lockGetForecast.Unlock()
+	return mock.GetForecastFunc(ctx, city)
+}
+
+// GetForecastCalls gets all the calls that were made to GetForecast.
+// Check the length with:
+//
+//	len(mockedStore.GetForecastCalls())
+func (mock *StoreMock) GetForecastCalls() []struct {
+	Ctx  context.Context
+	City string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		City string
+	}
+	mock.lockGetForecast.RLock()
+	calls = mock.calls.GetForecast
+	mock.lockGetForecast.RUnlock()
+	return calls
+}
+
+// GetLatestWeatherData calls GetLatestWeatherDataFunc.
+func (mock *StoreMock) GetLatestWeatherData(ctx context.Context, city string) (*storage.WeatherData, error) {
+	if mock.GetLatestWeatherDataFunc == nil {
+		panic("StoreMock.GetLatestWeatherDataFunc: method is nil but Store.GetLatestWeatherData was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		City string
+	}{
+		Ctx:  ctx,
+		City: city,
+	}
+	mock.lockGetLatestWeatherData.Lock()
+	mock.calls.GetLatestWeatherData = append(mock.calls.GetLatestWeatherData, callInfo)
+	mock.lockGetLatestWeatherData.Unlock()
+	return mock.GetLatestWeatherDataFunc(ctx, city)
+}
+
+// GetLatestWeatherDataCalls gets all the calls that were made to GetLatestWeatherData.
+// Check the length with:
+//
+//	len(mockedStore.GetLatestWeatherDataCalls())
+func (mock *StoreMock) GetLatestWeatherDataCalls() []struct {
+	Ctx  context.Context
+	City string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		City string
+	}
+	mock.lockGetLatestWeatherData.RLock()
+	calls = mock.calls.GetLatestWeatherData
+	mock.lockGetLatestWeatherData.RUnlock()
+	return calls
+}
+
+// GetLatestWeatherDataBatch calls GetLatestWeatherDataBatchFunc.
+func (mock *StoreMock) GetLatestWeatherDataBatch(ctx context.Context, cities []string) (map[string]*storage.WeatherData, error) {
+	if mock.GetLatestWeatherDataBatchFunc == nil {
+		panic("StoreMock.GetLatestWeatherDataBatchFunc: method is nil but Store.GetLatestWeatherDataBatch was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Cities []string
+	}{
+		Ctx:    ctx,
+		Cities: cities,
+	}
+	mock.lockGetLatestWeatherDataBatch.Lock()
+	mock.calls.GetLatestWeatherDataBatch = append(mock.calls.GetLatestWeatherDataBatch, callInfo)
+	mock.lockGetLatestWeatherDataBatch.Unlock()
+	return mock.GetLatestWeatherDataBatchFunc(ctx, cities)
+}
+
+// GetLatestWeatherDataBatchCalls gets all the calls that were made to GetLatestWeatherDataBatch.
+// Check the length with:
+//
+//	len(mockedStore.GetLatestWeatherDataBatchCalls())
+func (mock *StoreMock) GetLatestWeatherDataBatchCalls() []struct {
+	Ctx    context.Context
+	Cities []string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Cities []string
+	}
+	mock.lockGetLatestWeatherDataBatch.RLock()
+	calls = mock.calls.GetLatestWeatherDataBatch
+	mock.lockGetLatestWeatherDataBatch.RUnlock()
+	return calls
+}
+
+// GetLocation calls GetLocationFunc.
+func (mock *StoreMock) GetLocation(ctx context.Context, city string) (*storage.Location, error) {
+	if mock.GetLocationFunc == nil {
+		panic("StoreMock.GetLocationFunc: method is nil but Store.GetLocation was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		City string
+	}{
+		Ctx:  ctx,
+		City: city,
+	}
+	mock.lockGetLocation.Lock()
+	mock.calls.GetLocation = append(mock.calls.GetLocation, callInfo)
+	mock.lockGetLocation.Unlock()
+	return mock.GetLocationFunc(ctx, city)
+}
+
+// GetLocationCalls gets all the calls that were made to GetLocation.
+// Check the length with:
+//
+//	len(mockedStore.GetLocationCalls())
+func (mock *StoreMock) GetLocationCalls() []struct {
+	Ctx  context.Context
+	City string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		City string
+	}
+	mock.lockGetLocation.RLock()
+	calls = mock.calls.GetLocation
+	mock.lockGetLocation.RUnlock()
+	return calls
+}
+
+// GetLocationBatch calls GetLocationBatchFunc.
+func (mock *StoreMock) GetLocationBatch(ctx context.Context, cities []string) (map[string]*storage.Location, error) {
+	if mock.GetLocationBatchFunc == nil {
+		panic("StoreMock.GetLocationBatchFunc: method is nil but Store.GetLocationBatch was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Cities []string
+	}{
+		Ctx:    ctx,
+		Cities: cities,
+	}
+	mock.lockGetLocationBatch.Lock()
+	mock.calls.GetLocationBatch = append(mock.calls.GetLocationBatch, callInfo)
+	mock.lockGetLocationBatch.Unlock()
+	return mock.GetLocationBatchFunc(ctx, cities)
+}
+
+// GetLocationBatchCalls gets all the calls that were made to GetLocationBatch.
+// Check the length with:
+//
+//	len(mockedStore.GetLocationBatchCalls())
+func (mock *StoreMock) GetLocationBatchCalls() []struct {
+	Ctx    context.Context
+	Cities []string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Cities []string
+	}
+	mock.lockGetLocationBatch.RLock()
+	calls = mock.calls.GetLocationBatch
+	mock.lockGetLocationBatch.RUnlock()
+	return calls
+}
+
+// GetProviderUsage calls GetProviderUsageFunc.
+func (mock *StoreMock) GetProviderUsage(ctx context.Context, provider string, period string) (int, error) {
+	if mock.GetProviderUsageFunc == nil {
+		panic("StoreMock.GetProviderUsageFunc: method is nil but Store.GetProviderUsage was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Provider string
+		Period   string
+	}{
+		Ctx:      ctx,
+		Provider: provider,
+		Period:   period,
+	}
+	mock.lockGetProviderUsage.Lock()
+	mock.calls.GetProviderUsage = append(mock.calls.GetProviderUsage, callInfo)
+	mock.lockGetProviderUsage.Unlock()
+	return mock.GetProviderUsageFunc(ctx, provider, period)
+}
+
+// GetProviderUsageCalls gets all the calls that were made to GetProviderUsage.
+// Check the length with:
+//
+//	len(mockedStore.GetProviderUsageCalls())
+func (mock *StoreMock) GetProviderUsageCalls() []struct {
+	Ctx      context.Context
+	Provider string
+	Period   string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Provider string
+		Period   string
+	}
+	mock.lockGetProviderUsage.RLock()
+	calls = mock.calls.GetProviderUsage
+	mock.lockGetProviderUsage.RUnlock()
+	return calls
+}
+
+// InsertAirQuality calls InsertAirQualityFunc.
+func (mock *StoreMock) InsertAirQuality(ctx context.Context, airQuality *storage.AirQuality) error {
+	if mock.InsertAirQualityFunc == nil {
+		panic("StoreMock.InsertAirQualityFunc: method is nil but Store.InsertAirQuality was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		AirQuality *storage.AirQuality
+	}{
+		Ctx:        ctx,
+		AirQuality: airQuality,
+	}
+	mock.lockInsertAirQuality.Lock()
+	mock.calls.InsertAirQuality = append(mock.calls.InsertAirQuality, callInfo)
+	mock.lockInsertAirQuality.Unlock()
+	return mock.InsertAirQualityFunc(ctx, airQuality)
+}
+
+// InsertAirQualityCalls gets all the calls that were made to InsertAirQuality.
+// Check the length with:
+//
+//	len(mockedStore.InsertAirQualityCalls())
+func (mock *StoreMock) InsertAirQualityCalls() []struct {
+	Ctx        context.Context
+	AirQuality *storage.AirQuality
+} {
+	var calls []struct {
+		Ctx        context.Context
+		AirQuality *storage.AirQuality
+	}
+	mock.lockInsertAirQuality.RLock()
+	calls = mock.calls.InsertAirQuality
+	mock.lockInsertAirQuality.RUnlock()
+	return calls
+}
+
+// InsertAlerts calls InsertAlertsFunc.
+func (mock *StoreMock) InsertAlerts(ctx context.Context, alerts *storage.Alerts) error {
+	if mock.InsertAlertsFunc == nil {
+		panic("StoreMock.InsertAlertsFunc: method is nil but Store.InsertAlerts was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Alerts *storage.Alerts
+	}{
+		Ctx:    ctx,
+		Alerts: alerts,
+	}
+	mock.lockInsertAlerts.Lock()
+	mock.calls.InsertAlerts = append(mock.calls.InsertAlerts, callInfo)
+	mock.lockInsertAlerts.Unlock()
+	return mock.InsertAlertsFunc(ctx, alerts)
+}
+
+// InsertAlertsCalls gets all the calls that were made to InsertAlerts.
+// Check the length with:
+//
+//	len(mockedStore.InsertAlertsCalls())
+func (mock *StoreMock) InsertAlertsCalls() []struct {
+	Ctx    context.Context
+	Alerts *storage.Alerts
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Alerts *storage.Alerts
+	}
+	mock.lockInsertAlerts.RLock()
+	calls = mock.calls.InsertAlerts
+	mock.lockInsertAlerts.RUnlock()
+	return calls
+}
+
+// InsertForecast calls InsertForecastFunc.
+func (mock *StoreMock) InsertForecast(ctx context.Context, forecast *storage.Forecast) error {
+	if mock.InsertForecastFunc == nil {
+		panic("StoreMock.InsertForecastFunc: method is nil but Store.InsertForecast was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Forecast *storage.Forecast
+	}{
+		Ctx:      ctx,
+		Forecast: forecast,
+	}
+	mock.lockInsertForecast.Lock()
+	mock.calls.InsertForecast = append(mock.calls.InsertForecast, callInfo)
+	mock.lockInsertForecast.Unlock()
+	return mock.InsertForecastFunc(ctx, forecast)
+}
+
+// InsertForecastCalls gets all the calls that were made to InsertForecast.
+// Check the length with:
+//
+//	len(mockedStore.InsertForecastCalls())
+func (mock *StoreMock) InsertForecastCalls() []struct {
+	Ctx      context.Context
+	Forecast *storage.Forecast
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Forecast *storage.Forecast
+	}
+	mock.lockInsertForecast.RLock()
+	calls = mock.calls.InsertForecast
+	mock.lockInsertForecast.RUnlock()
+	return calls
+}
+
+// InsertLocation calls InsertLocationFunc.
+func (mock *StoreMock) InsertLocation(ctx context.Context, location *storage.Location) error {
+	if mock.InsertLocationFunc == nil {
+		panic("StoreMock.InsertLocationFunc: method is nil but Store.InsertLocation was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Location *storage.Location
+	}{
+		Ctx:      ctx,
+		Location: location,
+	}
+	mock.lockInsertLocation.Lock()
+	mock.calls.InsertLocation = append(mock.calls.InsertLocation, callInfo)
+	mock.lockInsertLocation.Unlock()
+	return mock.InsertLocationFunc(ctx, location)
+}
+
+// InsertLocationCalls gets all the calls that were made to InsertLocation.
+// Check the length with:
+//
+//	len(mockedStore.InsertLocationCalls())
+func (mock *StoreMock) InsertLocationCalls() []struct {
+	Ctx      context.Context
+	Location *storage.Location
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Location *storage.Location
+	}
+	mock.lockInsertLocation.RLock()
+	calls = mock.calls.InsertLocation
+	mock.lockInsertLocation.RUnlock()
+	return calls
+}
+
+// InsertWeatherData calls InsertWeatherDataFunc.
+func (mock *StoreMock) InsertWeatherData(ctx context.Context, weatherData *storage.WeatherData) (bool, error) {
+	if mock.InsertWeatherDataFunc == nil {
+		panic("StoreMock.InsertWeatherDataFunc: method is nil but Store.InsertWeatherData was just called")
+	}
+	callInfo := struct {
+		Ctx         context.Context
+		WeatherData *storage.WeatherData
+	}{
+		Ctx:         ctx,
+		WeatherData: weatherData,
+	}
+	mock.lockInsertWeatherData.Lock()
+	mock.calls.InsertWeatherData = append(mock.calls.InsertWeatherData, callInfo)
+	mock.lockInsertWeatherData.Unlock()
+	return mock.InsertWeatherDataFunc(ctx, weatherData)
+}
+
+// InsertWeatherDataCalls gets all the calls that were made to InsertWeatherData.
+// Check the length with:
+//
+//	len(mockedStore.InsertWeatherDataCalls())
+func (mock *StoreMock) InsertWeatherDataCalls() []struct {
+	Ctx         context.Context
+	WeatherData *storage.WeatherData
+} {
+	var calls []struct {
+		Ctx         context.Context
+		WeatherData *storage.WeatherData
+	}
+	mock.lockInsertWeatherData.RLock()
+	calls = mock.calls.InsertWeatherData
+	mock.lockInsertWeatherData.RUnlock()
+	return calls
+}
+
+// InsertWeatherDataBatch calls InsertWeatherDataBatchFunc.
+func (mock *StoreMock) InsertWeatherDataBatch(ctx context.Context, weatherData []*storage.WeatherData) (int, error) {
+	if mock.InsertWeatherDataBatchFunc == nil {
+		panic("StoreMock.InsertWeatherDataBatchFunc: method is nil but Store.InsertWeatherDataBatch was just called")
+	}
+	callInfo := struct {
+		Ctx         context.Context
+		WeatherData []*storage.WeatherData
+	}{
+		Ctx:         ctx,
+		WeatherData: weatherData,
+	}
+	mock.lockInsertWeatherDataBatch.Lock()
+	mock.calls.InsertWeatherDataBatch = append(mock.calls.InsertWeatherDataBatch, callInfo)
+	mock.lockInsertWeatherDataBatch.Unlock()
+	return mock.InsertWeatherDataBatchFunc(ctx, weatherData)
+}
+
+// InsertWeatherDataBatchCalls gets all the calls that were made to InsertWeatherDataBatch.
+// Check the length with:
+//
+//	len(mockedStore.InsertWeatherDataBatchCalls())
+func (mock *StoreMock) InsertWeatherDataBatchCalls() []struct {
+	Ctx         context.Context
+	WeatherData []*storage.WeatherData
+} {
+	var calls []struct {
+		Ctx         context.Context
+		WeatherData []*storage.WeatherData
+	}
+	mock.lockInsertWeatherDataBatch.RLock()
+	calls = mock.calls.InsertWeatherDataBatch
+	mock.lockInsertWeatherDataBatch.RUnlock()
+	return calls
+}
+
+// PruneWeatherData calls PruneWeatherDataFunc.
+func (mock *StoreMock) PruneWeatherData(ctx context.Context, policy storage.RetentionPolicy) (*storage.PruneResult, error) {
+	if mock.PruneWeatherDataFunc == nil {
+		panic("StoreMock.PruneWeatherDataFunc: method is nil but Store.PruneWeatherData was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Policy storage.RetentionPolicy
+	}{
+		Ctx:    ctx,
+		Policy: policy,
+	}
+	mock.lockPruneWeatherData.Lock()
+	mock.calls.PruneWeatherData = append(mock.calls.PruneWeatherData, callInfo)
+	mock.lockPruneWeatherData.Unlock()
+	return mock.PruneWeatherDataFunc(ctx, policy)
+}
+
+// PruneWeatherDataCalls gets all the calls that were made to PruneWeatherData.
+// Check the length with:
+//
+//	len(mockedStore.PruneWeatherDataCalls())
+func (mock *StoreMock) PruneWeatherDataCalls() []struct {
+	Ctx    context.Context
+	Policy storage.RetentionPolicy
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Policy storage.RetentionPolicy
+	}
+	mock.lockPruneWeatherData.RLock()
+	calls = mock.calls.PruneWeatherData
+	mock.lockPruneWeatherData.RUnlock()
+	return calls
+}
+
+// ReleaseProviderCall calls ReleaseProviderCallFunc.
+func (mock *StoreMock) ReleaseProviderCall(ctx context.Context, provider string, period string) error {
+	if mock.ReleaseProviderCallFunc == nil {
+		panic("StoreMock.ReleaseProviderCallFunc: method is nil but Store.ReleaseProviderCall was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Provider string
+		Period   string
+	}{
+		Ctx:      ctx,
+		Provider: provider,
+		Period:   period,
+	}
+	mock.lockReleaseProviderCall.Lock()
+	mock.calls.ReleaseProviderCall = append(mock.calls.ReleaseProviderCall, callInfo)
+	mock.lockReleaseProviderCall.Unlock()
+	return mock.ReleaseProviderCallFunc(ctx, provider, period)
+}
+
+// ReleaseProviderCallCalls gets all the calls that were made to ReleaseProviderCall.
+// Check the length with:
+//
+//	len(mockedStore.ReleaseProviderCallCalls())
+func (mock *StoreMock) ReleaseProviderCallCalls() []struct {
+	Ctx      context.Context
+	Provider string
+	Period   string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Provider string
+		Period   string
+	}
+	mock.lockReleaseProviderCall.RLock()
+	calls = mock.calls.ReleaseProviderCall
+	mock.lockReleaseProviderCall.RUnlock()
+	return calls
+}
+
+// ReserveProviderCall calls ReserveProviderCallFunc.
+func (mock *StoreMock) ReserveProviderCall(ctx context.Context, provider string, period string, limit int) (bool, error) {
+	if mock.ReserveProviderCallFunc == nil {
+		panic("StoreMock.ReserveProviderCallFunc: method is nil but Store.ReserveProviderCall was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Provider string
+		Period   string
+		Limit    int
+	}{
+		Ctx:      ctx,
+		Provider: provider,
+		Period:   period,
+		Limit:    limit,
+	}
+	mock.lockReserveProviderCall.Lock()
+	mock.calls.ReserveProviderCall = append(mock.calls.ReserveProviderCall, callInfo)
+	mock.lockReserveProviderCall.Unlock()
+	return mock.ReserveProviderCallFunc(ctx, provider, period, limit)
+}
+
+// ReserveProviderCallCalls gets all the calls that were made to ReserveProviderCall.
+// Check the length with:
+//
+//	len(mockedStore.ReserveProviderCallCalls())
+func (mock *StoreMock) ReserveProviderCallCalls() []struct {
+	Ctx      context.Context
+	Provider string
+	Period   string
+	Limit    int
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Provider string
+		Period   string
+		Limit    int
+	}
+	mock.lockReserveProviderCall.RLock()
+	calls = mock.calls.ReserveProviderCall
+	mock.lockReserveProviderCall.RUnlock()
+	return calls
+}
@@ -9,70 +9,124 @@ import (
 	"sync"
 )
 
-var (
-	lockWeatherStackClientMockGetWeather sync.RWMutex
-)
-
 // Ensure, that WeatherStackClientMock does implement weatherapi.WeatherStackClient.
 // If this is not the case, regenerate this file with moq.
 var _ weatherapi.WeatherStackClient = &WeatherStackClientMock{}
 
 // WeatherStackClientMock is a mock implementation of weatherapi.WeatherStackClient.
 //
-//     func TestSomethingThatUsesWeatherStackClient(t *testing.T) {
+//	func TestSomethingThatUsesWeatherStackClient(t *testing.T) {
 //
-//         // make and configure a mocked weatherapi.WeatherStackClient
-//         mockedWeatherStackClient := &WeatherStackClientMock{
-//             GetWeatherFunc: func(city string) (*weatherstack.APIResponse, error) {
-// 	               panic("mock out the GetWeather method")
-//             },
-//         }
+//		// make and configure a mocked weatherapi.WeatherStackClient
+//		mockedWeatherStackClient := &WeatherStackClientMock{
+//			GetForecastFunc: func(city string, days int) (*weatherstack.ForecastResponse, error) {
+//				panic("mock out the GetForecast method")
+//			},
+//			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+//				panic("mock out the GetWeather method")
+//			},
+//		}
 //
-//         // use mockedWeatherStackClient in code that requires weatherapi.WeatherStackClient
-//         // and then make assertions.
+//		// use mockedWeatherStackClient in code that requires weatherapi.WeatherStackClient
+//		// and then make assertions.
 //
-//     }
+//	}
 type WeatherStackClientMock struct {
+	// GetForecastFunc mocks the GetForecast method.
+	GetForecastFunc func(city string, days int) (*weatherstack.ForecastResponse, error)
+
 	// GetWeatherFunc mocks the GetWeather method.
-	GetWeatherFunc func(city string) (*weatherstack.APIResponse, error)
+	GetWeatherFunc func(city string, language string) (*weatherstack.APIResponse, error)
 
 	// calls tracks calls to the methods.
 	calls struct {
+		// GetForecast holds details about calls to the GetForecast method.
+		GetForecast []struct {
+			// City is the city argument value.
+			City string
+			// Days is the days argument value.
+			Days int
+		}
 		// GetWeather holds details about calls to the GetWeather method.
 		GetWeather []struct {
 			// City is the city argument value.
 			City string
+			// Language is the language argument value.
+			Language string
 		}
 	}
+	lockGetForecast sync.RWMutex
+	lockGetWeather  sync.RWMutex
+}
+
+// GetForecast calls GetForecastFunc.
+func (mock *WeatherStackClientMock) GetForecast(city string, days int) (*weatherstack.ForecastResponse, error) {
+	if mock.GetForecastFunc == nil {
+		panic("WeatherStackClientMock.GetForecastFunc: method is nil but WeatherStackClient.GetForecast was just called")
+	}
+	callInfo := struct {
+		City string
+		Days int
+	}{
+		City: city,
+		Days: days,
+	}
+	mock.lockGetForecast.Lock()
+	mock.calls.GetForecast = append(mock.calls.GetForecast, callInfo)
+	mock.lockGetForecast.Unlock()
+	return mock.GetForecastFunc(city, days)
+}
+
+// GetForecastCalls gets all the calls that were made to GetForecast.
+// Check the length with:
+//
+//	len(mockedWeatherStackClient.GetForecastCalls())
+func (mock *WeatherStackClientMock) GetForecastCalls() []struct {
+	City string
+	Days int
+} {
+	var calls []struct {
+		City string
+		Days int
+	}
+	mock.lockGetForecast.RLock()
+	calls = mock.calls.GetForecast
+	mock.lockGetForecast.RUnlock()
+	return calls
 }
 
 // GetWeather calls GetWeatherFunc.
-func (mock *WeatherStackClientMock) GetWeather(city string) (*weatherstack.APIResponse, error) {
+func (mock *WeatherStackClientMock) GetWeather(city string, language string) (*weatherstack.APIResponse, error) {
 	if mock.GetWeatherFunc == nil {
 		panic("WeatherStackClientMock.GetWeatherFunc: method is nil but WeatherStackClient.GetWeather was just called")
 	}
 	callInfo := struct {
-		City string
+		City     string
+		Language string
 	}{
-		City: city,
+		City:     city,
+		Language: language,
 	}
-	lockWeatherStackClientMockGetWeather.Lock()
+	mock.lockGetWeather.Lock()
 	mock.calls.GetWeather = append(mock.calls.GetWeather, callInfo)
-	lockWeatherStackClientMockGetWeather.Unlock()
-	return mock.GetWeatherFunc(city)
+	mock.lockGetWeather.Unlock()
+	return mock.GetWeatherFunc(city, language)
 }
 
 // GetWeatherCalls gets all the calls that were made to GetWeather.
 // Check the length with:
-//     len(mockedWeatherStackClient.GetWeatherCalls())
+//
+//	len(mockedWeatherStackClient.GetWeatherCalls())
 func (mock *WeatherStackClientMock) GetWeatherCalls() []struct {
-	City string
+	City     string
+	Language string
 } {
 	var calls []struct {
-		City string
+		City     string
+		Language string
 	}
-	lockWeatherStackClientMockGetWeather.RLock()
+	mock.lockGetWeather.RLock()
 	calls = mock.calls.GetWeather
-	lockWeatherStackClientMockGetWeather.RUnlock()
+	mock.lockGetWeather.RUnlock()
 	return calls
 }
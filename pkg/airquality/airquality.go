@@ -0,0 +1,198 @@
+// Package airquality computes air quality indices from pollutant concentrations
+package airquality
+
+import "math"
+
+// Pollutants, as named by openweathermap
+const (
+	PM25 = "pm2_5"
+	PM10 = "pm10"
+	O3   = "o3"
+	NO2  = "no2"
+	SO2  = "so2"
+	CO   = "co"
+)
+
+// US EPA categories
+const (
+	EPAGood                        = "good"
+	EPAModerate                    = "moderate"
+	EPAUnhealthyForSensitiveGroups = "unhealthy for sensitive groups"
+	EPAUnhealthy                   = "unhealthy"
+	EPAVeryUnhealthy               = "very unhealthy"
+	EPAHazardous                   = "hazardous"
+)
+
+// European CAQI categories
+const (
+	CAQIVeryLow  = "very low"
+	CAQILow      = "low"
+	CAQIMedium   = "medium"
+	CAQIHigh     = "high"
+	CAQIVeryHigh = "very high"
+)
+
+// Molecular weights in g/mol, converting µg/m³ to ppb at 25°C and 1 atm
+const (
+	molarVolume = 24.45
+	o3Weight    = 48.00
+	no2Weight   = 46.01
+	so2Weight   = 64.07
+	coWeight    = 28.01
+)
+
+// Concentrations are pollutant concentrations in µg/m³
+type Concentrations struct {
+	PM25 float64
+	PM10 float64
+	O3   float64
+	NO2  float64
+	SO2  float64
+	CO   float64
+}
+
+// Index is an air quality index and the pollutant that set it
+type Index struct {
+	Value    int
+	Category string
+	// Dominant is the pollutant with the highest sub-index
+	Dominant string
+}
+
+// breakpoint maps concentrations from low to high linearly onto the indices from indexLow to indexHigh
+type breakpoint struct {
+	low, high           float64
+	indexLow, indexHigh float64
+}
+
+// EPA breakpoints (as revised in 2024) in the units and precision the EPA truncates concentrations to
+var (
+	// µg/m³, 0.1
+	epaPM25 = []breakpoint{{0, 9.0, 0, 50}, {9.1, 35.4, 51, 100}, {35.5, 55.4, 101, 150}, {55.5, 125.4, 151, 200}, {125.5, 225.4, 201, 300}, {225.5, 325.4, 301, 500}}
+	// µg/m³, 1
+	epaPM10 = []breakpoint{{0, 54, 0, 50}, {55, 154, 51, 100}, {155, 254, 101, 150}, {255, 354, 151, 200}, {355, 424, 201, 300}, {425, 604, 301, 500}}
+	// ppm, 0.001. The 8 hour table stops at 300, the 1 hour table starts at 101.
+	epaO38Hour = []breakpoint{{0, 0.054, 0, 50}, {0.055, 0.070, 51, 100}, {0.071, 0.085, 101, 150}, {0.086, 0.105, 151, 200}, {0.106, 0.200, 201, 300}}
+	epaO31Hour = []breakpoint{{0.125, 0.164, 101, 150}, {0.165, 0.204, 151, 200}, {0.205, 0.404, 201, 300}, {0.405, 0.504, 301, 400}, {0.505, 0.604, 401, 500}}
+	// ppb, 1
+	epaNO2 = []breakpoint{{0, 53, 0, 50}, {54, 100, 51, 100}, {101, 360, 101, 150}, {361, 649, 151, 200}, {650, 1249, 201, 300}, {1250, 2049, 301, 500}}
+	// ppb, 1
+	epaSO2 = []breakpoint{{0, 35, 0, 50}, {36, 75, 51, 100}, {76, 185, 101, 150}, {186, 304, 151, 200}, {305, 604, 201, 300}, {605, 1004, 301, 500}}
+	// ppm, 0.1
+	epaCO = []breakpoint{{0, 4.4, 0, 50}, {4.5, 9.4, 51, 100}, {9.5, 12.4, 101, 150}, {12.5, 15.4, 151, 200}, {15.5, 30.4, 201, 300}, {30.5, 50.4, 301, 500}}
+)
+
+// CAQI hourly background grid in µg/m³, the concentrations at indices 0, 25, 50, 75 and 100
+var (
+	caqiPM25 = []float64{0, 15, 30, 55, 110}
+	caqiPM10 = []float64{0, 25, 50, 90, 180}
+	caqiO3   = []float64{0, 60, 120, 180, 240}
+	caqiNO2  = []float64{0, 50, 100, 200, 400}
+	caqiSO2  = []float64{0, 50, 100, 350, 500}
+	caqiCO   = []float64{0, 5000, 7500, 10000, 20000}
+)
+
+// EPA returns the US EPA AQI (0 to 500). The EPA averages concentrations over 1 to 24 hours depending on the pollutant,
+// the hourly concentrations are used as they are, so this is closer to a NowCast than an official AQI.
+func EPA(c Concentrations) Index {
+	o3 := truncate(c.O3*molarVolume/o3Weight/1000, 3)
+	subIndices := []float64{
+		epaSubIndex(epaPM25, truncate(c.PM25, 1)),
+		epaSubIndex(epaPM10, truncate(c.PM10, 0)),
+		math.Max(epaSubIndex(epaO38Hour, o3), epaSubIndex(epaO31Hour, o3)),
+		epaSubIndex(epaNO2, truncate(c.NO2*molarVolume/no2Weight, 0)),
+		epaSubIndex(epaSO2, truncate(c.SO2*molarVolume/so2Weight, 0)),
+		epaSubIndex(epaCO, truncate(c.CO*molarVolume/coWeight/1000, 1)),
+	}
+
+	value, dominant := highest(subIndices)
+	index := Index{Value: int(math.Round(value)), Dominant: dominant}
+	switch {
+	case index.Value <= 50:
+		index.Category = EPAGood
+	case index.Value <= 100:
+		index.Category = EPAModerate
+	case index.Value <= 150:
+		index.Category = EPAUnhealthyForSensitiveGroups
+	case index.Value <= 200:
+		index.Category = EPAUnhealthy
+	case index.Value <= 300:
+		index.Category = EPAVeryUnhealthy
+	default:
+		index.Category = EPAHazardous
+	}
+	return index
+}
+
+// CAQI returns the European Common Air Quality Index for background locations, from the hourly grid.
+// It's open ended, concentrations beyond the grid extend its last band past 100.
+func CAQI(c Concentrations) Index {
+	subIndices := []float64{
+		caqiSubIndex(caqiPM25, c.PM25),
+		caqiSubIndex(caqiPM10, c.PM10),
+		caqiSubIndex(caqiO3, c.O3),
+		caqiSubIndex(caqiNO2, c.NO2),
+		caqiSubIndex(caqiSO2, c.SO2),
+		caqiSubIndex(caqiCO, c.CO),
+	}
+
+	value, dominant := highest(subIndices)
+	index := Index{Value: int(math.Round(value)), Dominant: dominant}
+	switch {
+	case value < 25:
+		index.Category = CAQIVeryLow
+	case value < 50:
+		index.Category = CAQILow
+	case value < 75:
+		index.Category = CAQIMedium
+	case value <= 100:
+		index.Category = CAQIHigh
+	default:
+		index.Category = CAQIVeryHigh
+	}
+	return index
+}
+
+// epaSubIndex interpolates the truncated concentration within its band. Concentrations below the table are 0 and
+// beyond it the table's highest index.
+func epaSubIndex(table []breakpoint, concentration float64) float64 {
+	if concentration < table[0].low {
+		return 0
+	}
+	for _, bp := range table {
+		if concentration <= bp.high {
+			// Truncation leaves no concentrations between bands
+			return (bp.indexHigh-bp.indexLow)/(bp.high-bp.low)*(math.Max(concentration, bp.low)-bp.low) + bp.indexLow
+		}
+	}
+	return table[len(table)-1].indexHigh
+}
+
+// caqiSubIndex interpolates the concentration within the grid
+func caqiSubIndex(grid []float64, concentration float64) float64 {
+	for i := 1; i < len(grid); i++ {
+		if concentration <= grid[i] || i == len(grid)-1 {
+			return 25*float64(i-1) + 25*(concentration-grid[i-1])/(grid[i]-grid[i-1])
+		}
+	}
+	return 0
+}
+
+// highest returns the highest sub-index, in the order of pollutants, and its pollutant
+func highest(subIndices []float64) (float64, string) {
+	pollutants := []string{PM25, PM10, O3, NO2, SO2, CO}
+	value, dominant := subIndices[0], pollutants[0]
+	for i, subIndex := range subIndices {
+		if subIndex > value {
+			value, dominant = subIndex, pollutants[i]
+		}
+	}
+	return value, dominant
+}
+
+// truncate drops the digits after decimals places, as the EPA does before looking up breakpoints
+func truncate(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	// Nudge up so values like 0.07 aren't truncated to 0.069 by their binary representation
+	return math.Floor(value*scale+1e-9) / scale
+}
@@ -0,0 +1,62 @@
+package airquality_test
+
+import (
+	"testing"
+
+	"github.com/TomSED/weather-api/pkg/airquality"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEPA(t *testing.T) {
+
+	t.Run("It should pick the pollutant with the highest sub-index", func(t *testing.T) {
+		index := airquality.EPA(airquality.Concentrations{PM25: 8.5, PM10: 17.2, O3: 60.1, NO2: 12.3, SO2: 1.5, CO: 230.3})
+		assert.Equal(t, airquality.Index{Value: 47, Category: airquality.EPAGood, Dominant: airquality.PM25}, index)
+	})
+
+	t.Run("It should interpolate within bands and truncate concentrations before looking them up", func(t *testing.T) {
+		assert.Equal(t, 50, airquality.EPA(airquality.Concentrations{PM25: 9.09}).Value)
+		assert.Equal(t, 51, airquality.EPA(airquality.Concentrations{PM25: 9.1}).Value)
+		assert.Equal(t, 100, airquality.EPA(airquality.Concentrations{PM25: 35.4}).Value)
+		assert.Equal(t, airquality.EPAUnhealthyForSensitiveGroups, airquality.EPA(airquality.Concentrations{PM25: 35.5}).Category)
+		assert.Equal(t, airquality.EPAUnhealthy, airquality.EPA(airquality.Concentrations{PM10: 300}).Category)
+	})
+
+	t.Run("It should convert gases to ppm and ppb", func(t *testing.T) {
+		// 8.7 ppm
+		index := airquality.EPA(airquality.Concentrations{CO: 10000})
+		assert.Equal(t, 93, index.Value)
+		assert.Equal(t, airquality.CO, index.Dominant)
+
+		// 106 ppb
+		index = airquality.EPA(airquality.Concentrations{NO2: 200})
+		assert.Equal(t, 102, index.Value)
+		assert.Equal(t, airquality.NO2, index.Dominant)
+	})
+
+	t.Run("It should use the 1 hour ozone table beyond the 8 hour one", func(t *testing.T) {
+		// 0.254 ppm
+		assert.Equal(t, airquality.Index{Value: 300, Category: airquality.EPAVeryUnhealthy, Dominant: airquality.O3}, airquality.EPA(airquality.Concentrations{O3: 500}))
+		// 0.458 ppm
+		assert.Equal(t, airquality.Index{Value: 354, Category: airquality.EPAHazardous, Dominant: airquality.O3}, airquality.EPA(airquality.Concentrations{O3: 900}))
+	})
+
+	t.Run("It should cap concentrations beyond the table at 500", func(t *testing.T) {
+		assert.Equal(t, 500, airquality.EPA(airquality.Concentrations{PM25: 1000}).Value)
+	})
+}
+
+func TestCAQI(t *testing.T) {
+
+	t.Run("It should pick the pollutant with the highest sub-index", func(t *testing.T) {
+		index := airquality.CAQI(airquality.Concentrations{PM25: 8.5, PM10: 17.2, O3: 60.1, NO2: 12.3, SO2: 1.5, CO: 230.3})
+		assert.Equal(t, airquality.Index{Value: 25, Category: airquality.CAQILow, Dominant: airquality.O3}, index)
+	})
+
+	t.Run("It should interpolate within the grid and extend its last band", func(t *testing.T) {
+		assert.Equal(t, airquality.Index{Value: 0, Category: airquality.CAQIVeryLow, Dominant: airquality.PM25}, airquality.CAQI(airquality.Concentrations{}))
+		assert.Equal(t, airquality.Index{Value: 63, Category: airquality.CAQIMedium, Dominant: airquality.PM10}, airquality.CAQI(airquality.Concentrations{PM10: 70}))
+		assert.Equal(t, airquality.Index{Value: 100, Category: airquality.CAQIHigh, Dominant: airquality.NO2}, airquality.CAQI(airquality.Concentrations{NO2: 400}))
+		assert.Equal(t, airquality.Index{Value: 125, Category: airquality.CAQIVeryHigh, Dominant: airquality.PM25}, airquality.CAQI(airquality.Concentrations{PM25: 165}))
+	})
+}
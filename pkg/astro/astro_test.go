@@ -0,0 +1,92 @@
+package astro_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/astro"
+	"github.com/TomSED/weather-api/pkg/openweathermap"
+	"github.com/stretchr/testify/assert"
+)
+
+func assertWithin(t *testing.T, expected time.Time, actual time.Time, tolerance time.Duration) {
+	t.Helper()
+	diff := actual.Sub(expected)
+	if diff < 0 {
+		diff = -diff
+	}
+	assert.True(t, diff <= tolerance, "expected %v within %v, got %v", expected, tolerance, actual)
+}
+
+func TestSun(t *testing.T) {
+
+	t.Run("It should agree with openweathermap's sunrise and sunset", func(t *testing.T) {
+		// Sydney on 16 May 2021, as in the openweathermap client tests
+		resp := &openweathermap.APIResponse{}
+		err := json.Unmarshal([]byte(`{"coord":{"lon":151.2073,"lat":-33.8679},"dt":1621130173,"sys":{"type":1,"id":9600,"country":"AU","sunrise":1621111254,"sunset":1621148542},"timezone":36000,"name":"Sydney"}`), resp)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+
+		local := time.Unix(int64(resp.Dt), 0).In(time.FixedZone("", resp.Timezone))
+		sun := astro.Sun(local, resp.Coord.Lat, resp.Coord.Lon)
+		assertWithin(t, time.Unix(int64(resp.Sys.Sunrise), 0), sun.Sunrise, time.Minute)
+		assertWithin(t, time.Unix(int64(resp.Sys.Sunset), 0), sun.Sunset, time.Minute)
+		assertWithin(t, time.Date(2021, 5, 16, 1, 51, 0, 0, time.UTC), sun.SolarNoon, time.Minute)
+		assert.InDelta(t, time.Duration(resp.Sys.Sunset-resp.Sys.Sunrise)*time.Second, sun.DayLength, float64(time.Minute))
+	})
+
+	t.Run("It should compute civil twilight and the day of points west of Greenwich", func(t *testing.T) {
+		// London on the June solstice, 04:43 to 21:21 BST
+		sun := astro.Sun(time.Date(2021, 6, 21, 0, 0, 0, 0, time.UTC), 51.5074, -0.1278)
+		assertWithin(t, time.Date(2021, 6, 21, 3, 43, 0, 0, time.UTC), sun.Sunrise, time.Minute)
+		assertWithin(t, time.Date(2021, 6, 21, 20, 21, 0, 0, time.UTC), sun.Sunset, time.Minute)
+		assertWithin(t, time.Date(2021, 6, 21, 2, 55, 0, 0, time.UTC), sun.CivilDawn, 2*time.Minute)
+		assertWithin(t, time.Date(2021, 6, 21, 21, 9, 0, 0, time.UTC), sun.CivilDusk, 2*time.Minute)
+
+		// Honolulu's 10 January sets on the 11th in UTC
+		sun = astro.Sun(time.Date(2021, 1, 10, 0, 0, 0, 0, time.UTC), 21.3069, -157.8583)
+		assertWithin(t, time.Date(2021, 1, 10, 17, 11, 0, 0, time.UTC), sun.Sunrise, time.Minute)
+		assertWithin(t, time.Date(2021, 1, 11, 4, 7, 0, 0, time.UTC), sun.Sunset, time.Minute)
+	})
+
+	t.Run("It should leave out events that don't happen in the midnight sun and polar night", func(t *testing.T) {
+		// Tromsø
+		sun := astro.Sun(time.Date(2021, 6, 21, 0, 0, 0, 0, time.UTC), 69.6492, 18.9553)
+		assert.True(t, sun.Sunrise.IsZero())
+		assert.True(t, sun.Sunset.IsZero())
+		assert.Equal(t, 24*time.Hour, sun.DayLength)
+
+		sun = astro.Sun(time.Date(2021, 12, 21, 0, 0, 0, 0, time.UTC), 69.6492, 18.9553)
+		assert.True(t, sun.Sunrise.IsZero())
+		assert.Equal(t, time.Duration(0), sun.DayLength)
+		// The sun still gets within 6° of the horizon
+		assert.False(t, sun.CivilDawn.IsZero())
+		assert.True(t, sun.CivilDawn.Before(sun.SolarNoon))
+	})
+}
+
+func TestMoon(t *testing.T) {
+
+	testCases := []struct {
+		name         string
+		time         time.Time
+		phase        string
+		illumination float64
+	}{
+		{"new moon", time.Date(2021, 5, 11, 19, 0, 0, 0, time.UTC), astro.NewMoon, 0},
+		{"first quarter", time.Date(2021, 5, 19, 19, 13, 0, 0, time.UTC), astro.FirstQuarter, 0.5},
+		{"full moon", time.Date(2021, 5, 26, 11, 14, 0, 0, time.UTC), astro.FullMoon, 1},
+		{"last quarter", time.Date(2021, 6, 2, 7, 24, 0, 0, time.UTC), astro.LastQuarter, 0.5},
+	}
+
+	for _, tc := range testCases {
+		t.Run("It should compute the "+tc.name, func(t *testing.T) {
+			moon := astro.Moon(tc.time)
+			assert.Equal(t, tc.phase, moon.Name)
+			assert.InDelta(t, tc.illumination, moon.Illumination, 0.01)
+			assert.InDelta(t, moon.Phase*29.530588853, moon.Age, 0.0001)
+		})
+	}
+}
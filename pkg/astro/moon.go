@@ -0,0 +1,60 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// Moon phase names
+const (
+	NewMoon        = "new moon"
+	WaxingCrescent = "waxing crescent"
+	FirstQuarter   = "first quarter"
+	WaxingGibbous  = "waxing gibbous"
+	FullMoon       = "full moon"
+	WaningGibbous  = "waning gibbous"
+	LastQuarter    = "last quarter"
+	WaningCrescent = "waning crescent"
+)
+
+// synodicMonth is the mean time between new moons in days
+const synodicMonth = 29.530588853
+
+var phaseNames = []string{NewMoon, WaxingCrescent, FirstQuarter, WaxingGibbous, FullMoon, WaningGibbous, LastQuarter, WaningCrescent}
+
+// MoonPhase is the moon's phase at a time
+type MoonPhase struct {
+	// Phase runs from 0 (new) through 0.5 (full) back towards 1
+	Phase float64
+	// Illumination is the fraction of the disc lit, from 0 to 1
+	Illumination float64
+	// Age is the days since the new moon
+	Age  float64
+	Name string
+}
+
+// Moon returns the moon's phase at t, from its elongation from the sun with the largest periodic terms (Meeus ch. 48)
+func Moon(t time.Time) MoonPhase {
+	c := julianCentury(t)
+	// Mean elongation of the moon and mean anomalies of the sun and moon
+	d := radians(297.8501921 + 445267.1114034*c - 0.0018819*c*c)
+	m := radians(357.5291092 + 35999.0502909*c - 0.0001536*c*c)
+	mMoon := radians(134.9633964 + 477198.8675055*c + 0.0087414*c*c)
+
+	// Phase angle, the sun-moon angle seen from the earth is 180° less
+	i := 180 - degrees(d) - 6.289*math.Sin(mMoon) + 2.100*math.Sin(m) - 1.274*math.Sin(2*d-mMoon) -
+		0.658*math.Sin(2*d) - 0.214*math.Sin(2*mMoon) - 0.110*math.Sin(d)
+	elongation := math.Mod(180-i, 360)
+	if elongation < 0 {
+		elongation += 360
+	}
+
+	phase := elongation / 360
+	return MoonPhase{
+		Phase:        phase,
+		Illumination: (1 - math.Cos(radians(elongation))) / 2,
+		Age:          phase * synodicMonth,
+		// Each name covers an eighth of the cycle centred on it
+		Name: phaseNames[int(math.Floor(phase*8+0.5))%8],
+	}
+}
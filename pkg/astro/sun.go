@@ -0,0 +1,135 @@
+// Package astro computes sun and moon positions with the NOAA solar calculator and Meeus' lunar phase formulas,
+// accurate to about a minute for latitudes within the polar circles
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// Zenith angles in degrees, the sun's centre is below the horizon at sunrise because of refraction and its radius
+	sunriseZenith = 90.833
+	civilZenith   = 96
+
+	// Event times are refined from the sun's position at the previous estimate this many times
+	refinements = 2
+)
+
+// SunTimes are the sun's events of a day. Events that don't happen that day, e.g. sunrise in the polar night, are zero.
+type SunTimes struct {
+	Sunrise   time.Time
+	Sunset    time.Time
+	SolarNoon time.Time
+	// CivilDawn and CivilDusk are when the sun is 6° below the horizon
+	CivilDawn time.Time
+	CivilDusk time.Time
+	// DayLength is between sunrise and sunset, 24 hours when the sun doesn't set and 0 when it doesn't rise
+	DayLength time.Duration
+}
+
+// Sun returns the sun's events on the date at the point. The day is the solar day around the point's mean solar noon,
+// so date's year, month and day are taken as the local date and its time and location are ignored.
+func Sun(date time.Time, lat float64, lon float64) SunTimes {
+	year, month, day := date.Date()
+	midnight := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	noon := midnight.Add(minutes(720 - 4*lon))
+	for i := 0; i < refinements; i++ {
+		noon = midnight.Add(minutes(720 - 4*lon - equationOfTime(noon)))
+	}
+
+	out := SunTimes{SolarNoon: noon.Truncate(time.Second)}
+	out.Sunrise, out.Sunset = crossings(noon, lat, sunriseZenith)
+	out.CivilDawn, out.CivilDusk = crossings(noon, lat, civilZenith)
+
+	switch {
+	case !out.Sunrise.IsZero() && !out.Sunset.IsZero():
+		out.DayLength = out.Sunset.Sub(out.Sunrise)
+	case math.IsInf(hourAngle(noon, lat, sunriseZenith), 1):
+		// Midnight sun
+		out.DayLength = 24 * time.Hour
+	}
+	return out
+}
+
+// crossings returns when the sun passes the zenith angle before and after noon, zero if it doesn't
+func crossings(noon time.Time, lat float64, zenith float64) (time.Time, time.Time) {
+	var out [2]time.Time
+	for i, sign := range []float64{-1, 1} {
+		event := noon
+		for j := 0; j <= refinements; j++ {
+			h := hourAngle(event, lat, zenith)
+			if math.IsNaN(h) || math.IsInf(h, 0) {
+				return time.Time{}, time.Time{}
+			}
+			event = noon.Add(minutes(sign * 4 * h))
+		}
+		out[i] = event.Truncate(time.Second)
+	}
+	return out[0], out[1]
+}
+
+// hourAngle returns the sun's hour angle in degrees when it's at the zenith angle, +Inf when the sun stays above it
+// all day (the midnight sun) and NaN when it stays below (the polar night)
+func hourAngle(t time.Time, lat float64, zenith float64) float64 {
+	decl := declination(t)
+	cosH := (math.Cos(radians(zenith)) - math.Sin(radians(lat))*math.Sin(decl)) / (math.Cos(radians(lat)) * math.Cos(decl))
+	switch {
+	case cosH < -1:
+		return math.Inf(1)
+	case cosH > 1:
+		return math.NaN()
+	}
+	return degrees(math.Acos(cosH))
+}
+
+// julianCentury returns the Julian centuries since J2000.0
+func julianCentury(t time.Time) float64 {
+	julianDay := float64(t.UnixNano())/float64(24*time.Hour) + 2440587.5
+	return (julianDay - 2451545) / 36525
+}
+
+// solarCoordinates returns the sun's geometric mean longitude, the earth's orbital eccentricity and mean anomaly, the
+// sun's apparent longitude and the obliquity of the ecliptic, angles in radians
+func solarCoordinates(t time.Time) (meanLongitude float64, eccentricity float64, meanAnomaly float64, apparentLongitude float64, obliquity float64) {
+	c := julianCentury(t)
+	meanLongitude = math.Mod(280.46646+c*(36000.76983+c*0.0003032), 360)
+	meanAnomaly = 357.52911 + c*(35999.05029-0.0001537*c)
+	eccentricity = 0.016708634 - c*(0.000042037+0.0000001267*c)
+
+	m := radians(meanAnomaly)
+	center := math.Sin(m)*(1.914602-c*(0.004817+0.000014*c)) + math.Sin(2*m)*(0.019993-0.000101*c) + math.Sin(3*m)*0.000289
+	omega := radians(125.04 - 1934.136*c)
+	apparentLongitude = meanLongitude + center - 0.00569 - 0.00478*math.Sin(omega)
+
+	meanObliquity := 23 + (26+(21.448-c*(46.815+c*(0.00059-c*0.001813)))/60)/60
+	obliquity = meanObliquity + 0.00256*math.Cos(omega)
+
+	return radians(meanLongitude), eccentricity, m, radians(apparentLongitude), radians(obliquity)
+}
+
+// declination returns the sun's declination in radians
+func declination(t time.Time) float64 {
+	_, _, _, apparentLongitude, obliquity := solarCoordinates(t)
+	return math.Asin(math.Sin(obliquity) * math.Sin(apparentLongitude))
+}
+
+// equationOfTime returns how far apparent solar time is ahead of mean solar time, in minutes
+func equationOfTime(t time.Time) float64 {
+	l0, e, m, _, obliquity := solarCoordinates(t)
+	y := math.Pow(math.Tan(obliquity/2), 2)
+	return 4 * degrees(y*math.Sin(2*l0)-2*e*math.Sin(m)+4*e*y*math.Sin(m)*math.Cos(2*l0)-0.5*y*y*math.Sin(4*l0)-1.25*e*e*math.Sin(2*m))
+}
+
+func minutes(m float64) time.Duration {
+	return time.Duration(m * float64(time.Minute))
+}
+
+func radians(d float64) float64 {
+	return d * math.Pi / 180
+}
+
+func degrees(r float64) float64 {
+	return r * 180 / math.Pi
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Tier is one layer of the cache, each owning its own expiry
+type Tier interface {
+	// Name identifies the tier in logs
+	Name() string
+	// Get returns the value of key, nil if it's missing or expired
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value under key
+	Set(ctx context.Context, key string, value []byte) error
+}
+
+// Memory is a per process tier. Entries expire after the ttl, keep it short so instances see each other's writes.
+type Memory struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemory creates an empty memory tier
+func NewMemory(ttl time.Duration) *Memory {
+	return &Memory{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]memoryEntry{},
+	}
+}
+
+// SetClock sets the time source used for expiry, for tests
+func (m *Memory) SetClock(now func() time.Time) {
+	m.now = now
+}
+
+// Name identifies the tier in logs
+func (m *Memory) Name() string {
+	return "memory"
+}
+
+// Get returns the value of key, nil if it's missing or expired
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, exist := m.entries[key]
+	if !exist {
+		return nil, nil
+	}
+	if !m.now().Before(entry.expires) {
+		delete(m.entries, key)
+		return nil, nil
+	}
+	return entry.value, nil
+}
+
+// Set stores value under key, sweeping expired entries so the map doesn't grow with every city ever requested
+func (m *Memory) Set(ctx context.Context, key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for k, entry := range m.entries {
+		if !now.Before(entry.expires) {
+			delete(m.entries, k)
+		}
+	}
+
+	m.entries[key] = memoryEntry{
+		value:   value,
+		expires: now.Add(m.ttl),
+	}
+	return nil
+}
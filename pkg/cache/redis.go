@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/redis"
+)
+
+const defaultRedisBackoff = 30 * time.Second
+
+// ErrUnavailable is returned by a tier that is being skipped after failing to reach its server
+var ErrUnavailable = errors.New("cache: tier unavailable")
+
+// Redis is a tier shared between instances. Entries expire after the ttl.
+// When the server can't be reached the tier is skipped for the backoff period, so requests don't each wait out a timeout.
+type Redis struct {
+	client  *redis.Client
+	ttl     time.Duration
+	backoff time.Duration
+	now     func() time.Time
+
+	mu        sync.Mutex
+	downUntil time.Time
+}
+
+// NewRedis creates a redis tier
+func NewRedis(client *redis.Client, ttl time.Duration) *Redis {
+	return &Redis{
+		client:  client,
+		ttl:     ttl,
+		backoff: defaultRedisBackoff,
+		now:     time.Now,
+	}
+}
+
+// SetBackoff sets how long the tier is skipped after the server can't be reached
+func (r *Redis) SetBackoff(backoff time.Duration) {
+	r.backoff = backoff
+}
+
+// SetClock sets the time source used for the backoff, for tests
+func (r *Redis) SetClock(now func() time.Time) {
+	r.now = now
+}
+
+// Name identifies the tier in logs
+func (r *Redis) Name() string {
+	return "redis"
+}
+
+// Get returns the value of key, nil if it's missing or expired
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	if r.unavailable() {
+		return nil, ErrUnavailable
+	}
+
+	value, err := r.client.Get(ctx, key)
+	r.checkAvailable(ctx, err)
+	return value, err
+}
+
+// Set stores value under key
+func (r *Redis) Set(ctx context.Context, key string, value []byte) error {
+	if r.unavailable() {
+		return ErrUnavailable
+	}
+
+	err := r.client.Set(ctx, key, value, r.ttl)
+	r.checkAvailable(ctx, err)
+	return err
+}
+
+func (r *Redis) unavailable() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.now().Before(r.downUntil)
+}
+
+// checkAvailable starts the backoff on connection errors, error replies mean the server is up
+// and the caller's own cancellations and deadlines say nothing about it
+func (r *Redis) checkAvailable(ctx context.Context, err error) {
+	var replyErr redis.Error
+	if err == nil || ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.As(err, &replyErr) {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.downUntil = r.now().Add(r.backoff)
+}
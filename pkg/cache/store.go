@@ -0,0 +1,262 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// Store is a storage.Store that reads each city's latest weather through the cache tiers before the wrapped store,
+// e.g. memory then redis then postgres. Tier failures are logged and skipped, so a cache outage only costs latency.
+type Store struct {
+	store  storage.Store
+	tiers  []Tier
+	logger *logrus.Logger
+}
+
+var _ storage.Store = &Store{}
+
+// NewStore wraps store with the tiers, fastest first
+func NewStore(store storage.Store, tiers ...Tier) *Store {
+	return &Store{
+		store: store,
+		tiers: tiers,
+	}
+}
+
+func (s *Store) SetLogger(logger *logrus.Logger) {
+	s.logger = logger
+}
+
+func latestKey(city string) string {
+	return "weather:latest:" + city
+}
+
+// InsertWeatherData writes to the wrapped store, then through to the tiers unless they hold newer data
+func (s *Store) InsertWeatherData(ctx context.Context, weatherData *storage.WeatherData) (bool, error) {
+	inserted, err := s.store.InsertWeatherData(ctx, weatherData)
+	if err != nil {
+		return false, err
+	}
+
+	// Repeated observations still refresh the latest data
+	return inserted, s.writeThrough(ctx, weatherData)
+}
+
+// InsertWeatherDataBatch writes the batch to the wrapped store, then each row through to the tiers
+func (s *Store) InsertWeatherDataBatch(ctx context.Context, weatherData []*storage.WeatherData) (int, error) {
+	inserted, err := s.store.InsertWeatherDataBatch(ctx, weatherData)
+	if err != nil {
+		return inserted, err
+	}
+
+	for _, data := range weatherData {
+		err = s.writeThrough(ctx, data)
+		if err != nil {
+			return inserted, err
+		}
+	}
+	return inserted, nil
+}
+
+func (s *Store) writeThrough(ctx context.Context, weatherData *storage.WeatherData) error {
+	value, err := json.Marshal(weatherData)
+	if err != nil {
+		return err
+	}
+
+	key := latestKey(weatherData.City)
+	for _, tier := range s.tiers {
+		// Older data arriving late doesn't replace newer data, as in the stores
+		current, err := s.get(ctx, tier, key)
+		if err != nil {
+			continue
+		}
+		if current != nil && current.NewerThan(weatherData) {
+			continue
+		}
+
+		s.set(ctx, tier, key, value)
+	}
+
+	return nil
+}
+
+// GetLatestWeatherData returns the first tier's cached data, filling the faster tiers, or the wrapped store's data
+func (s *Store) GetLatestWeatherData(ctx context.Context, city string) (*storage.WeatherData, error) {
+	key := latestKey(city)
+
+	if data := s.fromTiers(ctx, key); data != nil {
+		return data, nil
+	}
+
+	data, err := s.store.GetLatestWeatherData(ctx, city)
+	if err != nil || data == nil {
+		return data, err
+	}
+	s.fill(ctx, s.tiers, key, data)
+
+	return data, nil
+}
+
+// GetLatestWeatherDataBatch reads each city through the tiers, then the cities they don't hold from the wrapped store
+// in one batch, filling the tiers. If the wrapped store fails, the cities found in the tiers are returned with the error.
+func (s *Store) GetLatestWeatherDataBatch(ctx context.Context, cities []string) (map[string]*storage.WeatherData, error) {
+	out := map[string]*storage.WeatherData{}
+	missing := []string{}
+	for _, city := range cities {
+		if data := s.fromTiers(ctx, latestKey(city)); data != nil {
+			out[city] = data
+			continue
+		}
+		missing = append(missing, city)
+	}
+	if len(missing) == 0 {
+		return out, nil
+	}
+
+	stored, err := s.store.GetLatestWeatherDataBatch(ctx, missing)
+	if err != nil {
+		return out, err
+	}
+	for city, data := range stored {
+		out[city] = data
+		s.fill(ctx, s.tiers, latestKey(city), data)
+	}
+
+	return out, nil
+}
+
+// fromTiers returns the first tier's data for key, filling the faster tiers, nil if no tier has it
+func (s *Store) fromTiers(ctx context.Context, key string) *storage.WeatherData {
+	for i, tier := range s.tiers {
+		data, err := s.get(ctx, tier, key)
+		if err != nil || data == nil {
+			continue
+		}
+
+		s.fill(ctx, s.tiers[:i], key, data)
+		return data
+	}
+	return nil
+}
+
+// fill sets key to data in the tiers
+func (s *Store) fill(ctx context.Context, tiers []Tier, key string, data *storage.WeatherData) {
+	if len(tiers) == 0 {
+		return
+	}
+	value, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	for _, tier := range tiers {
+		s.set(ctx, tier, key, value)
+	}
+}
+
+// ReserveProviderCall isn't cached, quotas are shared through the wrapped store
+func (s *Store) ReserveProviderCall(ctx context.Context, provider string, period string, limit int) (bool, error) {
+	return s.store.ReserveProviderCall(ctx, provider, period, limit)
+}
+
+// ReleaseProviderCall isn't cached
+func (s *Store) ReleaseProviderCall(ctx context.Context, provider string, period string) error {
+	return s.store.ReleaseProviderCall(ctx, provider, period)
+}
+
+// GetProviderUsage isn't cached
+func (s *Store) GetProviderUsage(ctx context.Context, provider string, period string) (int, error) {
+	return s.store.GetProviderUsage(ctx, provider, period)
+}
+
+// PruneWeatherData prunes the wrapped store's history, the tiers only hold latest data
+func (s *Store) PruneWeatherData(ctx context.Context, policy storage.RetentionPolicy) (*storage.PruneResult, error) {
+	return s.store.PruneWeatherData(ctx, policy)
+}
+
+// InsertForecast isn't cached, the tiers only hold latest weather
+func (s *Store) InsertForecast(ctx context.Context, forecast *storage.Forecast) error {
+	return s.store.InsertForecast(ctx, forecast)
+}
+
+// GetForecast isn't cached
+func (s *Store) GetForecast(ctx context.Context, city string) (*storage.Forecast, error) {
+	return s.store.GetForecast(ctx, city)
+}
+
+// InsertAlerts isn't cached
+func (s *Store) InsertAlerts(ctx context.Context, alerts *storage.Alerts) error {
+	return s.store.InsertAlerts(ctx, alerts)
+}
+
+// GetAlerts isn't cached
+func (s *Store) GetAlerts(ctx context.Context, key string) (*storage.Alerts, error) {
+	return s.store.GetAlerts(ctx, key)
+}
+
+// InsertAirQuality isn't cached
+func (s *Store) InsertAirQuality(ctx context.Context, airQuality *storage.AirQuality) error {
+	return s.store.InsertAirQuality(ctx, airQuality)
+}
+
+// GetAirQuality isn't cached
+func (s *Store) GetAirQuality(ctx context.Context, city string) (*storage.AirQuality, error) {
+	return s.store.GetAirQuality(ctx, city)
+}
+
+// InsertLocation isn't cached
+func (s *Store) InsertLocation(ctx context.Context, location *storage.Location) error {
+	return s.store.InsertLocation(ctx, location)
+}
+
+// GetLocation isn't cached
+func (s *Store) GetLocation(ctx context.Context, city string) (*storage.Location, error) {
+	return s.store.GetLocation(ctx, city)
+}
+
+// GetLocationBatch isn't cached
+func (s *Store) GetLocationBatch(ctx context.Context, cities []string) (map[string]*storage.Location, error) {
+	return s.store.GetLocationBatch(ctx, cities)
+}
+
+// get decodes the tier's data for key, logging failures
+func (s *Store) get(ctx context.Context, tier Tier, key string) (*storage.WeatherData, error) {
+	value, err := tier.Get(ctx, key)
+	if err != nil {
+		s.logTierError(tier, "Get", err)
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+
+	data := &storage.WeatherData{}
+	err = json.Unmarshal(value, data)
+	if err != nil {
+		s.logTierError(tier, "Get", err)
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *Store) set(ctx context.Context, tier Tier, key string, value []byte) {
+	err := tier.Set(ctx, key, value)
+	if err != nil {
+		s.logTierError(tier, "Set", err)
+	}
+}
+
+func (s *Store) logTierError(tier Tier, op string, err error) {
+	if s.logger == nil {
+		return
+	}
+	// Skipped tiers were logged when they went down
+	if err == ErrUnavailable {
+		s.logger.Debugf("%s cache %s skipped: %v\n", tier.Name(), op, err)
+		return
+	}
+	s.logger.Errorf("%s cache %s error: %v\n", tier.Name(), op, err)
+}
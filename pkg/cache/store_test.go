@@ -0,0 +1,238 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TomSED/weather-api/mocks"
+	"github.com/TomSED/weather-api/pkg/cache"
+	"github.com/TomSED/weather-api/pkg/redis"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/TomSED/weather-api/pkg/storage/memory"
+	"github.com/TomSED/weather-api/pkg/storage/storagetest"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	storagetest.TestStore(t, func(t *testing.T) storage.Store {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(server.Addr())
+		t.Cleanup(func() { client.Close() })
+
+		return cache.NewStore(memory.NewStore(), cache.NewMemory(time.Minute), cache.NewRedis(client, time.Hour))
+	})
+}
+
+func TestGetLatestWeatherData(t *testing.T) {
+
+	updatedDate := time.Date(2021, 5, 16, 2, 30, 0, 0, time.UTC)
+	weatherData := &storage.WeatherData{DataSource: "weatherstack", City: "sydney", Temperature: 17, WindSpeed: 9, UpdatedDate: updatedDate}
+
+	t.Run("On a miss it should read the store and fill every tier", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(server.Addr())
+		defer client.Close()
+
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return weatherData, nil
+			},
+		}
+		memoryTier := cache.NewMemory(time.Minute)
+		store := cache.NewStore(mockStore, memoryTier, cache.NewRedis(client, time.Hour))
+
+		data, err := store.GetLatestWeatherData(context.Background(), "sydney")
+		assert.Nil(t, err)
+		assert.Equal(t, 17, data.Temperature)
+
+		data, err = store.GetLatestWeatherData(context.Background(), "sydney")
+		assert.Nil(t, err)
+		assert.Equal(t, 17, data.Temperature)
+		assert.True(t, updatedDate.Equal(data.UpdatedDate))
+		assert.Len(t, mockStore.GetLatestWeatherDataCalls(), 1)
+
+		assert.True(t, server.Exists("weather:latest:sydney"))
+		assert.Equal(t, time.Hour, server.TTL("weather:latest:sydney"))
+		value, _ := memoryTier.Get(context.Background(), "weather:latest:sydney")
+		assert.NotNil(t, value)
+	})
+
+	t.Run("On a redis hit it should fill the memory tier without reading the store", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(server.Addr())
+		defer client.Close()
+
+		// Written by another instance
+		other := cache.NewStore(memory.NewStore(), cache.NewRedis(client, time.Hour))
+		_, err := other.InsertWeatherData(context.Background(), weatherData)
+		assert.Nil(t, err)
+
+		mockStore := &mocks.StoreMock{}
+		memoryTier := cache.NewMemory(time.Minute)
+		store := cache.NewStore(mockStore, memoryTier, cache.NewRedis(client, time.Hour))
+
+		data, err := store.GetLatestWeatherData(context.Background(), "sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assert.Equal(t, "weatherstack", data.DataSource)
+		value, _ := memoryTier.Get(context.Background(), "weather:latest:sydney")
+		assert.NotNil(t, value)
+	})
+
+	t.Run("If redis is down it should fall through to the store and skip redis until the backoff passes", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(server.Addr())
+		defer client.Close()
+
+		now := time.Now()
+		redisTier := cache.NewRedis(client, time.Hour)
+		redisTier.SetClock(func() time.Time { return now })
+		redisTier.SetBackoff(30 * time.Second)
+
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return weatherData, nil
+			},
+		}
+		store := cache.NewStore(mockStore, redisTier)
+
+		server.Close()
+		data, err := store.GetLatestWeatherData(context.Background(), "sydney")
+		assert.Nil(t, err)
+		assert.Equal(t, 17, data.Temperature)
+
+		assert.Nil(t, server.Restart())
+		_, err = redisTier.Get(context.Background(), "weather:latest:sydney")
+		assert.Equal(t, cache.ErrUnavailable, err)
+
+		now = now.Add(31 * time.Second)
+		data, err = store.GetLatestWeatherData(context.Background(), "sydney")
+		assert.Nil(t, err)
+		assert.Equal(t, 17, data.Temperature)
+		assert.True(t, server.Exists("weather:latest:sydney"))
+		assert.Len(t, mockStore.GetLatestWeatherDataCalls(), 2)
+	})
+
+	t.Run("If the caller's context is cancelled it shouldn't skip redis for later requests", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(server.Addr())
+		defer client.Close()
+
+		redisTier := cache.NewRedis(client, time.Hour)
+		assert.Nil(t, server.Set("key", "value"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := redisTier.Get(ctx, "key")
+		assert.NotNil(t, err)
+
+		value, err := redisTier.Get(context.Background(), "key")
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value"), value)
+	})
+
+	t.Run("It should return store errors", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return nil, errors.New("connection refused")
+			},
+		}
+		store := cache.NewStore(mockStore, cache.NewMemory(time.Minute))
+
+		data, err := store.GetLatestWeatherData(context.Background(), "sydney")
+		assert.NotNil(t, err)
+		assert.Nil(t, data)
+	})
+}
+
+func TestGetLatestWeatherDataBatch(t *testing.T) {
+
+	updatedDate := time.Date(2021, 5, 16, 2, 30, 0, 0, time.UTC)
+
+	t.Run("It should only read the cities the tiers miss from the store, in one batch", func(t *testing.T) {
+		memoryTier := cache.NewMemory(time.Minute)
+		cached := cache.NewStore(memory.NewStore(), memoryTier)
+		_, err := cached.InsertWeatherData(context.Background(), &storage.WeatherData{DataSource: "weatherstack", City: "sydney", Temperature: 17, UpdatedDate: updatedDate})
+		assert.Nil(t, err)
+
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataBatchFunc: func(ctx context.Context, cities []string) (map[string]*storage.WeatherData, error) {
+				return map[string]*storage.WeatherData{
+					"perth": {DataSource: "weatherstack", City: "perth", Temperature: 24, UpdatedDate: updatedDate},
+				}, nil
+			},
+		}
+		store := cache.NewStore(mockStore, memoryTier)
+
+		data, err := store.GetLatestWeatherDataBatch(context.Background(), []string{"sydney", "perth", "hobart"})
+		assert.Nil(t, err)
+		assert.Len(t, data, 2)
+		assert.Equal(t, 17, data["sydney"].Temperature)
+		assert.Equal(t, 24, data["perth"].Temperature)
+		if assert.Len(t, mockStore.GetLatestWeatherDataBatchCalls(), 1) {
+			assert.Equal(t, []string{"perth", "hobart"}, mockStore.GetLatestWeatherDataBatchCalls()[0].Cities)
+		}
+		value, _ := memoryTier.Get(context.Background(), "weather:latest:perth")
+		assert.NotNil(t, value)
+	})
+
+	t.Run("It should return the cached cities with the store's error", func(t *testing.T) {
+		memoryTier := cache.NewMemory(time.Minute)
+		cached := cache.NewStore(memory.NewStore(), memoryTier)
+		_, err := cached.InsertWeatherData(context.Background(), &storage.WeatherData{DataSource: "weatherstack", City: "sydney", Temperature: 17, UpdatedDate: updatedDate})
+		assert.Nil(t, err)
+
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataBatchFunc: func(ctx context.Context, cities []string) (map[string]*storage.WeatherData, error) {
+				return nil, errors.New("connection refused")
+			},
+		}
+		store := cache.NewStore(mockStore, memoryTier)
+
+		data, err := store.GetLatestWeatherDataBatch(context.Background(), []string{"sydney", "perth"})
+		assert.NotNil(t, err)
+		assert.Len(t, data, 1)
+		assert.Equal(t, 17, data["sydney"].Temperature)
+	})
+}
+
+func TestInsertWeatherData(t *testing.T) {
+
+	t.Run("It should not cache data if the store write fails", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				return false, errors.New("connection refused")
+			},
+		}
+		memoryTier := cache.NewMemory(time.Minute)
+		store := cache.NewStore(mockStore, memoryTier)
+
+		_, err := store.InsertWeatherData(context.Background(), &storage.WeatherData{DataSource: "weatherstack", City: "sydney", UpdatedDate: time.Now()})
+		assert.NotNil(t, err)
+		value, _ := memoryTier.Get(context.Background(), "weather:latest:sydney")
+		assert.Nil(t, value)
+	})
+}
+
+func TestMemory(t *testing.T) {
+
+	t.Run("It should expire entries after the ttl", func(t *testing.T) {
+		now := time.Now()
+		memoryTier := cache.NewMemory(time.Second)
+		memoryTier.SetClock(func() time.Time { return now })
+
+		assert.Nil(t, memoryTier.Set(context.Background(), "key", []byte("value")))
+		value, err := memoryTier.Get(context.Background(), "key")
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value"), value)
+
+		now = now.Add(time.Second)
+		value, err = memoryTier.Get(context.Background(), "key")
+		assert.Nil(t, err)
+		assert.Nil(t, value)
+	})
+}
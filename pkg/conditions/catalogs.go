@@ -0,0 +1,59 @@
+package conditions
+
+import (
+	"embed"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+)
+
+// English is the language conditions are described in by default
+const English = "en"
+
+// nightSuffix marks catalog entries replacing a condition's description at night, e.g. "clear_night"
+const nightSuffix = "_night"
+
+//go:embed catalogs/*.json
+var catalogFiles embed.FS
+
+// catalogs are the condition descriptions keyed by language, its ISO 639-1 code
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+	entries, err := catalogFiles.ReadDir("catalogs")
+	if err != nil {
+		panic(err)
+	}
+
+	out := map[string]map[string]string{}
+	for _, entry := range entries {
+		byt, err := catalogFiles.ReadFile(path.Join("catalogs", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		catalog := map[string]string{}
+		err = json.Unmarshal(byt, &catalog)
+		if err != nil {
+			panic("conditions: catalog " + entry.Name() + ": " + err.Error())
+		}
+		out[strings.TrimSuffix(entry.Name(), ".json")] = catalog
+	}
+	return out
+}
+
+// Languages returns the languages conditions can be described in, sorted
+func Languages() []string {
+	out := []string{}
+	for language := range catalogs {
+		out = append(out, language)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Supported reports whether conditions can be described in the language
+func Supported(language string) bool {
+	_, exist := catalogs[language]
+	return exist
+}
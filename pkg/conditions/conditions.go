@@ -0,0 +1,85 @@
+// Package conditions maps the providers' weather codes onto one taxonomy of conditions
+package conditions
+
+// Condition is a canonical weather condition
+type Condition string
+
+// Conditions, from clearest to most severe within each group
+const (
+	Unknown Condition = ""
+
+	Clear        Condition = "clear"
+	PartlyCloudy Condition = "partly_cloudy"
+	Cloudy       Condition = "cloudy"
+	Overcast     Condition = "overcast"
+
+	Mist Condition = "mist"
+	Fog  Condition = "fog"
+	Haze Condition = "haze"
+	Dust Condition = "dust"
+
+	Drizzle         Condition = "drizzle"
+	FreezingDrizzle Condition = "freezing_drizzle"
+	LightRain       Condition = "light_rain"
+	Rain            Condition = "rain"
+	HeavyRain       Condition = "heavy_rain"
+	FreezingRain    Condition = "freezing_rain"
+	Showers         Condition = "showers"
+
+	Sleet        Condition = "sleet"
+	LightSnow    Condition = "light_snow"
+	Snow         Condition = "snow"
+	HeavySnow    Condition = "heavy_snow"
+	SnowShowers  Condition = "snow_showers"
+	Blizzard     Condition = "blizzard"
+	IcePellets   Condition = "ice_pellets"
+	Thunderstorm Condition = "thunderstorm"
+	Squalls      Condition = "squalls"
+	Tornado      Condition = "tornado"
+)
+
+// variants are the conditions whose icons differ by day and night, where the sun or moon shows
+var variants = map[Condition]bool{
+	Clear:        true,
+	PartlyCloudy: true,
+	Showers:      true,
+	SnowShowers:  true,
+}
+
+// Text returns the condition's English description, empty if it's unknown
+func (c Condition) Text(night bool) string {
+	return c.TextIn(English, night)
+}
+
+// TextIn returns the condition's description in the language, empty if it's unknown or the language isn't supported
+func (c Condition) TextIn(language string, night bool) string {
+	catalog := catalogs[language]
+	if night {
+		if text, exist := catalog[string(c)+nightSuffix]; exist {
+			return text
+		}
+	}
+	return catalog[string(c)]
+}
+
+// Icon returns the condition's icon key, e.g. "partly-cloudy-night" or "heavy-rain", empty if it's unknown.
+// Keys are stable, clients map them to their own icon sets.
+func (c Condition) Icon(night bool) string {
+	if c.Text(false) == "" {
+		return ""
+	}
+
+	icon := []byte(c)
+	for i := range icon {
+		if icon[i] == '_' {
+			icon[i] = '-'
+		}
+	}
+	if !variants[c] {
+		return string(icon)
+	}
+	if night {
+		return string(icon) + "-night"
+	}
+	return string(icon) + "-day"
+}
@@ -0,0 +1,118 @@
+package conditions_test
+
+import (
+	"testing"
+
+	"github.com/TomSED/weather-api/pkg/conditions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromWeatherStack(t *testing.T) {
+
+	t.Run("It should map weatherstack's weather codes", func(t *testing.T) {
+		cases := map[int]conditions.Condition{
+			113: conditions.Clear,
+			116: conditions.PartlyCloudy,
+			122: conditions.Overcast,
+			248: conditions.Fog,
+			296: conditions.LightRain,
+			308: conditions.HeavyRain,
+			338: conditions.HeavySnow,
+			356: conditions.Showers,
+			389: conditions.Thunderstorm,
+			999: conditions.Unknown,
+		}
+		for code, expected := range cases {
+			assert.Equal(t, expected, conditions.FromWeatherStack(code), "weather_code %d", code)
+		}
+	})
+}
+
+func TestFromOpenWeatherMap(t *testing.T) {
+
+	t.Run("It should map openweathermap's condition ids, falling back to their group", func(t *testing.T) {
+		cases := map[int]conditions.Condition{
+			800: conditions.Clear,
+			802: conditions.PartlyCloudy,
+			804: conditions.Overcast,
+			741: conditions.Fog,
+			501: conditions.Rain,
+			511: conditions.FreezingRain,
+			521: conditions.Showers,
+			311: conditions.Drizzle,
+			601: conditions.Snow,
+			211: conditions.Thunderstorm,
+			781: conditions.Tornado,
+			0:   conditions.Unknown,
+			999: conditions.Unknown,
+		}
+		for id, expected := range cases {
+			assert.Equal(t, expected, conditions.FromOpenWeatherMap(id), "id %d", id)
+		}
+	})
+}
+
+func TestText(t *testing.T) {
+
+	t.Run("It should describe conditions by day and night", func(t *testing.T) {
+		assert.Equal(t, "Sunny", conditions.Clear.Text(false))
+		assert.Equal(t, "Clear", conditions.Clear.Text(true))
+		assert.Equal(t, "Heavy rain", conditions.HeavyRain.Text(true))
+		assert.Equal(t, "", conditions.Unknown.Text(false))
+	})
+}
+
+func TestTextIn(t *testing.T) {
+
+	t.Run("It should describe conditions in every supported language", func(t *testing.T) {
+		assert.Len(t, conditions.Languages(), 12)
+		for _, language := range conditions.Languages() {
+			assert.True(t, conditions.Supported(language))
+			for code := 100; code < 1000; code++ {
+				for _, condition := range []conditions.Condition{conditions.FromWeatherStack(code), conditions.FromOpenWeatherMap(code)} {
+					if condition == conditions.Unknown {
+						continue
+					}
+					assert.NotEmpty(t, condition.TextIn(language, false), "%s in %s", condition, language)
+					assert.NotEmpty(t, condition.TextIn(language, true), "%s at night in %s", condition, language)
+				}
+			}
+		}
+	})
+
+	t.Run("It should use the language's night descriptions", func(t *testing.T) {
+		assert.Equal(t, "Ensoleillé", conditions.Clear.TextIn("fr", false))
+		assert.Equal(t, "Dégagé", conditions.Clear.TextIn("fr", true))
+		assert.Equal(t, "Forte pluie", conditions.HeavyRain.TextIn("fr", true))
+		assert.Equal(t, "Gewitter", conditions.Thunderstorm.TextIn("de", false))
+	})
+
+	t.Run("It should be empty in unsupported languages", func(t *testing.T) {
+		assert.False(t, conditions.Supported("sv"))
+		assert.Equal(t, "", conditions.Clear.TextIn("sv", false))
+	})
+}
+
+func TestIcon(t *testing.T) {
+
+	t.Run("It should only have day and night icons where the sun or moon shows", func(t *testing.T) {
+		assert.Equal(t, "clear-day", conditions.Clear.Icon(false))
+		assert.Equal(t, "partly-cloudy-night", conditions.PartlyCloudy.Icon(true))
+		assert.Equal(t, "heavy-rain", conditions.HeavyRain.Icon(true))
+		assert.Equal(t, "overcast", conditions.Overcast.Icon(false))
+		assert.Equal(t, "", conditions.Unknown.Icon(false))
+		assert.Equal(t, "", conditions.Condition("volcano").Icon(false))
+	})
+
+	t.Run("It should map every provider code onto a condition with a text and icon", func(t *testing.T) {
+		for code := 100; code < 1000; code++ {
+			for _, condition := range []conditions.Condition{conditions.FromWeatherStack(code), conditions.FromOpenWeatherMap(code)} {
+				if condition == conditions.Unknown {
+					continue
+				}
+				assert.NotEmpty(t, condition.Text(false), "%s", condition)
+				assert.NotEmpty(t, condition.Icon(false), "%s", condition)
+			}
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package conditions
+
+// weatherStackCodes maps weatherstack's weather_code (the WWO condition codes)
+var weatherStackCodes = map[int]Condition{
+	113: Clear,
+	116: PartlyCloudy,
+	119: Cloudy,
+	122: Overcast,
+	143: Mist,
+	176: Showers,
+	179: LightSnow,
+	182: Sleet,
+	185: FreezingDrizzle,
+	200: Thunderstorm,
+	227: Snow,
+	230: Blizzard,
+	248: Fog,
+	260: Fog,
+	263: Drizzle,
+	266: Drizzle,
+	281: FreezingDrizzle,
+	284: FreezingDrizzle,
+	293: LightRain,
+	296: LightRain,
+	299: Rain,
+	302: Rain,
+	305: HeavyRain,
+	308: HeavyRain,
+	311: FreezingRain,
+	314: FreezingRain,
+	317: Sleet,
+	320: Sleet,
+	323: LightSnow,
+	326: LightSnow,
+	329: Snow,
+	332: Snow,
+	335: HeavySnow,
+	338: HeavySnow,
+	350: IcePellets,
+	353: Showers,
+	356: Showers,
+	359: HeavyRain,
+	362: Sleet,
+	365: Sleet,
+	368: SnowShowers,
+	371: SnowShowers,
+	374: IcePellets,
+	377: IcePellets,
+	386: Thunderstorm,
+	389: Thunderstorm,
+	392: Thunderstorm,
+	395: Thunderstorm,
+}
+
+// openWeatherMapIDs maps openweathermap's weather condition ids that aren't covered by their group
+var openWeatherMapIDs = map[int]Condition{
+	500: LightRain,
+	501: Rain,
+	502: HeavyRain,
+	503: HeavyRain,
+	504: HeavyRain,
+	511: FreezingRain,
+	600: LightSnow,
+	601: Snow,
+	602: HeavySnow,
+	611: Sleet,
+	612: Sleet,
+	613: Sleet,
+	615: Sleet,
+	616: Sleet,
+	620: SnowShowers,
+	621: SnowShowers,
+	622: SnowShowers,
+	701: Mist,
+	711: Haze,
+	721: Haze,
+	731: Dust,
+	741: Fog,
+	751: Dust,
+	761: Dust,
+	762: Dust,
+	771: Squalls,
+	781: Tornado,
+	800: Clear,
+	801: PartlyCloudy,
+	802: PartlyCloudy,
+	803: Cloudy,
+	804: Overcast,
+}
+
+// openWeatherMapGroups maps the rest of openweathermap's ids by group, their hundreds
+var openWeatherMapGroups = map[int]Condition{
+	2: Thunderstorm,
+	3: Drizzle,
+	5: Showers,
+}
+
+// FromWeatherStack returns the condition of a weatherstack weather_code, Unknown if it isn't one
+func FromWeatherStack(code int) Condition {
+	return weatherStackCodes[code]
+}
+
+// FromOpenWeatherMap returns the condition of an openweathermap weather condition id, Unknown if it isn't one
+func FromOpenWeatherMap(id int) Condition {
+	if condition, exist := openWeatherMapIDs[id]; exist {
+		return condition
+	}
+	return openWeatherMapGroups[id/100]
+}
@@ -0,0 +1,152 @@
+// Package derived computes comfort metrics from observed temperature, wind speed and humidity.
+// Temperatures are in celsius, wind speeds in km/h and humidity in percent, as stored by the weather service.
+package derived
+
+import "math"
+
+// Beaufort descriptions, indexed by force
+var beaufortDescriptions = []string{
+	"calm",
+	"light air",
+	"light breeze",
+	"gentle breeze",
+	"moderate breeze",
+	"fresh breeze",
+	"strong breeze",
+	"near gale",
+	"gale",
+	"strong gale",
+	"storm",
+	"violent storm",
+	"hurricane force",
+}
+
+// beaufortLimits are the lowest wind speeds in km/h of forces 1 to 12
+var beaufortLimits = []float64{1, 6, 12, 20, 29, 39, 50, 62, 75, 89, 103, 118}
+
+// Observation is the input to Compute
+type Observation struct {
+	Temperature float64
+	WindSpeed   float64
+	// Humidity is 0 if unknown, leaving out the metrics that need it
+	Humidity float64
+}
+
+// Beaufort is a Beaufort scale force and its description
+type Beaufort struct {
+	Force       int
+	Description string
+}
+
+// Metrics are the metrics an observation has the inputs and conditions for, nil otherwise
+type Metrics struct {
+	HeatIndex           *float64
+	WindChill           *float64
+	DewPoint            *float64
+	Humidex             *float64
+	ApparentTemperature *float64
+	Beaufort            Beaufort
+}
+
+// Compute returns every metric defined for the observation
+func Compute(obs Observation) Metrics {
+	out := Metrics{Beaufort: BeaufortScale(obs.WindSpeed)}
+
+	if windChill, ok := WindChill(obs.Temperature, obs.WindSpeed); ok {
+		out.WindChill = &windChill
+	}
+	if obs.Humidity <= 0 {
+		return out
+	}
+
+	dewPoint := DewPoint(obs.Temperature, obs.Humidity)
+	out.DewPoint = &dewPoint
+	if heatIndex, ok := HeatIndex(obs.Temperature, obs.Humidity); ok {
+		out.HeatIndex = &heatIndex
+	}
+	if humidex, ok := Humidex(obs.Temperature, dewPoint); ok {
+		out.Humidex = &humidex
+	}
+	apparent := ApparentTemperature(obs.Temperature, obs.Humidity, obs.WindSpeed)
+	out.ApparentTemperature = &apparent
+
+	return out
+}
+
+// HeatIndex is the NWS heat index: the Rothfusz regression with its low and high humidity adjustments, or Steadman's
+// simple formula where that's below 80°F. ok is false below 80°F air temperature, where the heat index isn't defined.
+func HeatIndex(temperature float64, humidity float64) (_ float64, ok bool) {
+	t := toFahrenheit(temperature)
+	if t < 80 {
+		return 0, false
+	}
+	rh := humidity
+
+	simple := 0.5 * (t + 61 + (t-68)*1.2 + rh*0.094)
+	if (simple+t)/2 < 80 {
+		return toCelsius(simple), true
+	}
+
+	hi := -42.379 + 2.04901523*t + 10.14333127*rh -
+		0.22475541*t*rh - 0.00683783*t*t - 0.05481717*rh*rh +
+		0.00122874*t*t*rh + 0.00085282*t*rh*rh - 0.00000199*t*t*rh*rh
+	if rh < 13 && t <= 112 {
+		hi -= (13 - rh) / 4 * math.Sqrt((17-math.Abs(t-95))/17)
+	}
+	if rh > 85 && t <= 87 {
+		hi += (rh - 85) / 10 * (87 - t) / 5
+	}
+	return toCelsius(hi), true
+}
+
+// WindChill is the wind chill index used by Environment Canada and the NWS since 2001. ok is false above 10°C or in
+// winds of 4.8 km/h or less, where it isn't defined.
+func WindChill(temperature float64, windSpeed float64) (_ float64, ok bool) {
+	if temperature > 10 || windSpeed <= 4.8 {
+		return 0, false
+	}
+	v := math.Pow(windSpeed, 0.16)
+	return 13.12 + 0.6215*temperature - 11.37*v + 0.3965*temperature*v, true
+}
+
+// DewPoint is the Magnus approximation of the dew point, with the Sonntag 1990 coefficients
+func DewPoint(temperature float64, humidity float64) float64 {
+	const a, b = 17.62, 243.12
+	gamma := math.Log(humidity/100) + a*temperature/(b+temperature)
+	return b * gamma / (a - gamma)
+}
+
+// Humidex is Environment Canada's humidex from the temperature and dew point. ok is false below 20°C or a humidex of
+// 25, which Environment Canada doesn't report.
+func Humidex(temperature float64, dewPoint float64) (_ float64, ok bool) {
+	e := 6.11 * math.Exp(5417.7530*(1/273.16-1/(273.15+dewPoint)))
+	humidex := temperature + 0.5555*(e-10)
+	if temperature < 20 || humidex < 25 {
+		return 0, false
+	}
+	return humidex, true
+}
+
+// ApparentTemperature is the Australian Bureau of Meteorology's apparent temperature (Steadman 1994, shaded, without
+// radiation)
+func ApparentTemperature(temperature float64, humidity float64, windSpeed float64) float64 {
+	e := humidity / 100 * 6.105 * math.Exp(17.27*temperature/(237.7+temperature))
+	return temperature + 0.33*e - 0.70*windSpeed/3.6 - 4.00
+}
+
+// BeaufortScale returns the Beaufort force of the wind speed
+func BeaufortScale(windSpeed float64) Beaufort {
+	force := 0
+	for force < len(beaufortLimits) && windSpeed >= beaufortLimits[force] {
+		force++
+	}
+	return Beaufort{Force: force, Description: beaufortDescriptions[force]}
+}
+
+func toFahrenheit(celsius float64) float64 {
+	return celsius*9/5 + 32
+}
+
+func toCelsius(fahrenheit float64) float64 {
+	return (fahrenheit - 32) * 5 / 9
+}
@@ -0,0 +1,178 @@
+package derived_test
+
+import (
+	"testing"
+
+	"github.com/TomSED/weather-api/pkg/derived"
+	"github.com/stretchr/testify/assert"
+)
+
+func celsius(fahrenheit float64) float64 {
+	return (fahrenheit - 32) * 5 / 9
+}
+
+func TestHeatIndex(t *testing.T) {
+
+	t.Run("It should match the NWS heat index chart", func(t *testing.T) {
+		// Temperatures and heat indices in °F, from the NWS heat index chart
+		cases := []struct {
+			temperature float64
+			humidity    float64
+			expected    float64
+		}{
+			{80, 40, 80},
+			{90, 70, 106},
+			{100, 40, 109},
+			{86, 90, 105},
+			{104, 55, 137},
+		}
+		for _, c := range cases {
+			heatIndex, ok := derived.HeatIndex(celsius(c.temperature), c.humidity)
+			assert.True(t, ok)
+			assert.InDelta(t, celsius(c.expected), heatIndex, celsius(33)-celsius(32), "%v°F at %v%%", c.temperature, c.humidity)
+		}
+	})
+
+	t.Run("It should not be defined below 80°F", func(t *testing.T) {
+		_, ok := derived.HeatIndex(25, 90)
+		assert.False(t, ok)
+	})
+}
+
+func TestWindChill(t *testing.T) {
+
+	t.Run("It should match Environment Canada's wind chill chart", func(t *testing.T) {
+		cases := []struct {
+			temperature float64
+			windSpeed   float64
+			expected    float64
+		}{
+			{5, 10, 3},
+			{-10, 20, -18},
+			{-20, 30, -33},
+			{-30, 50, -49},
+			{0, 60, -9},
+		}
+		for _, c := range cases {
+			windChill, ok := derived.WindChill(c.temperature, c.windSpeed)
+			assert.True(t, ok)
+			assert.InDelta(t, c.expected, windChill, 0.5, "%v°C at %v km/h", c.temperature, c.windSpeed)
+		}
+	})
+
+	t.Run("It should not be defined when it's warm or calm", func(t *testing.T) {
+		_, ok := derived.WindChill(15, 30)
+		assert.False(t, ok)
+		_, ok = derived.WindChill(-5, 4)
+		assert.False(t, ok)
+	})
+}
+
+func TestDewPoint(t *testing.T) {
+
+	t.Run("It should match published dew points", func(t *testing.T) {
+		cases := []struct {
+			temperature float64
+			humidity    float64
+			expected    float64
+		}{
+			{20, 50, 9.3},
+			{30, 80, 26.2},
+			{10, 100, 10},
+			{0, 60, -6.8},
+		}
+		for _, c := range cases {
+			assert.InDelta(t, c.expected, derived.DewPoint(c.temperature, c.humidity), 0.1, "%v°C at %v%%", c.temperature, c.humidity)
+		}
+	})
+}
+
+func TestHumidex(t *testing.T) {
+
+	t.Run("It should match Environment Canada's humidex chart", func(t *testing.T) {
+		cases := []struct {
+			temperature float64
+			dewPoint    float64
+			expected    float64
+		}{
+			{30, 15, 34},
+			{25, 20, 33},
+			{35, 25, 47},
+		}
+		for _, c := range cases {
+			humidex, ok := derived.Humidex(c.temperature, c.dewPoint)
+			assert.True(t, ok)
+			assert.InDelta(t, c.expected, humidex, 0.5, "%v°C with a dew point of %v°C", c.temperature, c.dewPoint)
+		}
+	})
+
+	t.Run("It should not be reported when it's cool", func(t *testing.T) {
+		_, ok := derived.Humidex(18, 15)
+		assert.False(t, ok)
+		_, ok = derived.Humidex(21, 0)
+		assert.False(t, ok)
+	})
+}
+
+func TestApparentTemperature(t *testing.T) {
+
+	t.Run("It should feel warmer when humid and cooler when windy", func(t *testing.T) {
+		assert.InDelta(t, 26.2, derived.ApparentTemperature(25, 50, 0), 0.1)
+		assert.InDelta(t, 19.2, derived.ApparentTemperature(25, 50, 36), 0.1)
+	})
+}
+
+func TestBeaufortScale(t *testing.T) {
+
+	t.Run("It should use the WMO wind speed ranges", func(t *testing.T) {
+		cases := []struct {
+			windSpeed   float64
+			force       int
+			description string
+		}{
+			{0, 0, "calm"},
+			{1, 1, "light air"},
+			{5, 1, "light air"},
+			{6, 2, "light breeze"},
+			{19, 3, "gentle breeze"},
+			{28, 4, "moderate breeze"},
+			{38, 5, "fresh breeze"},
+			{49, 6, "strong breeze"},
+			{50, 7, "near gale"},
+			{74, 8, "gale"},
+			{88, 9, "strong gale"},
+			{102, 10, "storm"},
+			{117, 11, "violent storm"},
+			{118, 12, "hurricane force"},
+			{250, 12, "hurricane force"},
+		}
+		for _, c := range cases {
+			assert.Equal(t, derived.Beaufort{Force: c.force, Description: c.description}, derived.BeaufortScale(c.windSpeed), "%v km/h", c.windSpeed)
+		}
+	})
+}
+
+func TestCompute(t *testing.T) {
+
+	t.Run("It should only compute the metrics the observation has inputs for", func(t *testing.T) {
+		metrics := derived.Compute(derived.Observation{Temperature: -10, WindSpeed: 20})
+		if assert.NotNil(t, metrics.WindChill) {
+			assert.InDelta(t, -17.9, *metrics.WindChill, 0.1)
+		}
+		assert.Nil(t, metrics.DewPoint)
+		assert.Nil(t, metrics.ApparentTemperature)
+		assert.Nil(t, metrics.HeatIndex)
+		assert.Nil(t, metrics.Humidex)
+		assert.Equal(t, derived.Beaufort{Force: 4, Description: "moderate breeze"}, metrics.Beaufort)
+	})
+
+	t.Run("It should compute the humidity metrics when it's known", func(t *testing.T) {
+		metrics := derived.Compute(derived.Observation{Temperature: 32, WindSpeed: 10, Humidity: 70})
+		assert.Nil(t, metrics.WindChill)
+		assert.NotNil(t, metrics.DewPoint)
+		assert.NotNil(t, metrics.ApparentTemperature)
+		assert.NotNil(t, metrics.HeatIndex)
+		assert.NotNil(t, metrics.Humidex)
+		assert.Equal(t, 2, metrics.Beaufort.Force)
+	})
+}
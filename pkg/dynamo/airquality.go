@@ -0,0 +1,118 @@
+package dynamo
+
+import (
+	"context"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type airQualityItem struct {
+	PK         string  `dynamodbav:"pk"`
+	SK         string  `dynamodbav:"sk"`
+	DataSource string  `dynamodbav:"datasource"`
+	City       string  `dynamodbav:"city"`
+	Lat        float64 `dynamodbav:"lat"`
+	Lon        float64 `dynamodbav:"lon"`
+	MeasuredAt string  `dynamodbav:"measuredat"`
+	FetchedAt  string  `dynamodbav:"fetchedat"`
+	PM25       float64 `dynamodbav:"pm2_5"`
+	PM10       float64 `dynamodbav:"pm10"`
+	O3         float64 `dynamodbav:"o3"`
+	NO2        float64 `dynamodbav:"no2"`
+	SO2        float64 `dynamodbav:"so2"`
+	CO         float64 `dynamodbav:"co"`
+}
+
+func airQualityKey(city string) string {
+	return "AIRQUALITY#" + city
+}
+
+// InsertAirQuality replaces the city's air quality unless a later reading is stored
+func (c *Client) InsertAirQuality(ctx context.Context, airQuality *storage.AirQuality) error {
+	item := airQualityItem{
+		PK:         airQualityKey(airQuality.City),
+		SK:         latestSortKey,
+		DataSource: airQuality.DataSource,
+		City:       airQuality.City,
+		Lat:        airQuality.Lat,
+		Lon:        airQuality.Lon,
+		MeasuredAt: formatTime(airQuality.MeasuredAt),
+		FetchedAt:  formatTime(airQuality.FetchedAt),
+		PM25:       airQuality.PM25,
+		PM10:       airQuality.PM10,
+		O3:         airQuality.O3,
+		NO2:        airQuality.NO2,
+		SO2:        airQuality.SO2,
+		CO:         airQuality.CO,
+	}
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.api.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(c.table),
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(fetchedat) OR fetchedat <= :fetchedat"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":fetchedat": &types.AttributeValueMemberS{Value: item.FetchedAt},
+		},
+	})
+	if err != nil && !isConditionalCheckFailed(err) {
+		return err
+	}
+
+	return nil
+}
+
+// GetAirQuality returns the city's stored air quality, nil if there is none
+func (c *Client) GetAirQuality(ctx context.Context, city string) (*storage.AirQuality, error) {
+	out, err := c.api.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.table),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: airQualityKey(city)},
+			"sk": &types.AttributeValueMemberS{Value: latestSortKey},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Item) == 0 {
+		return nil, nil
+	}
+
+	item := airQualityItem{}
+	err = attributevalue.UnmarshalMap(out.Item, &item)
+	if err != nil {
+		return nil, err
+	}
+
+	airQuality := &storage.AirQuality{
+		DataSource: item.DataSource,
+		City:       item.City,
+		Lat:        item.Lat,
+		Lon:        item.Lon,
+		PM25:       item.PM25,
+		PM10:       item.PM10,
+		O3:         item.O3,
+		NO2:        item.NO2,
+		SO2:        item.SO2,
+		CO:         item.CO,
+	}
+	airQuality.MeasuredAt, err = time.Parse(timeFormat, item.MeasuredAt)
+	if err != nil {
+		return nil, err
+	}
+	airQuality.FetchedAt, err = time.Parse(timeFormat, item.FetchedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return airQuality, nil
+}
@@ -0,0 +1,102 @@
+package dynamo
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type alertsItem struct {
+	PK        string  `dynamodbav:"pk"`
+	SK        string  `dynamodbav:"sk"`
+	Lat       float64 `dynamodbav:"lat"`
+	Lon       float64 `dynamodbav:"lon"`
+	FetchedAt string  `dynamodbav:"fetchedat"`
+	// Alerts is a JSON array of storage.Alert, as in the sql stores
+	Alerts string `dynamodbav:"alerts"`
+}
+
+func alertsKey(key string) string {
+	return "ALERTS#" + key
+}
+
+// InsertAlerts replaces the location's alerts unless later ones are stored
+func (c *Client) InsertAlerts(ctx context.Context, alerts *storage.Alerts) error {
+	byt, err := json.Marshal(alerts.Alerts)
+	if err != nil {
+		return err
+	}
+
+	item := alertsItem{
+		PK:        alertsKey(alerts.Key),
+		SK:        latestSortKey,
+		Lat:       alerts.Lat,
+		Lon:       alerts.Lon,
+		FetchedAt: formatTime(alerts.FetchedAt),
+		Alerts:    string(byt),
+	}
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.api.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(c.table),
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(fetchedat) OR fetchedat <= :fetchedat"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":fetchedat": &types.AttributeValueMemberS{Value: item.FetchedAt},
+		},
+	})
+	if err != nil && !isConditionalCheckFailed(err) {
+		return err
+	}
+
+	return nil
+}
+
+// GetAlerts returns the location's stored alerts, nil if there are none
+func (c *Client) GetAlerts(ctx context.Context, key string) (*storage.Alerts, error) {
+	out, err := c.api.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.table),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: alertsKey(key)},
+			"sk": &types.AttributeValueMemberS{Value: latestSortKey},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Item) == 0 {
+		return nil, nil
+	}
+
+	item := alertsItem{}
+	err = attributevalue.UnmarshalMap(out.Item, &item)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := &storage.Alerts{
+		Key: key,
+		Lat: item.Lat,
+		Lon: item.Lon,
+	}
+	alerts.FetchedAt, err = time.Parse(timeFormat, item.FetchedAt)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(item.Alerts), &alerts.Alerts)
+	if err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
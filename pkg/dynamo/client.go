@@ -0,0 +1,129 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+//go:generate moq -pkg mocks -out ../../mocks/mock_dynamodb_api.go . DynamoDBAPI
+
+// DynamoDBAPI is the part of the dynamodb client used by Client
+type DynamoDBAPI interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+	CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error)
+	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+	DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error)
+	UpdateTimeToLive(ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error)
+}
+
+// Client is a storage.Store backed by a single DynamoDB table keyed on pk (hash) and sk (range):
+//
+//	LATEST#<city>     <source>                latest observation per source, expiring after the latest TTL
+//	HISTORY#<city>    <updateddate>#<source>  observation history
+//	DAILY#<city>      <day>#<source>          daily aggregates of pruned history
+//	USAGE#<provider>  <period>                provider calls per billing period
+//	FORECAST#<city>   LATEST                  latest forecast
+//	ALERTS#<key>      LATEST                  latest alerts per location
+type Client struct {
+	api       DynamoDBAPI
+	table     string
+	latestTTL time.Duration
+	now       func() time.Time
+}
+
+var _ storage.Store = &Client{}
+
+// NewClient creates a client for the table
+func NewClient(api DynamoDBAPI, table string) *Client {
+	return &Client{
+		api:   api,
+		table: table,
+		now:   time.Now,
+	}
+}
+
+// SetLatestTTL sets how long latest items live after their observation before DynamoDB expires them, 0 keeps them forever
+func (c *Client) SetLatestTTL(ttl time.Duration) {
+	c.latestTTL = ttl
+}
+
+// SetClock sets the time source used for expiry, for tests
+func (c *Client) SetClock(now func() time.Time) {
+	c.now = now
+}
+
+// NewDynamoDB creates a dynamodb client from the default AWS config (env, shared config or role).
+// endpoint overrides the service endpoint, e.g. http://localhost:8000 for DynamoDB Local.
+func NewDynamoDB(ctx context.Context, endpoint string) (*dynamodb.Client, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+		if endpoint != "" {
+			o.EndpointResolver = dynamodb.EndpointResolverFromURL(endpoint)
+		}
+	}), nil
+}
+
+// CreateTable creates the table if it doesn't exist, waits for it to be active and enables expiry on the expires attribute
+func (c *Client) CreateTable(ctx context.Context) error {
+	_, err := c.api.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String(c.table),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("sk"), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String("sk"), KeyType: types.KeyTypeRange},
+		},
+		BillingMode: types.BillingModePayPerRequest,
+	})
+	if err != nil {
+		var inUse *types.ResourceInUseException
+		if !errors.As(err, &inUse) {
+			return err
+		}
+	}
+
+	err = dynamodb.NewTableExistsWaiter(c.api).Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(c.table)}, 2*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	ttl, err := c.api.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{TableName: aws.String(c.table)})
+	if err != nil {
+		return err
+	}
+	if ttl.TimeToLiveDescription != nil && ttl.TimeToLiveDescription.TimeToLiveStatus != types.TimeToLiveStatusDisabled {
+		return nil
+	}
+
+	_, err = c.api.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(c.table),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String("expires"),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	return err
+}
+
+func isConditionalCheckFailed(err error) bool {
+	var failed *types.ConditionalCheckFailedException
+	return errors.As(err, &failed)
+}
@@ -0,0 +1,387 @@
+package dynamo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TomSED/weather-api/mocks"
+	"github.com/TomSED/weather-api/pkg/dynamo"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var conditionalCheckFailed = &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+
+func stringAttribute(t *testing.T, item map[string]types.AttributeValue, name string) string {
+	t.Helper()
+
+	value, ok := item[name].(*types.AttributeValueMemberS)
+	if !ok {
+		t.Fatalf("attribute %s isn't a string: %#v", name, item[name])
+	}
+	return value.Value
+}
+
+func TestInsertWeatherData(t *testing.T) {
+
+	updatedDate := time.Date(2021, 5, 16, 2, 30, 0, 0, time.UTC)
+	weatherData := &storage.WeatherData{DataSource: "weatherstack", City: "sydney", Temperature: 17, WindSpeed: 9, UpdatedDate: updatedDate, ObservedAt: updatedDate.Add(-5 * time.Minute)}
+
+	t.Run("It should write a history item keyed by observation time and a conditional latest item that expires after the TTL", func(t *testing.T) {
+		mockAPI := &mocks.DynamoDBAPIMock{
+			PutItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+				return &dynamodb.PutItemOutput{}, nil
+			},
+		}
+		client := dynamo.NewClient(mockAPI, "weather")
+		client.SetLatestTTL(24 * time.Hour)
+
+		inserted, err := client.InsertWeatherData(context.Background(), weatherData)
+		assert.Nil(t, err)
+		assert.True(t, inserted)
+
+		calls := mockAPI.PutItemCalls()
+		if !assert.Len(t, calls, 2) {
+			t.FailNow()
+		}
+
+		history := calls[0].Params
+		assert.Equal(t, "weather", aws.ToString(history.TableName))
+		assert.Equal(t, "HISTORY#sydney", stringAttribute(t, history.Item, "pk"))
+		assert.Equal(t, "2021-05-16T02:25:00.000000Z#weatherstack", stringAttribute(t, history.Item, "sk"))
+		assert.Equal(t, "2021-05-16T02:30:00.000000Z", stringAttribute(t, history.Item, "updateddate"))
+		assert.Equal(t, "attribute_not_exists(pk)", aws.ToString(history.ConditionExpression))
+		assert.NotContains(t, history.Item, "expires")
+
+		latest := calls[1].Params
+		assert.Equal(t, "LATEST#sydney", stringAttribute(t, latest.Item, "pk"))
+		assert.Equal(t, "weatherstack", stringAttribute(t, latest.Item, "sk"))
+		assert.NotNil(t, latest.ConditionExpression)
+		assert.Equal(t, &types.AttributeValueMemberN{Value: "1621218600"}, latest.Item["expires"])
+	})
+
+	t.Run("It should ignore the latest item being newer", func(t *testing.T) {
+		mockAPI := &mocks.DynamoDBAPIMock{
+			PutItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+				if stringAttribute(t, params.Item, "pk") == "LATEST#sydney" {
+					return nil, conditionalCheckFailed
+				}
+				return &dynamodb.PutItemOutput{}, nil
+			},
+		}
+		client := dynamo.NewClient(mockAPI, "weather")
+
+		inserted, err := client.InsertWeatherData(context.Background(), weatherData)
+		assert.Nil(t, err)
+		assert.True(t, inserted)
+		assert.Len(t, mockAPI.PutItemCalls(), 2)
+	})
+
+	t.Run("It should report an observation already in the history and still update the latest item", func(t *testing.T) {
+		mockAPI := &mocks.DynamoDBAPIMock{
+			PutItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+				if stringAttribute(t, params.Item, "pk") == "HISTORY#sydney" {
+					return nil, conditionalCheckFailed
+				}
+				return &dynamodb.PutItemOutput{}, nil
+			},
+		}
+		client := dynamo.NewClient(mockAPI, "weather")
+
+		inserted, err := client.InsertWeatherData(context.Background(), weatherData)
+		assert.Nil(t, err)
+		assert.False(t, inserted)
+		assert.Len(t, mockAPI.PutItemCalls(), 2)
+	})
+
+	t.Run("It should return other write errors", func(t *testing.T) {
+		mockAPI := &mocks.DynamoDBAPIMock{
+			PutItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+				return nil, errors.New("throttled")
+			},
+		}
+		client := dynamo.NewClient(mockAPI, "weather")
+
+		_, err := client.InsertWeatherData(context.Background(), weatherData)
+		assert.NotNil(t, err)
+		assert.Len(t, mockAPI.PutItemCalls(), 1)
+	})
+}
+
+func TestInsertWeatherDataBatch(t *testing.T) {
+
+	t.Run("It should write each observation in order and count the new ones", func(t *testing.T) {
+		mockAPI := &mocks.DynamoDBAPIMock{
+			PutItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+				if stringAttribute(t, params.Item, "sk") == "2021-05-16T02:01:00.000000Z#weatherstack" {
+					return nil, conditionalCheckFailed
+				}
+				return &dynamodb.PutItemOutput{}, nil
+			},
+		}
+		client := dynamo.NewClient(mockAPI, "weather")
+
+		weatherData := []*storage.WeatherData{}
+		for i := 0; i < 3; i++ {
+			weatherData = append(weatherData, &storage.WeatherData{DataSource: "weatherstack", City: "sydney", Temperature: i, UpdatedDate: time.Date(2021, 5, 16, 2, i, 0, 0, time.UTC)})
+		}
+		inserted, err := client.InsertWeatherDataBatch(context.Background(), weatherData)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, inserted)
+
+		puts := mockAPI.PutItemCalls()
+		if !assert.Len(t, puts, 6) {
+			t.FailNow()
+		}
+		assert.Equal(t, "HISTORY#sydney", stringAttribute(t, puts[4].Params.Item, "pk"))
+		assert.Equal(t, "LATEST#sydney", stringAttribute(t, puts[5].Params.Item, "pk"))
+	})
+}
+
+func TestGetLatestWeatherData(t *testing.T) {
+
+	now := time.Date(2021, 5, 16, 3, 0, 0, 0, time.UTC)
+	latestItem := func(t *testing.T, source string, temperature int, updatedDate string, expires int64) map[string]types.AttributeValue {
+		item, err := attributevalue.MarshalMap(map[string]interface{}{
+			"pk":          "LATEST#sydney",
+			"sk":          source,
+			"datasource":  source,
+			"city":        "sydney",
+			"temperature": temperature,
+			"windspeed":   5,
+			"updateddate": updatedDate,
+			"expires":     expires,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return item
+	}
+
+	t.Run("It should return the newest unexpired item across sources", func(t *testing.T) {
+		mockAPI := &mocks.DynamoDBAPIMock{
+			QueryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+				return &dynamodb.QueryOutput{
+					Items: []map[string]types.AttributeValue{
+						latestItem(t, "weatherstack", 17, "2021-05-16T02:30:00.000000Z", 0),
+						latestItem(t, "openweathermap", 18, "2021-05-16T02:45:00.000000Z", now.Add(time.Hour).Unix()),
+						latestItem(t, "consensus", 19, "2021-05-16T02:50:00.000000Z", now.Add(-time.Second).Unix()),
+					},
+				}, nil
+			},
+		}
+		client := dynamo.NewClient(mockAPI, "weather")
+		client.SetClock(func() time.Time { return now })
+
+		data, err := client.GetLatestWeatherData(context.Background(), "sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assert.Equal(t, "openweathermap", data.DataSource)
+		assert.Equal(t, 18, data.Temperature)
+		assert.True(t, time.Date(2021, 5, 16, 2, 45, 0, 0, time.UTC).Equal(data.UpdatedDate))
+
+		calls := mockAPI.QueryCalls()
+		if !assert.Len(t, calls, 1) {
+			t.FailNow()
+		}
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "LATEST#sydney"}, calls[0].Params.ExpressionAttributeValues[":pk"])
+	})
+
+	t.Run("It should return nil if there are no items", func(t *testing.T) {
+		mockAPI := &mocks.DynamoDBAPIMock{
+			QueryFunc: func(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+				return &dynamodb.QueryOutput{}, nil
+			},
+		}
+		client := dynamo.NewClient(mockAPI, "weather")
+
+		data, err := client.GetLatestWeatherData(context.Background(), "sydney")
+		assert.Nil(t, err)
+		assert.Nil(t, data)
+	})
+}
+
+func TestReserveProviderCall(t *testing.T) {
+
+	t.Run("It should refuse the call once the limit is reached", func(t *testing.T) {
+		mockAPI := &mocks.DynamoDBAPIMock{
+			UpdateItemFunc: func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+				return nil, conditionalCheckFailed
+			},
+		}
+		client := dynamo.NewClient(mockAPI, "weather")
+
+		ok, err := client.ReserveProviderCall(context.Background(), "weatherstack", "2021-05", 250)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+
+		calls := mockAPI.UpdateItemCalls()
+		if !assert.Len(t, calls, 1) {
+			t.FailNow()
+		}
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "USAGE#weatherstack"}, calls[0].Params.Key["pk"])
+		assert.Equal(t, &types.AttributeValueMemberN{Value: "250"}, calls[0].Params.ExpressionAttributeValues[":limit"])
+	})
+
+	t.Run("It should count the call without a condition when unlimited", func(t *testing.T) {
+		mockAPI := &mocks.DynamoDBAPIMock{
+			UpdateItemFunc: func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+				return &dynamodb.UpdateItemOutput{}, nil
+			},
+		}
+		client := dynamo.NewClient(mockAPI, "weather")
+
+		ok, err := client.ReserveProviderCall(context.Background(), "weatherstack", "2021-05", 0)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		assert.Nil(t, mockAPI.UpdateItemCalls()[0].Params.ConditionExpression)
+	})
+}
+
+func TestPruneWeatherData(t *testing.T) {
+
+	now := time.Date(2021, 6, 30, 0, 0, 0, 0, time.UTC)
+	historyItems := func(t *testing.T, n int) []map[string]types.AttributeValue {
+		items := []map[string]types.AttributeValue{}
+		for i := 0; i < n; i++ {
+			updatedDate := time.Date(2021, 5, 1, i, 0, 0, 0, time.UTC).Format("2006-01-02T15:04:05.000000Z")
+			item, err := attributevalue.MarshalMap(map[string]interface{}{
+				"pk":          "HISTORY#sydney",
+				"sk":          updatedDate + "#weatherstack",
+				"datasource":  "weatherstack",
+				"city":        "sydney",
+				"temperature": 10 + i,
+				"windspeed":   i,
+				"updateddate": updatedDate,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			items = append(items, item)
+		}
+		return items
+	}
+
+	t.Run("It should roll up and delete old history in batches, retrying unprocessed deletes", func(t *testing.T) {
+		retried := false
+		mockAPI := &mocks.DynamoDBAPIMock{
+			ScanFunc: func(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+				return &dynamodb.ScanOutput{Items: historyItems(t, 3)}, nil
+			},
+			UpdateItemFunc: func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+				return &dynamodb.UpdateItemOutput{}, nil
+			},
+			BatchWriteItemFunc: func(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+				requests := params.RequestItems["weather"]
+				if !retried && len(requests) == 2 {
+					retried = true
+					return &dynamodb.BatchWriteItemOutput{
+						UnprocessedItems: map[string][]types.WriteRequest{"weather": requests[1:]},
+					}, nil
+				}
+				return &dynamodb.BatchWriteItemOutput{}, nil
+			},
+		}
+		client := dynamo.NewClient(mockAPI, "weather")
+		client.SetClock(func() time.Time { return now })
+
+		result, err := client.PruneWeatherData(context.Background(), storage.RetentionPolicy{RawRetention: 30 * 24 * time.Hour, Rollup: true, BatchSize: 2})
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, 3, result.Pruned)
+		assert.Equal(t, 2, result.Batches)
+		assert.True(t, now.Add(-30*24*time.Hour).Equal(result.Cutoff))
+
+		scans := mockAPI.ScanCalls()
+		if !assert.Len(t, scans, 1) {
+			t.FailNow()
+		}
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "2021-05-31T00:00:00.000000Z"}, scans[0].Params.ExpressionAttributeValues[":cutoff"])
+
+		writes := mockAPI.BatchWriteItemCalls()
+		if !assert.Len(t, writes, 3) {
+			t.FailNow()
+		}
+		assert.Len(t, writes[1].Params.RequestItems["weather"], 1)
+
+		// One daily group per batch, an add and a conditional update per extreme
+		updates := mockAPI.UpdateItemCalls()
+		if !assert.Len(t, updates, 8) {
+			t.FailNow()
+		}
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "DAILY#sydney"}, updates[0].Params.Key["pk"])
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "2021-05-01#weatherstack"}, updates[0].Params.Key["sk"])
+		assert.Equal(t, &types.AttributeValueMemberN{Value: "2"}, updates[0].Params.ExpressionAttributeValues[":samples"])
+		assert.Equal(t, &types.AttributeValueMemberN{Value: "21"}, updates[0].Params.ExpressionAttributeValues[":temperature_sum"])
+		assert.Equal(t, &types.AttributeValueMemberN{Value: "10"}, updates[1].Params.ExpressionAttributeValues[":value"])
+		assert.Equal(t, &types.AttributeValueMemberN{Value: "11"}, updates[2].Params.ExpressionAttributeValues[":value"])
+	})
+
+	t.Run("It should only delete when rollup is disabled", func(t *testing.T) {
+		mockAPI := &mocks.DynamoDBAPIMock{
+			ScanFunc: func(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+				return &dynamodb.ScanOutput{Items: historyItems(t, 3)}, nil
+			},
+			BatchWriteItemFunc: func(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+				return &dynamodb.BatchWriteItemOutput{}, nil
+			},
+		}
+		client := dynamo.NewClient(mockAPI, "weather")
+
+		result, err := client.PruneWeatherData(context.Background(), storage.RetentionPolicy{RawRetention: 30 * 24 * time.Hour})
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, 3, result.Pruned)
+		assert.Equal(t, 1, result.Batches)
+		assert.Len(t, mockAPI.UpdateItemCalls(), 0)
+	})
+
+	t.Run("It should roll history up into the day it was observed rather than fetched", func(t *testing.T) {
+		item, err := attributevalue.MarshalMap(map[string]interface{}{
+			"pk":          "HISTORY#sydney",
+			"sk":          "2021-04-30T23:00:00.000000Z#weatherstack",
+			"datasource":  "weatherstack",
+			"city":        "sydney",
+			"temperature": 10,
+			"windspeed":   1,
+			"updateddate": "2021-05-01T01:00:00.000000Z",
+			"observedat":  "2021-04-30T23:00:00.000000Z",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		mockAPI := &mocks.DynamoDBAPIMock{
+			ScanFunc: func(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+				return &dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{item}}, nil
+			},
+			UpdateItemFunc: func(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+				return &dynamodb.UpdateItemOutput{}, nil
+			},
+			BatchWriteItemFunc: func(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+				return &dynamodb.BatchWriteItemOutput{}, nil
+			},
+		}
+		client := dynamo.NewClient(mockAPI, "weather")
+		client.SetClock(func() time.Time { return now })
+
+		_, err = client.PruneWeatherData(context.Background(), storage.RetentionPolicy{RawRetention: 30 * 24 * time.Hour, Rollup: true})
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+
+		updates := mockAPI.UpdateItemCalls()
+		if !assert.NotEmpty(t, updates) {
+			t.FailNow()
+		}
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "2021-04-30#weatherstack"}, updates[0].Params.Key["sk"])
+	})
+}
@@ -0,0 +1,114 @@
+package dynamo
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Forecasts and alerts have a single item per location
+const latestSortKey = "LATEST"
+
+type forecastItem struct {
+	PK         string `dynamodbav:"pk"`
+	SK         string `dynamodbav:"sk"`
+	DataSource string `dynamodbav:"datasource"`
+	City       string `dynamodbav:"city"`
+	FetchedAt  string `dynamodbav:"fetchedat"`
+	// Hourly and Daily are JSON arrays of storage.ForecastPeriod, as in the sql stores
+	Hourly string `dynamodbav:"hourly"`
+	Daily  string `dynamodbav:"daily"`
+}
+
+func forecastKey(city string) string {
+	return "FORECAST#" + city
+}
+
+// InsertForecast replaces the city's forecast unless a later one is stored
+func (c *Client) InsertForecast(ctx context.Context, forecast *storage.Forecast) error {
+	hourly, err := json.Marshal(forecast.Hourly)
+	if err != nil {
+		return err
+	}
+	daily, err := json.Marshal(forecast.Daily)
+	if err != nil {
+		return err
+	}
+
+	item := forecastItem{
+		PK:         forecastKey(forecast.City),
+		SK:         latestSortKey,
+		DataSource: forecast.DataSource,
+		City:       forecast.City,
+		FetchedAt:  formatTime(forecast.FetchedAt),
+		Hourly:     string(hourly),
+		Daily:      string(daily),
+	}
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.api.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(c.table),
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(fetchedat) OR fetchedat <= :fetchedat"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":fetchedat": &types.AttributeValueMemberS{Value: item.FetchedAt},
+		},
+	})
+	if err != nil && !isConditionalCheckFailed(err) {
+		return err
+	}
+
+	return nil
+}
+
+// GetForecast returns the city's stored forecast, nil if there is none
+func (c *Client) GetForecast(ctx context.Context, city string) (*storage.Forecast, error) {
+	out, err := c.api.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.table),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: forecastKey(city)},
+			"sk": &types.AttributeValueMemberS{Value: latestSortKey},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Item) == 0 {
+		return nil, nil
+	}
+
+	item := forecastItem{}
+	err = attributevalue.UnmarshalMap(out.Item, &item)
+	if err != nil {
+		return nil, err
+	}
+
+	forecast := &storage.Forecast{
+		DataSource: item.DataSource,
+		City:       item.City,
+	}
+	forecast.FetchedAt, err = time.Parse(timeFormat, item.FetchedAt)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(item.Hourly), &forecast.Hourly)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(item.Daily), &forecast.Daily)
+	if err != nil {
+		return nil, err
+	}
+
+	return forecast, nil
+}
@@ -0,0 +1,108 @@
+package dynamo
+
+import (
+	"context"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type locationItem struct {
+	PK         string  `dynamodbav:"pk"`
+	SK         string  `dynamodbav:"sk"`
+	City       string  `dynamodbav:"city"`
+	Name       string  `dynamodbav:"name"`
+	Country    string  `dynamodbav:"country"`
+	Lat        float64 `dynamodbav:"lat"`
+	Lon        float64 `dynamodbav:"lon"`
+	Timezone   string  `dynamodbav:"timezone,omitempty"`
+	GeocodedAt string  `dynamodbav:"geocodedat"`
+}
+
+func locationKey(city string) string {
+	return "LOCATION#" + city
+}
+
+// InsertLocation replaces where the city geocodes to
+func (c *Client) InsertLocation(ctx context.Context, location *storage.Location) error {
+	item := locationItem{
+		PK:         locationKey(location.City),
+		SK:         latestSortKey,
+		City:       location.City,
+		Name:       location.Name,
+		Country:    location.Country,
+		Lat:        location.Lat,
+		Lon:        location.Lon,
+		Timezone:   location.Timezone,
+		GeocodedAt: formatTime(location.GeocodedAt),
+	}
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.api.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(c.table),
+		Item:      av,
+	})
+	return err
+}
+
+// GetLocation returns the city's stored location, nil if there is none
+func (c *Client) GetLocation(ctx context.Context, city string) (*storage.Location, error) {
+	out, err := c.api.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.table),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: locationKey(city)},
+			"sk": &types.AttributeValueMemberS{Value: latestSortKey},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Item) == 0 {
+		return nil, nil
+	}
+
+	item := locationItem{}
+	err = attributevalue.UnmarshalMap(out.Item, &item)
+	if err != nil {
+		return nil, err
+	}
+
+	location := &storage.Location{
+		City:     item.City,
+		Name:     item.Name,
+		Country:  item.Country,
+		Lat:      item.Lat,
+		Lon:      item.Lon,
+		Timezone: item.Timezone,
+	}
+	location.GeocodedAt, err = time.Parse(timeFormat, item.GeocodedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return location, nil
+}
+
+// GetLocationBatch returns the cities' stored locations keyed by city, cities without one are left out.
+// Each location is a separate item, so they are read one city at a time.
+func (c *Client) GetLocationBatch(ctx context.Context, cities []string) (map[string]*storage.Location, error) {
+	out := map[string]*storage.Location{}
+	for _, city := range cities {
+		location, err := c.GetLocation(ctx, city)
+		if err != nil {
+			return nil, err
+		}
+		if location != nil {
+			out[city] = location
+		}
+	}
+
+	return out, nil
+}
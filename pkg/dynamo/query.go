@@ -0,0 +1,330 @@
+package dynamo
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Fixed width so history sort keys order by time
+const timeFormat = "2006-01-02T15:04:05.000000Z"
+
+type weatherItem struct {
+	PK                  string   `dynamodbav:"pk"`
+	SK                  string   `dynamodbav:"sk"`
+	DataSource          string   `dynamodbav:"datasource"`
+	Sources             []string `dynamodbav:"sources,omitempty"`
+	City                string   `dynamodbav:"city"`
+	Temperature         int      `dynamodbav:"temperature"`
+	WindSpeed           int      `dynamodbav:"windspeed"`
+	Humidity            int      `dynamodbav:"humidity,omitempty"`
+	Condition           string   `dynamodbav:"condition,omitempty"`
+	Night               bool     `dynamodbav:"night,omitempty"`
+	Description         string   `dynamodbav:"description,omitempty"`
+	DescriptionLanguage string   `dynamodbav:"descriptionlanguage,omitempty"`
+	Timezone            string   `dynamodbav:"timezone,omitempty"`
+	UpdatedDate         string   `dynamodbav:"updateddate"`
+	// ObservedAt is missing on items written before observation times were stored
+	ObservedAt string `dynamodbav:"observedat,omitempty"`
+	// Expires is the epoch second DynamoDB may delete the item after
+	Expires int64 `dynamodbav:"expires,omitempty"`
+}
+
+// newerThan reports whether the item's observation is newer than other's, the later fetch breaking ties
+func (i *weatherItem) newerThan(other *weatherItem) bool {
+	observedAt, otherObservedAt := i.observationTime(), other.observationTime()
+	if observedAt != otherObservedAt {
+		return observedAt > otherObservedAt
+	}
+	return i.UpdatedDate > other.UpdatedDate
+}
+
+// observationTime is the item's formatted ObservedAt, falling back to UpdatedDate
+func (i *weatherItem) observationTime() string {
+	if i.ObservedAt == "" {
+		return i.UpdatedDate
+	}
+	return i.ObservedAt
+}
+
+func latestKey(city string) string {
+	return "LATEST#" + city
+}
+
+func historyKey(city string) string {
+	return "HISTORY#" + city
+}
+
+func dailyKey(city string) string {
+	return "DAILY#" + city
+}
+
+func usageKey(provider string) string {
+	return "USAGE#" + provider
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+// InsertWeatherData adds an observation to the history, unless it is already stored, and updates the latest item for
+// its city and source
+func (c *Client) InsertWeatherData(ctx context.Context, weatherData *storage.WeatherData) (bool, error) {
+	av, err := attributevalue.MarshalMap(newHistoryItem(weatherData))
+	if err != nil {
+		return false, err
+	}
+
+	// History is keyed by observation time, so an observation already stored fails the condition
+	inserted := true
+	_, err = c.api.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(c.table),
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(pk)"),
+	})
+	if isConditionalCheckFailed(err) {
+		inserted = false
+	} else if err != nil {
+		return false, err
+	}
+
+	return inserted, c.putLatest(ctx, weatherData)
+}
+
+// InsertWeatherDataBatch inserts the observations in order, returning how many were new.
+// DynamoDB can't batch conditional writes, so each observation is written on its own and, unlike the sql stores,
+// a failure can leave part of the batch stored.
+func (c *Client) InsertWeatherDataBatch(ctx context.Context, weatherData []*storage.WeatherData) (int, error) {
+	inserted := 0
+	for _, data := range weatherData {
+		ok, err := c.InsertWeatherData(ctx, data)
+		if err != nil {
+			return inserted, err
+		}
+		if ok {
+			inserted++
+		}
+	}
+
+	return inserted, nil
+}
+
+func newHistoryItem(weatherData *storage.WeatherData) weatherItem {
+	return weatherItem{
+		PK:                  historyKey(weatherData.City),
+		SK:                  formatTime(weatherData.ObservationTime()) + "#" + weatherData.DataSource,
+		DataSource:          weatherData.DataSource,
+		Sources:             weatherData.Sources,
+		City:                weatherData.City,
+		Temperature:         weatherData.Temperature,
+		WindSpeed:           weatherData.WindSpeed,
+		Humidity:            weatherData.Humidity,
+		Condition:           weatherData.Condition,
+		Night:               weatherData.Night,
+		Description:         weatherData.Description,
+		DescriptionLanguage: weatherData.DescriptionLanguage,
+		Timezone:            weatherData.Timezone,
+		UpdatedDate:         formatTime(weatherData.UpdatedDate),
+		ObservedAt:          formatTime(weatherData.ObservationTime()),
+	}
+}
+
+// putLatest replaces the city and source's latest item unless it's newer
+func (c *Client) putLatest(ctx context.Context, weatherData *storage.WeatherData) error {
+	item := newHistoryItem(weatherData)
+	item.PK = latestKey(weatherData.City)
+	item.SK = weatherData.DataSource
+	if c.latestTTL > 0 {
+		item.Expires = weatherData.UpdatedDate.Add(c.latestTTL).Unix()
+	}
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return err
+	}
+
+	// Older observations arriving late don't replace newer ones, the later fetch breaking ties.
+	// Items without an observation time were observed when they were fetched.
+	_, err = c.api.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(c.table),
+		Item:      av,
+		ConditionExpression: aws.String("attribute_not_exists(updateddate)" +
+			" OR observedat < :observedat OR (observedat = :observedat AND updateddate <= :updateddate)" +
+			" OR (attribute_not_exists(observedat) AND updateddate <= :observedat)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":observedat":  &types.AttributeValueMemberS{Value: item.ObservedAt},
+			":updateddate": &types.AttributeValueMemberS{Value: item.UpdatedDate},
+		},
+	})
+	if err != nil && !isConditionalCheckFailed(err) {
+		return err
+	}
+
+	return nil
+}
+
+// GetLatestWeatherData returns the city's most recent observation across sources, nil if there is none
+func (c *Client) GetLatestWeatherData(ctx context.Context, city string) (*storage.WeatherData, error) {
+	paginator := dynamodb.NewQueryPaginator(c.api, &dynamodb.QueryInput{
+		TableName:              aws.String(c.table),
+		KeyConditionExpression: aws.String("pk = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: latestKey(city)},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+
+	// Expired items can linger until DynamoDB gets around to deleting them
+	now := c.now().Unix()
+	var latest *weatherItem
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		items := []weatherItem{}
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &items)
+		if err != nil {
+			return nil, err
+		}
+		for i := range items {
+			if items[i].Expires > 0 && items[i].Expires < now {
+				continue
+			}
+			if latest == nil || items[i].newerThan(latest) {
+				latest = &items[i]
+			}
+		}
+	}
+
+	if latest == nil {
+		return nil, nil
+	}
+
+	updatedDate, err := time.Parse(timeFormat, latest.UpdatedDate)
+	if err != nil {
+		return nil, err
+	}
+	observedAt := time.Time{}
+	if latest.ObservedAt != "" {
+		observedAt, err = time.Parse(timeFormat, latest.ObservedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &storage.WeatherData{
+		DataSource:          latest.DataSource,
+		Sources:             latest.Sources,
+		City:                latest.City,
+		Temperature:         latest.Temperature,
+		WindSpeed:           latest.WindSpeed,
+		Humidity:            latest.Humidity,
+		Condition:           latest.Condition,
+		Night:               latest.Night,
+		Description:         latest.Description,
+		DescriptionLanguage: latest.DescriptionLanguage,
+		Timezone:            latest.Timezone,
+		UpdatedDate:         updatedDate,
+		ObservedAt:          observedAt,
+	}, nil
+}
+
+// GetLatestWeatherDataBatch returns each city's most recent observation across sources, keyed by city.
+// Each city's latest items are a separate partition, so they are queried one city at a time.
+func (c *Client) GetLatestWeatherDataBatch(ctx context.Context, cities []string) (map[string]*storage.WeatherData, error) {
+	out := map[string]*storage.WeatherData{}
+	for _, city := range cities {
+		data, err := c.GetLatestWeatherData(ctx, city)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil {
+			out[city] = data
+		}
+	}
+
+	return out, nil
+}
+
+// ReserveProviderCall counts a call to provider for the billing period unless it has reached limit (limit <= 0 is unlimited)
+func (c *Client) ReserveProviderCall(ctx context.Context, provider string, period string, limit int) (bool, error) {
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(c.table),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: usageKey(provider)},
+			"sk": &types.AttributeValueMemberS{Value: period},
+		},
+		UpdateExpression: aws.String("ADD calls :one"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one": &types.AttributeValueMemberN{Value: "1"},
+		},
+	}
+	if limit > 0 {
+		input.ConditionExpression = aws.String("attribute_not_exists(calls) OR calls < :limit")
+		input.ExpressionAttributeValues[":limit"] = &types.AttributeValueMemberN{Value: strconv.Itoa(limit)}
+	}
+
+	_, err := c.api.UpdateItem(ctx, input)
+	if err != nil {
+		if isConditionalCheckFailed(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ReleaseProviderCall gives back a call reserved by ReserveProviderCall that wasn't made
+func (c *Client) ReleaseProviderCall(ctx context.Context, provider string, period string) error {
+	_, err := c.api.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(c.table),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: usageKey(provider)},
+			"sk": &types.AttributeValueMemberS{Value: period},
+		},
+		UpdateExpression:    aws.String("ADD calls :minusone"),
+		ConditionExpression: aws.String("calls > :zero"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":minusone": &types.AttributeValueMemberN{Value: "-1"},
+			":zero":     &types.AttributeValueMemberN{Value: "0"},
+		},
+	})
+	if err != nil && !isConditionalCheckFailed(err) {
+		return err
+	}
+
+	return nil
+}
+
+// GetProviderUsage returns the number of calls made to provider in the billing period
+func (c *Client) GetProviderUsage(ctx context.Context, provider string, period string) (int, error) {
+	out, err := c.api.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.table),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: usageKey(provider)},
+			"sk": &types.AttributeValueMemberS{Value: period},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	usage := struct {
+		Calls int `dynamodbav:"calls"`
+	}{}
+	err = attributevalue.UnmarshalMap(out.Item, &usage)
+	if err != nil {
+		return 0, err
+	}
+
+	return usage.Calls, nil
+}
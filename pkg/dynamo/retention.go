@@ -0,0 +1,245 @@
+package dynamo
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// maxBatchWriteItems is the most items BatchWriteItem accepts per request
+const maxBatchWriteItems = 25
+
+type dailyGroup struct {
+	city           string
+	dataSource     string
+	day            string
+	samples        int
+	temperatureMin int
+	temperatureMax int
+	temperatureSum int
+	windSpeedMax   int
+	windSpeedSum   int
+}
+
+// PruneWeatherData deletes history older than the policy's retention, at most 25 items per batch.
+// Each batch is rolled up into the daily items before it is deleted, so an interrupted run can count a batch twice in the
+// aggregates but never loses it.
+func (c *Client) PruneWeatherData(ctx context.Context, policy storage.RetentionPolicy) (*storage.PruneResult, error) {
+	batchSize := policy.BatchSize
+	if batchSize <= 0 || batchSize > maxBatchWriteItems {
+		batchSize = maxBatchWriteItems
+	}
+
+	result := &storage.PruneResult{
+		Cutoff: c.now().UTC().Add(-policy.RawRetention),
+	}
+
+	// History sort keys start with the observation time so they compare against the cutoff as strings
+	paginator := dynamodb.NewScanPaginator(c.api, &dynamodb.ScanInput{
+		TableName:        aws.String(c.table),
+		FilterExpression: aws.String("begins_with(pk, :history) AND sk < :cutoff"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":history": &types.AttributeValueMemberS{Value: historyKey("")},
+			":cutoff":  &types.AttributeValueMemberS{Value: formatTime(result.Cutoff)},
+		},
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return result, err
+		}
+
+		items := []weatherItem{}
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &items)
+		if err != nil {
+			return result, err
+		}
+
+		for start := 0; start < len(items); start += batchSize {
+			end := start + batchSize
+			if end > len(items) {
+				end = len(items)
+			}
+			batch := items[start:end]
+
+			if policy.Rollup {
+				err = c.rollup(ctx, batch)
+				if err != nil {
+					return result, err
+				}
+			}
+
+			err = c.deleteItems(ctx, batch)
+			if err != nil {
+				return result, err
+			}
+			result.Pruned += len(batch)
+			result.Batches++
+		}
+	}
+
+	return result, nil
+}
+
+// deleteItems deletes the items
+func (c *Client) deleteItems(ctx context.Context, items []weatherItem) error {
+	requests := []types.WriteRequest{}
+	for _, item := range items {
+		requests = append(requests, types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{
+				Key: map[string]types.AttributeValue{
+					"pk": &types.AttributeValueMemberS{Value: item.PK},
+					"sk": &types.AttributeValueMemberS{Value: item.SK},
+				},
+			},
+		})
+	}
+
+	return c.batchWrite(ctx, requests)
+}
+
+// batchWrite sends up to 25 requests, retrying any the table didn't process
+func (c *Client) batchWrite(ctx context.Context, requests []types.WriteRequest) error {
+	backoff := 50 * time.Millisecond
+	for len(requests) > 0 {
+		out, err := c.api.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{c.table: requests},
+		})
+		if err != nil {
+			return err
+		}
+
+		requests = out.UnprocessedItems[c.table]
+		if len(requests) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+
+	return nil
+}
+
+// rollup adds the items to their city, source and observation day aggregates
+func (c *Client) rollup(ctx context.Context, items []weatherItem) error {
+	groups := map[string]*dailyGroup{}
+	order := []string{}
+	for _, item := range items {
+		day := item.observationTime()[:len("2006-01-02")]
+		key := item.City + "\x00" + item.DataSource + "\x00" + day
+
+		group, exist := groups[key]
+		if !exist {
+			group = &dailyGroup{
+				city:           item.City,
+				dataSource:     item.DataSource,
+				day:            day,
+				temperatureMin: item.Temperature,
+				temperatureMax: item.Temperature,
+				windSpeedMax:   item.WindSpeed,
+			}
+			groups[key] = group
+			order = append(order, key)
+		}
+
+		group.samples++
+		group.temperatureSum += item.Temperature
+		group.windSpeedSum += item.WindSpeed
+		group.temperatureMin = minInt(group.temperatureMin, item.Temperature)
+		group.temperatureMax = maxInt(group.temperatureMax, item.Temperature)
+		group.windSpeedMax = maxInt(group.windSpeedMax, item.WindSpeed)
+	}
+
+	for _, key := range order {
+		err := c.addToDaily(ctx, groups[key])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) addToDaily(ctx context.Context, group *dailyGroup) error {
+	key := map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: dailyKey(group.city)},
+		"sk": &types.AttributeValueMemberS{Value: group.day + "#" + group.dataSource},
+	}
+
+	_, err := c.api.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        aws.String(c.table),
+		Key:              key,
+		UpdateExpression: aws.String("SET city = :city, datasource = :datasource, #day = :day ADD samples :samples, temperature_sum :temperature_sum, windspeed_sum :windspeed_sum"),
+		ExpressionAttributeNames: map[string]string{
+			"#day": "day",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":city":            &types.AttributeValueMemberS{Value: group.city},
+			":datasource":      &types.AttributeValueMemberS{Value: group.dataSource},
+			":day":             &types.AttributeValueMemberS{Value: group.day},
+			":samples":         numberValue(group.samples),
+			":temperature_sum": numberValue(group.temperatureSum),
+			":windspeed_sum":   numberValue(group.windSpeedSum),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// DynamoDB has no min/max update, so each extreme is only set when the condition holds
+	extremes := []struct {
+		attribute  string
+		comparison string
+		value      int
+	}{
+		{"temperature_min", ">", group.temperatureMin},
+		{"temperature_max", "<", group.temperatureMax},
+		{"windspeed_max", "<", group.windSpeedMax},
+	}
+	for _, extreme := range extremes {
+		_, err = c.api.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName:           aws.String(c.table),
+			Key:                 key,
+			UpdateExpression:    aws.String("SET " + extreme.attribute + " = :value"),
+			ConditionExpression: aws.String("attribute_not_exists(" + extreme.attribute + ") OR " + extreme.attribute + " " + extreme.comparison + " :value"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":value": numberValue(extreme.value),
+			},
+		})
+		if err != nil && !isConditionalCheckFailed(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func numberValue(n int) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.Itoa(n)}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
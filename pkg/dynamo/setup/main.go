@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/TomSED/weather-api/pkg/dynamo"
+)
+
+// Usage: go run pkg/dynamo/setup/main.go
+// Creates the DYNAMODB_TABLE table (default weather) if it doesn't exist and enables expiry of latest items.
+func main() {
+
+	table := os.Getenv("DYNAMODB_TABLE")
+	if table == "" {
+		table = "weather"
+	}
+
+	api, err := dynamo.NewDynamoDB(context.Background(), os.Getenv("DYNAMODB_ENDPOINT"))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	err = dynamo.NewClient(api, table).CreateTable(context.Background())
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Printf("table %s is ready\n", table)
+}
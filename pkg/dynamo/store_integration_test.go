@@ -0,0 +1,45 @@
+//go:build integration
+// +build integration
+
+package dynamo_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/dynamo"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/TomSED/weather-api/pkg/storage/storagetest"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// Runs the storage conformance suite against DynamoDB Local at DYNAMODB_ENDPOINT, in a new table per test.
+// go test -tags integration ./pkg/dynamo/...
+func TestClientStore(t *testing.T) {
+	endpoint := os.Getenv("DYNAMODB_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("DYNAMODB_ENDPOINT isn't set")
+	}
+
+	api, err := dynamo.NewDynamoDB(context.Background(), endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storagetest.TestStore(t, func(t *testing.T) storage.Store {
+		table := fmt.Sprintf("weather-test-%d", time.Now().UnixNano())
+		client := dynamo.NewClient(api, table)
+		err := client.CreateTable(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			api.DeleteTable(context.Background(), &dynamodb.DeleteTableInput{TableName: aws.String(table)})
+		})
+		return client
+	})
+}
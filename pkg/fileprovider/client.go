@@ -0,0 +1,170 @@
+package fileprovider
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FailAll can be listed in a reading's fail list to fail every provider
+	FailAll = "*"
+)
+
+// Reading is a single step of a city's fixture
+type Reading struct {
+	Temperature float64 `yaml:"temperature"`
+	WindSpeed   float64 `yaml:"wind_speed"`
+	// Humidity is the relative humidity in percent, left out if unknown
+	Humidity int      `yaml:"humidity"`
+	Fail     []string `yaml:"fail"`
+}
+
+// AirQuality is a city's pollutant concentrations in µg/m³
+type AirQuality struct {
+	PM25 float64 `yaml:"pm2_5"`
+	PM10 float64 `yaml:"pm10"`
+	O3   float64 `yaml:"o3"`
+	NO2  float64 `yaml:"no2"`
+	SO2  float64 `yaml:"so2"`
+	CO   float64 `yaml:"co"`
+}
+
+// Fixture is the weather served for a city. Readings are stepped through per call,
+// or by elapsed time when Interval is set, wrapping around at the end.
+type Fixture struct {
+	City string `yaml:"city"`
+	// Lat and Lon are where the city geocodes to
+	Lat      float64       `yaml:"lat"`
+	Lon      float64       `yaml:"lon"`
+	Interval time.Duration `yaml:"interval"`
+	Readings []Reading     `yaml:"readings"`
+	// AirQuality is served at the city's coordinates, clean air when unset
+	AirQuality AirQuality `yaml:"air_quality"`
+}
+
+// Client serves weather from a directory of YAML/JSON fixtures, one file per city
+type Client struct {
+	fixtures map[string]*Fixture
+	start    time.Time
+	now      func() time.Time
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+// NewClient loads every .yaml, .yml and .json fixture in dir
+func NewClient(dir string) (*Client, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fixtures := map[string]*Fixture{}
+	for _, file := range files {
+		ext := filepath.Ext(file.Name())
+		if file.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+
+		byt, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		// JSON is valid YAML so both are parsed the same way
+		fixture := &Fixture{}
+		err = yaml.Unmarshal(byt, fixture)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file.Name(), err)
+		}
+		if fixture.City == "" {
+			fixture.City = strings.TrimSuffix(file.Name(), ext)
+		}
+		if len(fixture.Readings) == 0 {
+			return nil, fmt.Errorf("%s: no readings", file.Name())
+		}
+
+		fixtures[strings.ToLower(fixture.City)] = fixture
+	}
+
+	return &Client{
+		fixtures: fixtures,
+		start:    time.Now(),
+		now:      time.Now,
+		calls:    map[string]int{},
+	}, nil
+}
+
+// SetClock overrides the clock used for time-varying fixtures
+func (c *Client) SetClock(now func() time.Time) {
+	c.start = now()
+	c.now = now
+}
+
+// fixture returns the city's fixture
+func (c *Client) fixture(city string) (*Fixture, error) {
+	fixture, exist := c.fixtures[strings.ToLower(city)]
+	if !exist {
+		return nil, fmt.Errorf("fileprovider: no fixture for city %q", city)
+	}
+	return fixture, nil
+}
+
+// fixtureAt returns the fixture of the city geocoded to the point
+func (c *Client) fixtureAt(lat float64, lon float64) (*Fixture, error) {
+	for _, fixture := range c.fixtures {
+		if math.Abs(fixture.Lat-lat) < 0.01 && math.Abs(fixture.Lon-lon) < 0.01 {
+			return fixture, nil
+		}
+	}
+	return nil, fmt.Errorf("fileprovider: no fixture at %v,%v", lat, lon)
+}
+
+// reading returns the current reading of a city for a provider, or the injected failure
+func (c *Client) reading(provider string, city string) (*Reading, error) {
+	readings, err := c.readings(provider, city, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &readings[0], nil
+}
+
+// readings returns n readings of a city for a provider from the current one on, wrapping around the fixture,
+// or the current reading's injected failure
+func (c *Client) readings(provider string, city string, n int) ([]Reading, error) {
+	fixture, err := c.fixture(city)
+	if err != nil {
+		return nil, err
+	}
+
+	var step int
+	if fixture.Interval > 0 {
+		step = int(c.now().Sub(c.start) / fixture.Interval)
+	} else {
+		// Each provider steps through the sequence independently so failover sees the same step
+		c.mu.Lock()
+		key := provider + "/" + strings.ToLower(city)
+		step = c.calls[key]
+		c.calls[key]++
+		c.mu.Unlock()
+	}
+
+	for _, fail := range fixture.Readings[step%len(fixture.Readings)].Fail {
+		if fail == provider || fail == FailAll {
+			return nil, fmt.Errorf("fileprovider: injected %s failure for city %q", provider, city)
+		}
+	}
+
+	out := make([]Reading, n)
+	for i := range out {
+		out[i] = fixture.Readings[(step+i)%len(fixture.Readings)]
+	}
+	return out, nil
+}
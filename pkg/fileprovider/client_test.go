@@ -0,0 +1,201 @@
+package fileprovider_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/fileprovider"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFixture(t *testing.T, dir, name, content string) {
+	err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetWeather(t *testing.T) {
+
+	t.Run("It should serve yaml and json fixtures in provider units", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFixture(t, dir, "sydney.yaml", "readings:\n  - temperature: 15\n    wind_speed: 36\n    humidity: 60\n")
+		writeFixture(t, dir, "melbourne.json", `{"city": "Melbourne", "readings": [{"temperature": 10, "wind_speed": 18}]}`)
+		writeFixture(t, dir, "README.md", "not a fixture")
+
+		client, err := fileprovider.NewClient(dir)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+
+		weatherStackResp, err := client.WeatherStack().GetWeather("Sydney", "")
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 15, weatherStackResp.Current.Temperature)
+		assert.Equal(t, 36, weatherStackResp.Current.WindSpeed)
+		assert.Equal(t, 60, weatherStackResp.Current.Humidity)
+
+		openWeatherMapResp, err := client.OpenWeatherMap().GetWeather("melbourne", "")
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.InDelta(t, 10, openWeatherMapResp.Main.Temp, 0.001)
+		assert.InDelta(t, 5, openWeatherMapResp.Wind.Speed, 0.001)
+		assert.Equal(t, 0, openWeatherMapResp.Main.Humidity)
+
+		_, err = client.WeatherStack().GetWeather("Perth", "")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("It should step through sequences per provider and inject failures", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFixture(t, dir, "sydney.yaml", `
+readings:
+  - temperature: 15
+    wind_speed: 10
+  - temperature: 16
+    wind_speed: 11
+    fail: [weatherstack]
+  - temperature: 17
+    wind_speed: 12
+    fail: ["*"]
+`)
+		client, err := fileprovider.NewClient(dir)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+
+		resp, err := client.WeatherStack().GetWeather("Sydney", "")
+		assert.Nil(t, err)
+		assert.Equal(t, 15, resp.Current.Temperature)
+
+		_, err = client.WeatherStack().GetWeather("Sydney", "")
+		assert.NotNil(t, err)
+
+		// openweathermap is still on its first step
+		_, err = client.OpenWeatherMap().GetWeather("Sydney", "")
+		assert.Nil(t, err)
+		_, err = client.OpenWeatherMap().GetWeather("Sydney", "")
+		assert.Nil(t, err)
+		_, err = client.OpenWeatherMap().GetWeather("Sydney", "")
+		assert.NotNil(t, err)
+
+		// Wraps around
+		_, err = client.WeatherStack().GetWeather("Sydney", "")
+		assert.NotNil(t, err)
+		resp, err = client.WeatherStack().GetWeather("Sydney", "")
+		assert.Nil(t, err)
+		assert.Equal(t, 15, resp.Current.Temperature)
+	})
+
+	t.Run("It should step through sequences by time when an interval is set", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFixture(t, dir, "sydney.yaml", "interval: 10m\nreadings:\n  - temperature: 15\n  - temperature: 20\n")
+
+		client, err := fileprovider.NewClient(dir)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		now := time.Date(2021, 5, 16, 0, 0, 0, 0, time.UTC)
+		client.SetClock(func() time.Time { return now })
+
+		resp, _ := client.WeatherStack().GetWeather("Sydney", "")
+		assert.Equal(t, 15, resp.Current.Temperature)
+		resp, _ = client.WeatherStack().GetWeather("Sydney", "")
+		assert.Equal(t, 15, resp.Current.Temperature)
+
+		now = now.Add(11 * time.Minute)
+		resp, _ = client.WeatherStack().GetWeather("Sydney", "")
+		assert.Equal(t, 20, resp.Current.Temperature)
+	})
+
+	t.Run("It should reject fixtures without readings", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFixture(t, dir, "sydney.yaml", "city: Sydney\n")
+
+		_, err := fileprovider.NewClient(dir)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestGetForecast(t *testing.T) {
+
+	t.Run("It should serve the readings from the current one on as 3 hourly steps", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFixture(t, dir, "sydney.yaml", "readings:\n  - temperature: 15\n    wind_speed: 36\n  - temperature: 20\n    wind_speed: 18\n")
+
+		client, err := fileprovider.NewClient(dir)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		now := time.Date(2021, 5, 16, 10, 30, 0, 0, time.UTC)
+		client.SetClock(func() time.Time { return now })
+
+		openWeatherMapResp, err := client.OpenWeatherMap().GetForecast("Sydney", 1)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		if assert.Len(t, openWeatherMapResp.List, 8) {
+			assert.Equal(t, int(time.Date(2021, 5, 16, 9, 0, 0, 0, time.UTC).Unix()), openWeatherMapResp.List[0].Dt)
+			assert.InDelta(t, 15, openWeatherMapResp.List[0].Main.Temp, 0.001)
+			assert.InDelta(t, 5, openWeatherMapResp.List[1].Wind.Speed, 0.001)
+		}
+
+		weatherStackResp, err := client.WeatherStack().GetForecast("Sydney", 2)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		days := weatherStackResp.Days()
+		if assert.Len(t, days, 2) {
+			assert.Equal(t, "2021-05-16", days[0].Date)
+			assert.Equal(t, 15, days[0].MinTemp)
+			assert.Equal(t, 20, days[0].MaxTemp)
+			assert.Equal(t, "300", days[0].Hourly[1].Time)
+			assert.Equal(t, 18, days[0].Hourly[1].WindSpeed)
+		}
+	})
+
+	t.Run("It should inject failures", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFixture(t, dir, "sydney.yaml", "readings:\n  - temperature: 15\n    fail: ['*']\n")
+
+		client, err := fileprovider.NewClient(dir)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+
+		_, err = client.OpenWeatherMap().GetForecast("Sydney", 1)
+		assert.NotNil(t, err)
+		_, err = client.WeatherStack().GetForecast("Sydney", 1)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestGetAirPollution(t *testing.T) {
+
+	t.Run("It should serve the air quality of the fixture geocoded to the point", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFixture(t, dir, "sydney.yaml", "lat: -33.8698\nlon: 151.2083\nair_quality:\n  pm2_5: 8.5\n  co: 230.3\nreadings:\n  - temperature: 15\n")
+
+		client, err := fileprovider.NewClient(dir)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+
+		resp, err := client.OpenWeatherMap().GetAirPollution(-33.8698, 151.2083)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		if assert.Len(t, resp.List, 1) {
+			assert.Equal(t, 8.5, resp.List[0].Components.Pm25)
+			assert.Equal(t, 230.3, resp.List[0].Components.Co)
+			assert.Equal(t, 0.0, resp.List[0].Components.No2)
+		}
+
+		_, err = client.OpenWeatherMap().GetAirPollution(40.7128, -74.006)
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,177 @@
+package fileprovider
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/openweathermap"
+	"github.com/TomSED/weather-api/pkg/weatherstack"
+)
+
+const (
+	weatherStackProvider   = "weatherstack"
+	openWeatherMapProvider = "openweathermap"
+)
+
+// WeatherStackClient serves fixtures shaped like weatherstack.APIResponse
+type WeatherStackClient struct {
+	client *Client
+}
+
+// OpenWeatherMapClient serves fixtures shaped like openweathermap.APIResponse
+type OpenWeatherMapClient struct {
+	client *Client
+}
+
+// WeatherStack returns a weatherstack stand-in backed by the fixtures
+func (c *Client) WeatherStack() *WeatherStackClient {
+	return &WeatherStackClient{client: c}
+}
+
+// OpenWeatherMap returns an openweathermap stand-in backed by the fixtures
+func (c *Client) OpenWeatherMap() *OpenWeatherMapClient {
+	return &OpenWeatherMapClient{client: c}
+}
+
+// GetWeather returns the fixture reading in weatherstack units (celsius, km/h), fixtures aren't localized
+func (c *WeatherStackClient) GetWeather(city string, language string) (*weatherstack.APIResponse, error) {
+	reading, err := c.client.reading(weatherStackProvider, city)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &weatherstack.APIResponse{}
+	out.Location.Name = city
+	out.Current.Temperature = int(math.Round(reading.Temperature))
+	out.Current.WindSpeed = int(math.Round(reading.WindSpeed))
+	out.Current.Humidity = reading.Humidity
+	return out, nil
+}
+
+// GetWeather returns the fixture reading in openweathermap's metric units (celsius, m/s), fixtures aren't localized
+func (c *OpenWeatherMapClient) GetWeather(city string, language string) (*openweathermap.APIResponse, error) {
+	reading, err := c.client.reading(openWeatherMapProvider, city)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &openweathermap.APIResponse{}
+	out.Name = city
+	out.Main.Temp = reading.Temperature
+	out.Wind.Speed = reading.WindSpeed / 3.6
+	out.Main.Humidity = reading.Humidity
+	return out, nil
+}
+
+// forecastStep is the time between forecast readings, as in the real apis
+const forecastStep = 3 * time.Hour
+
+// GetForecast returns days days of readings from the current one on as 3 hourly steps in UTC, the first day starting at
+// today's midnight
+func (c *WeatherStackClient) GetForecast(city string, days int) (*weatherstack.ForecastResponse, error) {
+	steps := int(24 * time.Hour / forecastStep)
+	readings, err := c.client.readings(weatherStackProvider, city, days*steps)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &weatherstack.ForecastResponse{Forecast: map[string]weatherstack.ForecastDay{}}
+	out.Location.Name = city
+	out.Location.UtcOffset = "0.0"
+	today := c.client.now().UTC().Truncate(24 * time.Hour)
+	for d := 0; d < days; d++ {
+		date := today.AddDate(0, 0, d).Format("2006-01-02")
+		day := weatherstack.ForecastDay{Date: date, MinTemp: math.MaxInt32, MaxTemp: math.MinInt32}
+		sum := 0
+		for h := 0; h < steps; h++ {
+			reading := readings[d*steps+h]
+			temperature := int(math.Round(reading.Temperature))
+			day.Hourly = append(day.Hourly, weatherstack.ForecastHour{
+				Time:        strconv.Itoa(h * int(forecastStep/time.Hour) * 100),
+				Temperature: temperature,
+				WindSpeed:   int(math.Round(reading.WindSpeed)),
+			})
+			day.MinTemp = minInt(day.MinTemp, temperature)
+			day.MaxTemp = maxInt(day.MaxTemp, temperature)
+			sum += temperature
+		}
+		day.AvgTemp = int(math.Round(float64(sum) / float64(steps)))
+		out.Forecast[date] = day
+	}
+	return out, nil
+}
+
+// GetForecast returns days days (at most openweathermap.MaxForecastDays) of readings from the current one on as 3 hourly steps from the current step
+func (c *OpenWeatherMapClient) GetForecast(city string, days int) (*openweathermap.ForecastResponse, error) {
+	if days <= 0 || days > openweathermap.MaxForecastDays {
+		days = openweathermap.MaxForecastDays
+	}
+	readings, err := c.client.readings(openWeatherMapProvider, city, days*openweathermap.StepsPerDay)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &openweathermap.ForecastResponse{}
+	out.City.Name = city
+	out.Cnt = len(readings)
+	start := c.client.now().UTC().Truncate(forecastStep)
+	for i, reading := range readings {
+		step := openweathermap.ForecastStep{Dt: int(start.Add(time.Duration(i) * forecastStep).Unix())}
+		step.Main.Temp = reading.Temperature
+		step.Main.TempMin = reading.Temperature
+		step.Main.TempMax = reading.Temperature
+		step.Wind.Speed = reading.WindSpeed / 3.6
+		out.List = append(out.List, step)
+	}
+	return out, nil
+}
+
+// Geocode returns the fixture's coordinates
+func (c *OpenWeatherMapClient) Geocode(city string) (*openweathermap.GeocodeResult, error) {
+	fixture, err := c.client.fixture(city)
+	if err != nil {
+		return nil, err
+	}
+	return &openweathermap.GeocodeResult{Name: fixture.City, Lat: fixture.Lat, Lon: fixture.Lon}, nil
+}
+
+// GetAlerts returns no alerts, fixtures don't have any
+func (c *OpenWeatherMapClient) GetAlerts(lat float64, lon float64) (*openweathermap.AlertsResponse, error) {
+	return &openweathermap.AlertsResponse{Lat: lat, Lon: lon}, nil
+}
+
+// GetAirPollution returns the air quality of the fixture at the point, measured now
+func (c *OpenWeatherMapClient) GetAirPollution(lat float64, lon float64) (*openweathermap.AirPollutionResponse, error) {
+	fixture, err := c.client.fixtureAt(lat, lon)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &openweathermap.AirPollutionResponse{}
+	out.Coord.Lat = lat
+	out.Coord.Lon = lon
+	reading := openweathermap.AirPollutionReading{Dt: int(c.client.now().Unix())}
+	reading.Components.Pm25 = fixture.AirQuality.PM25
+	reading.Components.Pm10 = fixture.AirQuality.PM10
+	reading.Components.O3 = fixture.AirQuality.O3
+	reading.Components.No2 = fixture.AirQuality.NO2
+	reading.Components.So2 = fixture.AirQuality.SO2
+	reading.Components.Co = fixture.AirQuality.CO
+	out.List = append(out.List, reading)
+	return out, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,56 @@
+package nws
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// AlertsResponse is a GeoJSON collection of CAP alerts
+type AlertsResponse struct {
+	Title    string `json:"title"`
+	Updated  string `json:"updated"`
+	Features []struct {
+		ID         string          `json:"id"`
+		Properties AlertProperties `json:"properties"`
+	} `json:"features"`
+}
+
+// AlertProperties are an alert's CAP fields, times are RFC 3339 and may be missing
+type AlertProperties struct {
+	ID          string     `json:"id"`
+	AreaDesc    string     `json:"areaDesc"`
+	Sent        *time.Time `json:"sent"`
+	Effective   *time.Time `json:"effective"`
+	Onset       *time.Time `json:"onset"`
+	Expires     *time.Time `json:"expires"`
+	Ends        *time.Time `json:"ends"`
+	Status      string     `json:"status"`
+	MessageType string     `json:"messageType"`
+	Category    string     `json:"category"`
+	// Severity is Extreme, Severe, Moderate, Minor or Unknown
+	Severity  string `json:"severity"`
+	Certainty string `json:"certainty"`
+	// Urgency is Immediate, Expected, Future, Past or Unknown
+	Urgency     string `json:"urgency"`
+	Event       string `json:"event"`
+	SenderName  string `json:"senderName"`
+	Headline    string `json:"headline"`
+	Description string `json:"description"`
+	Instruction string `json:"instruction"`
+}
+
+// GetActiveAlerts returns the alerts in effect at the point. The NWS only covers the US, other points are an error.
+func (c *Client) GetActiveAlerts(lat float64, lon float64) (*AlertsResponse, error) {
+
+	queryParams := url.Values{}
+	queryParams.Add("point", fmt.Sprintf("%.4f,%.4f", lat, lon))
+
+	out := &AlertsResponse{}
+	err := c.get("/alerts/active", queryParams, out)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,61 @@
+package nws_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/nws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetActiveAlerts(t *testing.T) {
+
+	t.Run("Test Parse Response", func(t *testing.T) {
+		cannedResponse := `{"type":"FeatureCollection","features":[{"id":"https://api.weather.gov/alerts/urn:oid:2.49.0.1.840.0.1","type":"Feature","geometry":null,"properties":{"id":"urn:oid:2.49.0.1.840.0.1","areaDesc":"Miami-Dade","sent":"2021-05-16T10:02:00-04:00","effective":"2021-05-16T10:02:00-04:00","onset":"2021-05-16T12:00:00-04:00","expires":"2021-05-16T20:00:00-04:00","ends":null,"status":"Actual","messageType":"Alert","category":"Met","severity":"Severe","certainty":"Likely","urgency":"Expected","event":"Heat Advisory","senderName":"NWS Miami FL","headline":"Heat Advisory issued May 16","description":"Heat index values up to 110.","instruction":"Drink plenty of fluids."}}],"title":"current watches, warnings, and advisories for 25.7617 N, 80.1918 W","updated":"2021-05-16T14:02:00+00:00"}`
+		testAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// Canned Response
+			w.WriteHeader(200)
+			w.Header().Set("Content-Type", "application/geo+json")
+			w.Write([]byte(cannedResponse))
+		}))
+
+		client := nws.NewClient(testAPI.URL, "")
+
+		resp, err := client.GetActiveAlerts(25.7617, -80.1918)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		if !assert.Len(t, resp.Features, 1) {
+			t.Fatal()
+		}
+		alert := resp.Features[0].Properties
+		assert.Equal(t, "Heat Advisory", alert.Event)
+		assert.Equal(t, "Severe", alert.Severity)
+		assert.Equal(t, "Expected", alert.Urgency)
+		assert.True(t, time.Date(2021, 5, 16, 16, 0, 0, 0, time.UTC).Equal(*alert.Onset))
+		assert.Nil(t, alert.Ends)
+	})
+
+	t.Run("Check Request", func(t *testing.T) {
+
+		var testRequest *http.Request
+		testAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// Canned Response
+			w.WriteHeader(200)
+			testRequest = req
+		}))
+
+		client := nws.NewClient(testAPI.URL, "(example.com, ops@example.com)")
+
+		_, _ = client.GetActiveAlerts(25.76171, -80.19179)
+		if !assert.NotNil(t, testRequest) {
+			t.Fatal()
+		}
+		assert.Equal(t, http.MethodGet, testRequest.Method)
+		assert.Equal(t, "25.7617,-80.1918", testRequest.URL.Query().Get("point"))
+		assert.Equal(t, "(example.com, ops@example.com)", testRequest.Header.Get("User-Agent"))
+		assert.Equal(t, "/alerts/active", testRequest.URL.Path)
+	})
+}
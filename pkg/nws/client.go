@@ -0,0 +1,67 @@
+package nws
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+const (
+	defaultBaseURL = "https://api.weather.gov"
+	// The api rejects requests without a User-Agent identifying the application
+	defaultUserAgent = "weather-api"
+)
+
+// Client is a client for the US National Weather Service api, which is free and needs no key
+type Client struct {
+	baseURL   string
+	userAgent string
+}
+
+// NewClient creates a client. userAgent should identify the application and a contact, e.g. "(myweatherapp.com, contact@myweatherapp.com)"
+func NewClient(baseURL string, userAgent string) *Client {
+
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+
+	return &Client{
+		baseURL:   baseURL,
+		userAgent: userAgent,
+	}
+}
+
+// get calls the api path with the query params, decoding the GeoJSON response into out
+func (c *Client) get(path string, queryParams url.Values, out interface{}) error {
+
+	url := fmt.Sprintf("%v%v?%v", c.baseURL, path, queryParams.Encode())
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", "application/geo+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	byt, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Response status code: %d, body: %s", resp.StatusCode, string(byt))
+	}
+
+	return json.Unmarshal(byt, out)
+}
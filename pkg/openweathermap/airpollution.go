@@ -0,0 +1,56 @@
+package openweathermap
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// AirPollutionResponse is the current air pollution at a point
+type AirPollutionResponse struct {
+	Coord struct {
+		Lon float64 `json:"lon"`
+		Lat float64 `json:"lat"`
+	} `json:"coord"`
+	List []AirPollutionReading `json:"list"`
+}
+
+// AirPollutionReading is a measurement of pollutant concentrations in µg/m³
+type AirPollutionReading struct {
+	// Dt is when the concentrations were measured, a unix time
+	Dt   int `json:"dt"`
+	Main struct {
+		// Aqi is openweathermap's own 1 (good) to 5 (very poor) index
+		Aqi int `json:"aqi"`
+	} `json:"main"`
+	Components struct {
+		Co   float64 `json:"co"`
+		No   float64 `json:"no"`
+		No2  float64 `json:"no2"`
+		O3   float64 `json:"o3"`
+		So2  float64 `json:"so2"`
+		Pm25 float64 `json:"pm2_5"`
+		Pm10 float64 `json:"pm10"`
+		Nh3  float64 `json:"nh3"`
+	} `json:"components"`
+}
+
+// GetAirPollution returns the current air pollution at the point
+func (c *Client) GetAirPollution(lat float64, lon float64) (*AirPollutionResponse, error) {
+
+	queryParams := url.Values{}
+	queryParams.Add("lat", strconv.FormatFloat(lat, 'f', -1, 64))
+	queryParams.Add("lon", strconv.FormatFloat(lon, 'f', -1, 64))
+
+	out := &AirPollutionResponse{}
+	err := c.get("/data/2.5/air_pollution", queryParams, out)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out.List) == 0 {
+		return nil, fmt.Errorf("No air pollution reading for %v,%v", lat, lon)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,70 @@
+package openweathermap_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TomSED/weather-api/pkg/openweathermap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAirPollution(t *testing.T) {
+
+	t.Run("Test Parse Response", func(t *testing.T) {
+		cannedResponse := `{"coord":{"lon":151.2083,"lat":-33.8698},"list":[{"main":{"aqi":1},"components":{"co":230.31,"no":0.11,"no2":12.34,"o3":60.08,"so2":1.5,"pm2_5":8.5,"pm10":17.25,"nh3":0.3},"dt":1621130400}]}`
+		testAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// Canned Response
+			w.WriteHeader(200)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(cannedResponse))
+		}))
+
+		client := openweathermap.NewClient(testAPI.URL, "dummykey")
+
+		resp, err := client.GetAirPollution(-33.8698, 151.2083)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		if !assert.Len(t, resp.List, 1) {
+			t.Fatal()
+		}
+		assert.Equal(t, 1621130400, resp.List[0].Dt)
+		assert.Equal(t, 1, resp.List[0].Main.Aqi)
+		assert.Equal(t, 8.5, resp.List[0].Components.Pm25)
+		assert.Equal(t, 230.31, resp.List[0].Components.Co)
+	})
+
+	t.Run("It should return an error without a reading", func(t *testing.T) {
+		testAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(200)
+			w.Write([]byte(`{"coord":{"lon":151.2083,"lat":-33.8698},"list":[]}`))
+		}))
+
+		client := openweathermap.NewClient(testAPI.URL, "dummykey")
+
+		_, err := client.GetAirPollution(-33.8698, 151.2083)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Check Request", func(t *testing.T) {
+
+		var testRequest *http.Request
+		testAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// Canned Response
+			w.WriteHeader(200)
+			testRequest = req
+		}))
+
+		client := openweathermap.NewClient(testAPI.URL, "dummykey")
+
+		_, _ = client.GetAirPollution(-33.8698, 151.2083)
+		if !assert.NotNil(t, testRequest) {
+			t.Fatal()
+		}
+		assert.Equal(t, "dummykey", testRequest.URL.Query().Get("appid"))
+		assert.Equal(t, "-33.8698", testRequest.URL.Query().Get("lat"))
+		assert.Equal(t, "151.2083", testRequest.URL.Query().Get("lon"))
+		assert.Equal(t, "/data/2.5/air_pollution", testRequest.URL.Path)
+	})
+}
@@ -0,0 +1,41 @@
+package openweathermap
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// AlertsResponse is the One Call response with only its alerts
+type AlertsResponse struct {
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	Timezone string  `json:"timezone"`
+	// TimezoneOffset is the shift from UTC in seconds
+	TimezoneOffset int `json:"timezone_offset"`
+	Alerts         []struct {
+		SenderName string `json:"sender_name"`
+		Event      string `json:"event"`
+		// Start and End are unix times
+		Start       int      `json:"start"`
+		End         int      `json:"end"`
+		Description string   `json:"description"`
+		Tags        []string `json:"tags"`
+	} `json:"alerts"`
+}
+
+// GetAlerts returns the national weather alerts in effect at the point
+func (c *Client) GetAlerts(lat float64, lon float64) (*AlertsResponse, error) {
+
+	queryParams := url.Values{}
+	queryParams.Add("lat", strconv.FormatFloat(lat, 'f', -1, 64))
+	queryParams.Add("lon", strconv.FormatFloat(lon, 'f', -1, 64))
+	queryParams.Add("exclude", "current,minutely,hourly,daily")
+
+	out := &AlertsResponse{}
+	err := c.get("/data/2.5/onecall", queryParams, out)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
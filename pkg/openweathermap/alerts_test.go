@@ -0,0 +1,87 @@
+package openweathermap_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TomSED/weather-api/pkg/openweathermap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAlerts(t *testing.T) {
+
+	t.Run("Test Parse Response", func(t *testing.T) {
+		cannedResponse := `{"lat":25.7617,"lon":-80.1918,"timezone":"America/New_York","timezone_offset":-14400,"alerts":[{"sender_name":"NWS Miami (Southern Florida)","event":"Heat Advisory","start":1621180800,"end":1621209600,"description":"Heat index values up to 110.","tags":["Extreme temperature value"]}]}`
+		testAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// Canned Response
+			w.WriteHeader(200)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(cannedResponse))
+		}))
+
+		client := openweathermap.NewClient(testAPI.URL, "dummykey")
+
+		resp, err := client.GetAlerts(25.7617, -80.1918)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		if !assert.Len(t, resp.Alerts, 1) {
+			t.Fatal()
+		}
+		assert.Equal(t, "Heat Advisory", resp.Alerts[0].Event)
+		assert.Equal(t, 1621180800, resp.Alerts[0].Start)
+		assert.Equal(t, []string{"Extreme temperature value"}, resp.Alerts[0].Tags)
+	})
+
+	t.Run("Check Request", func(t *testing.T) {
+
+		var testRequest *http.Request
+		testAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// Canned Response
+			w.WriteHeader(200)
+			testRequest = req
+		}))
+
+		client := openweathermap.NewClient(testAPI.URL, "dummykey")
+
+		_, _ = client.GetAlerts(25.7617, -80.1918)
+		if !assert.NotNil(t, testRequest) {
+			t.Fatal()
+		}
+		assert.Equal(t, "dummykey", testRequest.URL.Query().Get("appid"))
+		assert.Equal(t, "25.7617", testRequest.URL.Query().Get("lat"))
+		assert.Equal(t, "-80.1918", testRequest.URL.Query().Get("lon"))
+		assert.Equal(t, "/data/2.5/onecall", testRequest.URL.Path)
+	})
+}
+
+func TestGeocode(t *testing.T) {
+
+	t.Run("It should return the best match", func(t *testing.T) {
+		var testRequest *http.Request
+		testAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			testRequest = req
+			w.WriteHeader(200)
+			if req.URL.Query().Get("q") == "Atlantis" {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[{"name":"Sydney","lat":-33.8698,"lon":151.2083,"country":"AU","state":"New South Wales"}]`))
+		}))
+
+		client := openweathermap.NewClient(testAPI.URL, "dummykey")
+
+		place, err := client.Geocode("Sydney")
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, -33.8698, place.Lat)
+		assert.Equal(t, 151.2083, place.Lon)
+		assert.Equal(t, "/geo/1.0/direct", testRequest.URL.Path)
+		assert.Equal(t, "1", testRequest.URL.Query().Get("limit"))
+
+		_, err = client.Geocode("Atlantis")
+		assert.NotNil(t, err)
+	})
+}
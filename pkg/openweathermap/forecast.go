@@ -0,0 +1,84 @@
+package openweathermap
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// StepsPerDay is the number of 3 hour forecast steps in a day
+	StepsPerDay = 8
+	// MaxForecastDays is how far ahead the forecast goes
+	MaxForecastDays = 5
+)
+
+// ForecastResponse is the 5 day / 3 hour forecast
+type ForecastResponse struct {
+	Cod     string         `json:"cod"`
+	Message int            `json:"message"`
+	Cnt     int            `json:"cnt"`
+	List    []ForecastStep `json:"list"`
+	City    struct {
+		ID      int    `json:"id"`
+		Name    string `json:"name"`
+		Country string `json:"country"`
+		// Timezone is the shift from UTC in seconds
+		Timezone int `json:"timezone"`
+		Sunrise  int `json:"sunrise"`
+		Sunset   int `json:"sunset"`
+	} `json:"city"`
+}
+
+// ForecastStep is the forecast weather for the 3 hours from Dt
+type ForecastStep struct {
+	Dt   int `json:"dt"`
+	Main struct {
+		Temp      float64 `json:"temp"`
+		FeelsLike float64 `json:"feels_like"`
+		TempMin   float64 `json:"temp_min"`
+		TempMax   float64 `json:"temp_max"`
+		Pressure  int     `json:"pressure"`
+		Humidity  int     `json:"humidity"`
+	} `json:"main"`
+	Weather []struct {
+		ID          int    `json:"id"`
+		Main        string `json:"main"`
+		Description string `json:"description"`
+		Icon        string `json:"icon"`
+	} `json:"weather"`
+	Clouds struct {
+		All int `json:"all"`
+	} `json:"clouds"`
+	Wind struct {
+		Speed float64 `json:"speed"`
+		Deg   int     `json:"deg"`
+	} `json:"wind"`
+	Visibility int `json:"visibility"`
+	// Pop is the probability of precipitation, 0 to 1
+	Pop   float64 `json:"pop"`
+	DtTxt string  `json:"dt_txt"`
+}
+
+// Location returns the city's fixed UTC offset as a time.Location
+func (r *ForecastResponse) Location() *time.Location {
+	return time.FixedZone(r.City.Name, r.City.Timezone)
+}
+
+// GetForecast returns the city's forecast in 3 hour steps for days days, all MaxForecastDays when 0 or more
+func (c *Client) GetForecast(city string, days int) (*ForecastResponse, error) {
+
+	queryParams := url.Values{}
+	queryParams.Add("q", city)
+	if days > 0 && days < MaxForecastDays {
+		queryParams.Add("cnt", strconv.Itoa(days*StepsPerDay))
+	}
+
+	out := &ForecastResponse{}
+	err := c.get("/data/2.5/forecast", queryParams, out)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,62 @@
+package openweathermap_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/openweathermap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetForecast(t *testing.T) {
+
+	t.Run("Test Parse Response", func(t *testing.T) {
+		cannedResponse := `{"cod":"200","message":0,"cnt":2,"list":[{"dt":1621134000,"main":{"temp":17.5,"feels_like":16.9,"temp_min":16.8,"temp_max":17.5,"pressure":1020,"humidity":52},"weather":[{"id":800,"main":"Clear","description":"clear sky","icon":"01d"}],"clouds":{"all":0},"wind":{"speed":5.14,"deg":260},"visibility":10000,"pop":0,"dt_txt":"2021-05-16 03:00:00"},{"dt":1621144800,"main":{"temp":15.2,"feels_like":14.6,"temp_min":15.2,"temp_max":15.2,"pressure":1020,"humidity":60},"weather":[{"id":500,"main":"Rain","description":"light rain","icon":"10n"}],"clouds":{"all":40},"wind":{"speed":3.1,"deg":240},"visibility":10000,"pop":0.35,"dt_txt":"2021-05-16 06:00:00"}],"city":{"id":2147714,"name":"Sydney","country":"AU","timezone":36000,"sunrise":1621111254,"sunset":1621148542}}`
+		testAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// Canned Response
+			w.WriteHeader(200)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(cannedResponse))
+		}))
+
+		client := openweathermap.NewClient(testAPI.URL, "dummykey")
+
+		resp, err := client.GetForecast("Sydney", 1)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		if !assert.Len(t, resp.List, 2) {
+			t.Fatal()
+		}
+		assert.Equal(t, 1621134000, resp.List[0].Dt)
+		assert.Equal(t, 17.5, resp.List[0].Main.Temp)
+		assert.Equal(t, 0.35, resp.List[1].Pop)
+		assert.Equal(t, "light rain", resp.List[1].Weather[0].Description)
+		_, offset := time.Unix(1621134000, 0).In(resp.Location()).Zone()
+		assert.Equal(t, 36000, offset)
+	})
+
+	t.Run("Check Request", func(t *testing.T) {
+
+		var testRequest *http.Request
+		testAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// Canned Response
+			w.WriteHeader(200)
+			testRequest = req
+		}))
+
+		client := openweathermap.NewClient(testAPI.URL, "dummykey")
+
+		_, _ = client.GetForecast("Sydney", 3)
+		if !assert.NotNil(t, testRequest) {
+			t.Fatal()
+		}
+		assert.Equal(t, "dummykey", testRequest.URL.Query().Get("appid"))
+		assert.Equal(t, "Sydney", testRequest.URL.Query().Get("q"))
+		assert.Equal(t, "metric", testRequest.URL.Query().Get("units"))
+		assert.Equal(t, "24", testRequest.URL.Query().Get("cnt"))
+		assert.Equal(t, "/data/2.5/forecast", testRequest.URL.Path)
+	})
+}
@@ -0,0 +1,34 @@
+package openweathermap
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// GeocodeResult is a place matching a geocoding query
+type GeocodeResult struct {
+	Name    string  `json:"name"`
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+	Country string  `json:"country"`
+	State   string  `json:"state"`
+}
+
+// Geocode returns the best match for the city name, e.g. "Sydney" or "Sydney,AU"
+func (c *Client) Geocode(city string) (*GeocodeResult, error) {
+
+	queryParams := url.Values{}
+	queryParams.Add("q", city)
+	queryParams.Add("limit", "1")
+
+	out := []GeocodeResult{}
+	err := c.get("/geo/1.0/direct", queryParams, &out)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("city %q not found", city)
+	}
+
+	return &out[0], nil
+}
@@ -1,11 +1,9 @@
 package openweathermap
 
 import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"net/http"
 	"net/url"
+	"strings"
+	"time"
 )
 
 type APIResponse struct {
@@ -50,34 +48,65 @@ type APIResponse struct {
 	Cod      int    `json:"cod"`
 }
 
-func (c *Client) GetWeather(city string) (*APIResponse, error) {
-
-	queryParams := url.Values{}
-	queryParams.Add("appid", c.apiKey)
-	queryParams.Add("q", city)
-	url := fmt.Sprintf("%v/data/2.5/weather?%v", c.baseURL, queryParams.Encode())
+// ObservedAt returns when the weather was observed (dt), zero if the response doesn't say
+func (r *APIResponse) ObservedAt() time.Time {
+	if r.Dt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(r.Dt), 0).UTC()
+}
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
+// Night reports whether the weather was observed outside sunrise to sunset, falling back to the icon's day or night
+// suffix (e.g. "01n") when the response doesn't have both
+func (r *APIResponse) Night() bool {
+	if r.Dt != 0 && r.Sys.Sunrise != 0 && r.Sys.Sunset != 0 {
+		return r.Dt < r.Sys.Sunrise || r.Dt >= r.Sys.Sunset
+	}
+	if len(r.Weather) > 0 {
+		return strings.HasSuffix(r.Weather[0].Icon, "n")
 	}
+	return false
+}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
+// UTCOffset returns the city's shift from UTC (timezone), ok is false if the response isn't an observation (has no dt)
+func (r *APIResponse) UTCOffset() (offset time.Duration, ok bool) {
+	if r.Dt == 0 {
+		return 0, false
 	}
+	return time.Duration(r.Timezone) * time.Second, true
+}
 
-	byt, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+// ConditionID returns the id of the main weather condition, 0 if there is none
+func (r *APIResponse) ConditionID() int {
+	if len(r.Weather) == 0 {
+		return 0
 	}
+	return r.Weather[0].ID
+}
+
+// languageCodes are the ISO 639-1 codes openweathermap names differently
+var languageCodes = map[string]string{
+	"cs": "cz",
+	"ko": "kr",
+	"sq": "al",
+	"zh": "zh_cn",
+}
+
+// GetWeather returns the city's current weather, with weather descriptions in the language (an ISO 639-1 code) unless
+// it's empty
+func (c *Client) GetWeather(city string, language string) (*APIResponse, error) {
 
-	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return nil, fmt.Errorf("Response status code: %d, body: %s", resp.StatusCode, string(byt))
+	queryParams := url.Values{}
+	queryParams.Add("q", city)
+	if code, exist := languageCodes[language]; exist {
+		language = code
+	}
+	if language != "" {
+		queryParams.Add("lang", language)
 	}
 
 	out := &APIResponse{}
-	err = json.Unmarshal(byt, out)
+	err := c.get("/data/2.5/weather", queryParams, out)
 	if err != nil {
 		return nil, err
 	}
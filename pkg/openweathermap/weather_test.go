@@ -1,9 +1,11 @@
 package openweathermap_test
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/TomSED/weather-api/pkg/openweathermap"
 	"github.com/stretchr/testify/assert"
@@ -22,12 +24,35 @@ func TestGetWeather(t *testing.T) {
 
 		client := openweathermap.NewClient(testAPI.URL, "dummykey")
 
-		resp, err := client.GetWeather("Sydney")
+		resp, err := client.GetWeather("Sydney", "")
 		if !assert.Nil(t, err) {
 			t.Fatal(err)
 		}
 		assert.Equal(t, 5.14, resp.Wind.Speed)
 		assert.Equal(t, 289.02, resp.Main.Temp)
+		assert.Equal(t, time.Date(2021, 5, 16, 1, 56, 13, 0, time.UTC), resp.ObservedAt())
+		assert.Equal(t, 800, resp.ConditionID())
+		assert.False(t, resp.Night())
+		offset, ok := resp.UTCOffset()
+		assert.True(t, ok)
+		assert.Equal(t, 10*time.Hour, offset)
+	})
+
+	t.Run("It should be night outside sunrise to sunset, or by the icon without them", func(t *testing.T) {
+		resp := &openweathermap.APIResponse{Dt: 1621150000}
+		resp.Sys.Sunrise = 1621111254
+		resp.Sys.Sunset = 1621148542
+		assert.True(t, resp.Night())
+
+		resp = &openweathermap.APIResponse{}
+		err := json.Unmarshal([]byte(`{"weather":[{"id":500,"main":"Rain","description":"light rain","icon":"10n"}]}`), resp)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.True(t, resp.Night())
+		assert.Equal(t, 500, resp.ConditionID())
+		_, ok := resp.UTCOffset()
+		assert.False(t, ok)
 	})
 
 	t.Run("Check Request", func(t *testing.T) {
@@ -41,13 +66,21 @@ func TestGetWeather(t *testing.T) {
 
 		client := openweathermap.NewClient(testAPI.URL, "dummykey")
 
-		_, _ = client.GetWeather("Sydney")
+		_, _ = client.GetWeather("Sydney", "")
 		if !assert.NotNil(t, testRequest) {
 			t.Fatal()
 		}
 		assert.Equal(t, http.MethodGet, testRequest.Method)
 		assert.Equal(t, "dummykey", testRequest.URL.Query().Get("appid"))
 		assert.Equal(t, "Sydney", testRequest.URL.Query().Get("q"))
+		assert.Equal(t, "metric", testRequest.URL.Query().Get("units"))
 		assert.Equal(t, "/data/2.5/weather", testRequest.URL.Path)
+		_, exist := testRequest.URL.Query()["lang"]
+		assert.False(t, exist)
+
+		_, _ = client.GetWeather("Sydney", "fr")
+		assert.Equal(t, "fr", testRequest.URL.Query().Get("lang"))
+		_, _ = client.GetWeather("Sydney", "ko")
+		assert.Equal(t, "kr", testRequest.URL.Query().Get("lang"))
 	})
 }
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/jackc/pgx/v4"
+)
+
+// InsertAirQuality replaces the city's air quality unless a later reading is stored
+func (c *Client) InsertAirQuality(ctx context.Context, airQuality *storage.AirQuality) (err error) {
+	if err := c.available(); err != nil {
+		return err
+	}
+	defer c.observe(ctx, &err)
+
+	query := `INSERT INTO public.air_quality AS a (city, datasource, lat, lon, measured_at, fetched_at, pm2_5, pm10, o3, no2, so2, co)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			ON CONFLICT (city) DO UPDATE SET
+				datasource = EXCLUDED.datasource,
+				lat = EXCLUDED.lat,
+				lon = EXCLUDED.lon,
+				measured_at = EXCLUDED.measured_at,
+				fetched_at = EXCLUDED.fetched_at,
+				pm2_5 = EXCLUDED.pm2_5,
+				pm10 = EXCLUDED.pm10,
+				o3 = EXCLUDED.o3,
+				no2 = EXCLUDED.no2,
+				so2 = EXCLUDED.so2,
+				co = EXCLUDED.co
+			WHERE a.fetched_at <= EXCLUDED.fetched_at;`
+
+	_, err = c.pool.Exec(ctx, query, airQuality.City, airQuality.DataSource, airQuality.Lat, airQuality.Lon,
+		airQuality.MeasuredAt.UTC(), airQuality.FetchedAt.UTC(),
+		airQuality.PM25, airQuality.PM10, airQuality.O3, airQuality.NO2, airQuality.SO2, airQuality.CO)
+	return err
+}
+
+// GetAirQuality returns the city's stored air quality, nil if there is none
+func (c *Client) GetAirQuality(ctx context.Context, city string) (_ *storage.AirQuality, err error) {
+	if err := c.available(); err != nil {
+		return nil, err
+	}
+	defer c.observe(ctx, &err)
+
+	query := `SELECT datasource, city, lat, lon, measured_at, fetched_at, pm2_5, pm10, o3, no2, so2, co FROM public.air_quality WHERE city = $1;`
+
+	out := &storage.AirQuality{}
+	err = c.pool.QueryRow(ctx, query, city).Scan(&out.DataSource, &out.City, &out.Lat, &out.Lon, &out.MeasuredAt, &out.FetchedAt,
+		&out.PM25, &out.PM10, &out.O3, &out.NO2, &out.SO2, &out.CO)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return out, nil
+}
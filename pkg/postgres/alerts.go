@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/jackc/pgx/v4"
+)
+
+// InsertAlerts replaces the location's alerts unless later ones are stored
+func (c *Client) InsertAlerts(ctx context.Context, alerts *storage.Alerts) (err error) {
+	if err := c.available(); err != nil {
+		return err
+	}
+	defer c.observe(ctx, &err)
+
+	query := `INSERT INTO public.alerts AS a (location_key, lat, lon, fetched_at, alerts)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (location_key) DO UPDATE SET
+				lat = EXCLUDED.lat,
+				lon = EXCLUDED.lon,
+				fetched_at = EXCLUDED.fetched_at,
+				alerts = EXCLUDED.alerts
+			WHERE a.fetched_at <= EXCLUDED.fetched_at;`
+
+	byt, err := json.Marshal(alerts.Alerts)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.pool.Exec(ctx, query, alerts.Key, alerts.Lat, alerts.Lon, alerts.FetchedAt.UTC(), string(byt))
+	return err
+}
+
+// GetAlerts returns the location's stored alerts, nil if there are none
+func (c *Client) GetAlerts(ctx context.Context, key string) (_ *storage.Alerts, err error) {
+	if err := c.available(); err != nil {
+		return nil, err
+	}
+	defer c.observe(ctx, &err)
+
+	query := `SELECT location_key, lat, lon, fetched_at, alerts FROM public.alerts WHERE location_key = $1;`
+
+	out := &storage.Alerts{}
+	alerts := []byte{}
+	err = c.pool.QueryRow(ctx, query, key).Scan(&out.Key, &out.Lat, &out.Lon, &out.FetchedAt, &alerts)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	err = json.Unmarshal(alerts, &out.Alerts)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -1,42 +1,129 @@
 package postgres
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"sync"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4/pgxpool"
 )
 
-// newDB initialises a new db connection
-func newDB(host, port, username, password, dbname string) (*sql.DB, error) {
+const defaultUnavailableBackoff = 30 * time.Second
 
-	dnsStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s connect_timeout=3", host, port, username, password, dbname)
+// Client is the client for the weatherapi database
+type Client struct {
+	pool    *pgxpool.Pool
+	backoff time.Duration
+	now     func() time.Time
+
+	mu        sync.Mutex
+	downUntil time.Time
+	lastErr   error
+}
+
+var _ storage.Store = &Client{}
+
+// NewClient creates a new database client. Connections are made lazily by the pool, so an unreachable database doesn't
+// fail here. Once a query fails to connect, the store methods return storage.ErrUnavailable without trying the database
+// until the backoff has passed.
+func NewClient(config Config) (*Client, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
 
-	db, err := sql.Open("postgres", dnsStr)
+	poolConfig, err := pgxpool.ParseConfig(config.DSN())
 	if err != nil {
-		fmt.Printf("sql.Open Err: %v\n", err)
 		return nil, err
 	}
+	poolConfig.LazyConnect = true
+	// Unset fields keep pgx's defaults
+	if config.MaxConns > 0 {
+		poolConfig.MaxConns = int32(config.MaxConns)
+	}
+	poolConfig.MinConns = int32(config.MinConns)
+	if config.ConnMaxLifetime > 0 {
+		poolConfig.MaxConnLifetime = config.ConnMaxLifetime
+	}
+	if config.ConnMaxIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = config.ConnMaxIdleTime
+	}
 
-	err = db.Ping()
+	pool, err := pgxpool.ConnectConfig(context.Background(), poolConfig)
 	if err != nil {
-		fmt.Println("db.Ping Err:", err)
 		return nil, err
 	}
-	return db, nil
+
+	return &Client{
+		pool:    pool,
+		backoff: defaultUnavailableBackoff,
+		now:     time.Now,
+	}, nil
 }
 
-// Client is the client for the weatherapi database
-type Client struct {
-	database *sql.DB
+// SetBackoff sets how long the database is skipped after failing to connect
+func (c *Client) SetBackoff(backoff time.Duration) {
+	c.backoff = backoff
 }
 
-// NewClient creates a new database client
-func NewClient(host, port, username, password, dbName string) (*Client, error) {
-	db, err := newDB(host, port, username, password, dbName)
-	if err != nil {
-		return nil, err
+// SetClock sets the time source used for the backoff, for tests
+func (c *Client) SetClock(now func() time.Time) {
+	c.now = now
+}
+
+// Close closes the connection pool
+func (c *Client) Close() {
+	c.pool.Close()
+}
+
+// Ping checks a connection to the database, connecting if there's none idle
+func (c *Client) Ping(ctx context.Context) error {
+	err := c.pool.Ping(ctx)
+	c.observe(ctx, &err)
+	return err
+}
+
+// available returns storage.ErrUnavailable, with the error that started it, while the database is being skipped
+func (c *Client) available() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.now().Before(c.downUntil) {
+		return fmt.Errorf("postgres: %w after %v", storage.ErrUnavailable, c.lastErr)
+	}
+	return nil
+}
+
+// observe starts the backoff when *err is a connection failure, unless it's from the caller giving up on ctx.
+// It takes a pointer to be deferred with a named result.
+func (c *Client) observe(ctx context.Context, err *error) {
+	if *err == nil || ctx.Err() != nil || !isConnectionError(*err) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.downUntil = c.now().Add(c.backoff)
+	c.lastErr = *err
+}
+
+// isConnectionError reports whether err means the database couldn't be reached, rather than a query failing
+func isConnectionError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// connection_exception, too_many_connections, admin_shutdown, crash_shutdown and cannot_connect_now
+		switch {
+		case pgErr.Code[:2] == "08", pgErr.Code == "53300", pgErr.Code == "57P01", pgErr.Code == "57P02", pgErr.Code == "57P03":
+			return true
+		}
+		return false
 	}
 
-	return &Client{db}, nil
+	var netErr net.Error
+	return errors.As(err, &netErr)
 }
@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultSSLMode matches lib/pq's default, pgx's own is prefer which falls back to plain text
+	DefaultSSLMode         = "require"
+	DefaultConnectTimeout  = 3 * time.Second
+	DefaultMaxConns        = 5
+	DefaultMinConns        = 0
+	DefaultConnMaxLifetime = 30 * time.Minute
+	DefaultConnMaxIdleTime = 5 * time.Minute
+)
+
+// sslModes are the sslmode values pgx supports
+var sslModes = map[string]bool{
+	"disable":     true,
+	"allow":       true,
+	"prefer":      true,
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+// deprecatedInts are the database/sql pool variables replaced by pgx's, still read when their replacement is unset
+var deprecatedInts = map[string]string{
+	"PG_MAX_CONNS": "PG_MAX_OPEN_CONNS",
+}
+
+// ignoredVariables are the database/sql pool variables pgx has no equivalent of, with why they're ignored
+var ignoredVariables = map[string]string{
+	// PG_MIN_CONNS is a floor of connections kept open, the opposite of an idle cap
+	"PG_MAX_IDLE_CONNS": "pgx doesn't cap idle connections, PG_CONN_MAX_IDLE_TIME closes them",
+}
+
+// Config is how to connect to the database and size the connection pool
+type Config struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	DBName   string
+	// SSLMode is disable, allow, prefer, require, verify-ca or verify-full, LoadConfig defaults to require
+	SSLMode string
+	// SSLRootCert is the path of the CA certificate used to verify the server
+	SSLRootCert    string
+	ConnectTimeout time.Duration
+
+	// Keep MaxConns low for lambda, every concurrent instance has its own pool
+	MaxConns        int
+	MinConns        int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// Warnings are the deprecated variables LoadConfig read, for the caller to log
+	Warnings []string
+}
+
+// LoadConfig reads the PG_* variables through getenv (e.g. os.Getenv), using the defaults for those unset
+func LoadConfig(getenv func(string) string) (Config, error) {
+	config := Config{
+		Host:            getenv("PG_HOST"),
+		Port:            getenv("PG_PORT"),
+		Username:        getenv("PG_USERNAME"),
+		Password:        getenv("PG_PASSWORD"),
+		DBName:          getenv("PG_DB_NAME"),
+		SSLMode:         DefaultSSLMode,
+		SSLRootCert:     getenv("PG_SSLROOTCERT"),
+		ConnectTimeout:  DefaultConnectTimeout,
+		MaxConns:        DefaultMaxConns,
+		MinConns:        DefaultMinConns,
+		ConnMaxLifetime: DefaultConnMaxLifetime,
+		ConnMaxIdleTime: DefaultConnMaxIdleTime,
+	}
+	if sslMode := getenv("PG_SSLMODE"); sslMode != "" {
+		config.SSLMode = sslMode
+	}
+
+	durations := map[string]*time.Duration{
+		"PG_CONNECT_TIMEOUT":    &config.ConnectTimeout,
+		"PG_CONN_MAX_LIFETIME":  &config.ConnMaxLifetime,
+		"PG_CONN_MAX_IDLE_TIME": &config.ConnMaxIdleTime,
+	}
+	for name, d := range durations {
+		if value := getenv(name); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				return config, fmt.Errorf("%s: %v", name, err)
+			}
+			*d = parsed
+		}
+	}
+
+	ints := map[string]*int{
+		"PG_MAX_CONNS": &config.MaxConns,
+		"PG_MIN_CONNS": &config.MinConns,
+	}
+	for name, n := range ints {
+		value := getenv(name)
+		if deprecated := deprecatedInts[name]; value == "" && getenv(deprecated) != "" {
+			config.Warnings = append(config.Warnings, fmt.Sprintf("%s is deprecated, use %s", deprecated, name))
+			name, value = deprecated, getenv(deprecated)
+		}
+		if value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return config, fmt.Errorf("%s: %v", name, err)
+			}
+			*n = parsed
+		}
+	}
+	for name, reason := range ignoredVariables {
+		if getenv(name) != "" {
+			config.Warnings = append(config.Warnings, fmt.Sprintf("%s is ignored, %s", name, reason))
+		}
+	}
+	sort.Strings(config.Warnings)
+
+	return config, config.Validate()
+}
+
+// Validate checks the ssl settings, connection details are only checked on connecting
+func (c Config) Validate() error {
+	if c.SSLMode != "" && !sslModes[c.SSLMode] {
+		return fmt.Errorf("unsupported sslmode %q", c.SSLMode)
+	}
+	if c.SSLRootCert != "" {
+		_, err := os.Stat(c.SSLRootCert)
+		if err != nil {
+			return fmt.Errorf("sslrootcert: %v", err)
+		}
+	}
+	return nil
+}
+
+// DSN returns the libpq style connection string, quoting values as needed. Empty settings are left to the driver's defaults.
+func (c Config) DSN() string {
+	settings := map[string]string{
+		"host":        c.Host,
+		"port":        c.Port,
+		"user":        c.Username,
+		"password":    c.Password,
+		"dbname":      c.DBName,
+		"sslmode":     c.SSLMode,
+		"sslrootcert": c.SSLRootCert,
+	}
+	if c.ConnectTimeout > 0 {
+		// Whole seconds, rounded up so a sub-second timeout doesn't become none
+		settings["connect_timeout"] = strconv.Itoa(int((c.ConnectTimeout + time.Second - 1) / time.Second))
+	}
+
+	keys := []string{}
+	for key, value := range settings {
+		if value != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	parts := []string{}
+	for _, key := range keys {
+		parts = append(parts, key+"="+quoteDSNValue(settings[key]))
+	}
+	return strings.Join(parts, " ")
+}
+
+var dsnEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+func quoteDSNValue(value string) string {
+	if !strings.ContainsAny(value, " '\\\t\n\r") {
+		return value
+	}
+	return "'" + dsnEscaper.Replace(value) + "'"
+}
@@ -0,0 +1,158 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/postgres"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig(t *testing.T) {
+
+	t.Run("It should build a DSN, quoting values that need it", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			config   postgres.Config
+			expected string
+		}{
+			{
+				name:     "plain values",
+				config:   postgres.Config{Host: "localhost", Port: "5432", Username: "weather", Password: "secret", DBName: "weather", ConnectTimeout: 3 * time.Second},
+				expected: "connect_timeout=3 dbname=weather host=localhost password=secret port=5432 user=weather",
+			},
+			{
+				name:     "quotes, backslashes and spaces",
+				config:   postgres.Config{Host: "localhost", Password: `it's a \secret`},
+				expected: `host=localhost password='it\'s a \\secret'`,
+			},
+			{
+				name:     "ssl settings and a sub-second timeout",
+				config:   postgres.Config{Host: "db.example.com", SSLMode: "verify-full", SSLRootCert: "/etc/ssl/rds ca.pem", ConnectTimeout: 500 * time.Millisecond},
+				expected: `connect_timeout=1 host=db.example.com sslmode=verify-full sslrootcert='/etc/ssl/rds ca.pem'`,
+			},
+		}
+
+		for _, test := range tests {
+			assert.Equal(t, test.expected, test.config.DSN(), test.name)
+		}
+	})
+
+	t.Run("It should load the env with defaults", func(t *testing.T) {
+		env := map[string]string{
+			"PG_HOST":              "localhost",
+			"PG_MAX_CONNS":         "2",
+			"PG_CONN_MAX_LIFETIME": "1m",
+			"PG_SSLMODE":           "require",
+		}
+		config, err := postgres.LoadConfig(func(name string) string { return env[name] })
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "localhost", config.Host)
+		assert.Equal(t, "require", config.SSLMode)
+		assert.Equal(t, 2, config.MaxConns)
+		assert.Equal(t, postgres.DefaultMinConns, config.MinConns)
+		assert.Equal(t, time.Minute, config.ConnMaxLifetime)
+		assert.Equal(t, postgres.DefaultConnectTimeout, config.ConnectTimeout)
+	})
+
+	t.Run("It should require ssl when PG_SSLMODE is unset", func(t *testing.T) {
+		config, err := postgres.LoadConfig(func(name string) string { return "" })
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "require", config.SSLMode)
+		assert.Contains(t, config.DSN(), "sslmode=require")
+
+		config, err = postgres.LoadConfig(func(name string) string {
+			return map[string]string{"PG_SSLMODE": "prefer"}[name]
+		})
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "prefer", config.SSLMode)
+	})
+
+	t.Run("It should fall back to the deprecated pool variables with a warning, ignoring PG_MAX_IDLE_CONNS", func(t *testing.T) {
+		env := map[string]string{
+			"PG_MAX_OPEN_CONNS": "3",
+			"PG_MAX_IDLE_CONNS": "10",
+		}
+		config, err := postgres.LoadConfig(func(name string) string { return env[name] })
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, 3, config.MaxConns)
+		assert.Equal(t, postgres.DefaultMinConns, config.MinConns)
+		assert.Equal(t, []string{
+			"PG_MAX_IDLE_CONNS is ignored, pgx doesn't cap idle connections, PG_CONN_MAX_IDLE_TIME closes them",
+			"PG_MAX_OPEN_CONNS is deprecated, use PG_MAX_CONNS",
+		}, config.Warnings)
+
+		env["PG_MAX_CONNS"] = "4"
+		config, err = postgres.LoadConfig(func(name string) string { return env[name] })
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, 4, config.MaxConns)
+		assert.Len(t, config.Warnings, 1)
+
+		_, err = postgres.LoadConfig(func(name string) string {
+			return map[string]string{"PG_MAX_OPEN_CONNS": "many"}[name]
+		})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("It should reject invalid settings", func(t *testing.T) {
+		envs := []map[string]string{
+			{"PG_SSLMODE": "sometimes"},
+			{"PG_SSLROOTCERT": "/does/not/exist.pem"},
+			{"PG_MAX_CONNS": "many"},
+			{"PG_CONNECT_TIMEOUT": "3"},
+		}
+		for _, env := range envs {
+			_, err := postgres.LoadConfig(func(name string) string { return env[name] })
+			assert.NotNil(t, err, env)
+		}
+	})
+}
+
+func TestClientUnavailable(t *testing.T) {
+
+	t.Run("It should start without the database and skip it after failing to connect until the backoff passes", func(t *testing.T) {
+		// Nothing listens on port 1
+		client, err := postgres.NewClient(postgres.Config{Host: "127.0.0.1", Port: "1", SSLMode: "disable", ConnectTimeout: time.Second})
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		defer client.Close()
+
+		now := time.Now()
+		client.SetClock(func() time.Time { return now })
+		client.SetBackoff(30 * time.Second)
+
+		_, err = client.GetLatestWeatherData(context.Background(), "sydney")
+		if !assert.NotNil(t, err) {
+			t.FailNow()
+		}
+		assert.False(t, errors.Is(err, storage.ErrUnavailable))
+
+		_, err = client.GetLatestWeatherData(context.Background(), "sydney")
+		assert.True(t, errors.Is(err, storage.ErrUnavailable))
+		_, err = client.InsertWeatherData(context.Background(), &storage.WeatherData{City: "sydney"})
+		assert.True(t, errors.Is(err, storage.ErrUnavailable))
+		_, err = client.ReserveProviderCall(context.Background(), "weatherstack", "2021-05", 0)
+		assert.True(t, errors.Is(err, storage.ErrUnavailable))
+
+		now = now.Add(31 * time.Second)
+		_, err = client.GetProviderUsage(context.Background(), "weatherstack", "2021-05")
+		if !assert.NotNil(t, err) {
+			t.FailNow()
+		}
+		assert.False(t, errors.Is(err, storage.ErrUnavailable))
+	})
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/jackc/pgx/v4"
+)
+
+// InsertForecast replaces the city's forecast unless a later one is stored
+func (c *Client) InsertForecast(ctx context.Context, forecast *storage.Forecast) (err error) {
+	if err := c.available(); err != nil {
+		return err
+	}
+	defer c.observe(ctx, &err)
+
+	query := `INSERT INTO public.forecast AS f (city, datasource, fetched_at, hourly, daily)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (city) DO UPDATE SET
+				datasource = EXCLUDED.datasource,
+				fetched_at = EXCLUDED.fetched_at,
+				hourly = EXCLUDED.hourly,
+				daily = EXCLUDED.daily
+			WHERE f.fetched_at <= EXCLUDED.fetched_at;`
+
+	hourly, err := json.Marshal(forecast.Hourly)
+	if err != nil {
+		return err
+	}
+	daily, err := json.Marshal(forecast.Daily)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.pool.Exec(ctx, query, forecast.City, forecast.DataSource, forecast.FetchedAt.UTC(), string(hourly), string(daily))
+	return err
+}
+
+// GetForecast returns the city's stored forecast, nil if there is none
+func (c *Client) GetForecast(ctx context.Context, city string) (_ *storage.Forecast, err error) {
+	if err := c.available(); err != nil {
+		return nil, err
+	}
+	defer c.observe(ctx, &err)
+
+	query := `SELECT datasource, city, fetched_at, hourly, daily FROM public.forecast WHERE city = $1;`
+
+	out := &storage.Forecast{}
+	hourly := []byte{}
+	daily := []byte{}
+	err = c.pool.QueryRow(ctx, query, city).Scan(&out.DataSource, &out.City, &out.FetchedAt, &hourly, &daily)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	err = json.Unmarshal(hourly, &out.Hourly)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(daily, &out.Daily)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
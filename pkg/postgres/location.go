@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/jackc/pgx/v4"
+)
+
+// InsertLocation replaces where the city geocodes to
+func (c *Client) InsertLocation(ctx context.Context, location *storage.Location) (err error) {
+	if err := c.available(); err != nil {
+		return err
+	}
+	defer c.observe(ctx, &err)
+
+	query := `INSERT INTO public.locations (city, name, country, lat, lon, timezone, geocoded_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (city) DO UPDATE SET
+				name = EXCLUDED.name,
+				country = EXCLUDED.country,
+				lat = EXCLUDED.lat,
+				lon = EXCLUDED.lon,
+				timezone = EXCLUDED.timezone,
+				geocoded_at = EXCLUDED.geocoded_at;`
+
+	_, err = c.pool.Exec(ctx, query, location.City, location.Name, location.Country, location.Lat, location.Lon, location.Timezone, location.GeocodedAt.UTC())
+	return err
+}
+
+// GetLocation returns the city's stored location, nil if there is none
+func (c *Client) GetLocation(ctx context.Context, city string) (_ *storage.Location, err error) {
+	if err := c.available(); err != nil {
+		return nil, err
+	}
+	defer c.observe(ctx, &err)
+
+	query := `SELECT city, name, country, lat, lon, timezone, geocoded_at FROM public.locations WHERE city = $1;`
+
+	out := &storage.Location{}
+	err = c.pool.QueryRow(ctx, query, city).Scan(&out.City, &out.Name, &out.Country, &out.Lat, &out.Lon, &out.Timezone, &out.GeocodedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// GetLocationBatch returns the cities' stored locations in one query keyed by city, cities without one are left out
+func (c *Client) GetLocationBatch(ctx context.Context, cities []string) (_ map[string]*storage.Location, err error) {
+	out := map[string]*storage.Location{}
+	if len(cities) == 0 {
+		return out, nil
+	}
+	if err := c.available(); err != nil {
+		return nil, err
+	}
+	defer c.observe(ctx, &err)
+
+	query := `SELECT city, name, country, lat, lon, timezone, geocoded_at FROM public.locations WHERE city = ANY($1);`
+
+	rows, err := c.pool.Query(ctx, query, cities)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		location := &storage.Location{}
+		err = rows.Scan(&location.City, &location.Name, &location.Country, &location.Lat, &location.Lon, &location.Timezone, &location.GeocodedAt)
+		if err != nil {
+			return nil, err
+		}
+		out[location.City] = location
+	}
+
+	return out, rows.Err()
+}
@@ -0,0 +1,249 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey is the advisory lock held while migrating so concurrent runs wait for each other
+const migrationLockKey = 7_391_102_345
+
+// Migration is a versioned schema change, loaded from migrations/<version>_<name>.<up|down>.sql
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a migration and when it was applied, nil if pending
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrations returns the embedded migrations in version order
+func Migrations() ([]Migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		parts := strings.SplitN(strings.TrimSuffix(name, ".sql"), "_", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid migration file name %s", name)
+		}
+		version, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %s", name)
+		}
+
+		byt, err := migrationFiles.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, exist := byVersion[version]
+		if !exist {
+			m = &Migration{Version: version}
+			byVersion[version] = m
+		}
+		switch {
+		case strings.HasSuffix(parts[1], ".up"):
+			m.Name = strings.TrimSuffix(parts[1], ".up")
+			m.Up = string(byt)
+		case strings.HasSuffix(parts[1], ".down"):
+			m.Down = string(byt)
+		default:
+			return nil, fmt.Errorf("migration %s is neither up nor down", name)
+		}
+	}
+
+	migrations := []Migration{}
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d needs both an up and down file", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// MigrateUp applies every pending migration, returning the ones applied
+func (c *Client) MigrateUp(ctx context.Context) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []Migration{}
+	err = c.withMigrationLock(ctx, func(conn *pgxpool.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if _, exist := versions[m.Version]; exist {
+				continue
+			}
+			err = runMigration(ctx, conn, m.Up, `INSERT INTO public.schema_migrations (version, name) VALUES ($1, $2);`, m.Version, m.Name)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s up: %v", m.Version, m.Name, err)
+			}
+			applied = append(applied, m)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// MigrateDown rolls back the latest applied migration, returning nil if there was nothing to roll back
+func (c *Client) MigrateDown(ctx context.Context) (*Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var rolledBack *Migration
+	err = c.withMigrationLock(ctx, func(conn *pgxpool.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0; i-- {
+			m := migrations[i]
+			if _, exist := versions[m.Version]; !exist {
+				continue
+			}
+			err = runMigration(ctx, conn, m.Down, `DELETE FROM public.schema_migrations WHERE version = $1;`, m.Version)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s down: %v", m.Version, m.Name, err)
+			}
+			rolledBack = &m
+			return nil
+		}
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+// MigrationStatus lists every migration and when it was applied
+func (c *Client) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	out := []MigrationStatus{}
+	err = c.withMigrationLock(ctx, func(conn *pgxpool.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			status := MigrationStatus{Migration: m}
+			if appliedAt, exist := versions[m.Version]; exist {
+				status.AppliedAt = &appliedAt
+			}
+			out = append(out, status)
+		}
+		return nil
+	})
+
+	return out, err
+}
+
+// withMigrationLock runs fn on a single connection holding the migration advisory lock
+func (c *Client) withMigrationLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := c.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, `SELECT pg_advisory_lock($1);`, migrationLockKey)
+	if err != nil {
+		return err
+	}
+	// Unlocked even if ctx is done, the connection goes back to the pool
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1);`, migrationLockKey)
+
+	_, err = conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS public.schema_migrations (
+		version bigint PRIMARY KEY,
+		name varchar NOT NULL,
+		applied_at timestamp NOT NULL DEFAULT (now() at time zone 'utc')
+	);`)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// appliedVersions returns when each applied migration version was applied
+func appliedVersions(ctx context.Context, conn *pgxpool.Conn) (map[int]time.Time, error) {
+	rows, err := conn.Query(ctx, `SELECT version, applied_at FROM public.schema_migrations;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := map[int]time.Time{}
+	for rows.Next() {
+		version := 0
+		appliedAt := time.Time{}
+		err = rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, err
+		}
+		versions[version] = appliedAt
+	}
+
+	return versions, rows.Err()
+}
+
+// runMigration runs a migration's sql and records it in schema_migrations in one transaction
+// Scripts without arguments run over the simple protocol, so they can hold several statements.
+func runMigration(ctx context.Context, conn *pgxpool.Conn, script string, record string, args ...interface{}) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, script)
+	if err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	_, err = tx.Exec(ctx, record, args...)
+	if err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
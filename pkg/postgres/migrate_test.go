@@ -0,0 +1,29 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/TomSED/weather-api/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrations(t *testing.T) {
+
+	t.Run("Embedded migrations should be ordered, contiguous and reversible", func(t *testing.T) {
+		migrations, err := postgres.Migrations()
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		if !assert.NotEmpty(t, migrations) {
+			t.Fatal()
+		}
+
+		for i, m := range migrations {
+			assert.Equal(t, i+1, m.Version)
+			assert.NotEmpty(t, m.Name)
+			assert.NotEmpty(t, m.Up)
+			assert.NotEmpty(t, m.Down)
+		}
+		assert.Equal(t, "create_weather", migrations[0].Name)
+	})
+}
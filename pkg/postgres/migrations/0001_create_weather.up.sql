@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS public.weather (
+	datasource varchar NOT NULL,
+	city varchar NOT NULL,
+	temperature integer NOT NULL,
+	windspeed integer NOT NULL,
+	updateddate timestamp NOT NULL
+);
+
+-- Tables created by v1 and v1.1 are missing the city column
+ALTER TABLE public.weather ADD COLUMN IF NOT EXISTS city varchar NOT NULL DEFAULT '';
+ALTER TABLE public.weather ALTER COLUMN city DROP DEFAULT;
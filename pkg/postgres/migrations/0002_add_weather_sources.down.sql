@@ -0,0 +1 @@
+ALTER TABLE public.weather DROP COLUMN IF EXISTS sources;
@@ -0,0 +1 @@
+ALTER TABLE public.weather ADD COLUMN IF NOT EXISTS sources text[];
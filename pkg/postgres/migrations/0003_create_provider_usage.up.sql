@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS public.provider_usage (
+	provider varchar NOT NULL,
+	period varchar NOT NULL,
+	calls integer NOT NULL DEFAULT 0,
+	PRIMARY KEY (provider, period)
+);
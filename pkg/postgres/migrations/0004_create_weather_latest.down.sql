@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.weather_latest;
+DROP INDEX IF EXISTS public.weather_city_updateddate_idx;
@@ -0,0 +1,18 @@
+CREATE INDEX IF NOT EXISTS weather_city_updateddate_idx ON public.weather (city, updateddate DESC);
+
+-- Latest observation per city and source, maintained by InsertWeatherData
+CREATE TABLE IF NOT EXISTS public.weather_latest (
+	city varchar NOT NULL,
+	datasource varchar NOT NULL,
+	temperature integer NOT NULL,
+	windspeed integer NOT NULL,
+	updateddate timestamp NOT NULL,
+	sources text[],
+	PRIMARY KEY (city, datasource)
+);
+
+INSERT INTO public.weather_latest (city, datasource, temperature, windspeed, updateddate, sources)
+SELECT DISTINCT ON (city, datasource) city, datasource, temperature, windspeed, updateddate, sources
+FROM public.weather
+ORDER BY city, datasource, updateddate DESC
+ON CONFLICT (city, datasource) DO NOTHING;
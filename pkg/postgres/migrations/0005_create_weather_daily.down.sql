@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.weather_daily;
+DROP INDEX IF EXISTS public.weather_updateddate_idx;
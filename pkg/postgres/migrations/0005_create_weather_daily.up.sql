@@ -0,0 +1,16 @@
+CREATE INDEX IF NOT EXISTS weather_updateddate_idx ON public.weather (updateddate);
+
+-- Daily aggregates of pruned observations, kept forever.
+-- Sums and sample counts are stored so batches can be merged, average = sum / samples.
+CREATE TABLE IF NOT EXISTS public.weather_daily (
+	city varchar NOT NULL,
+	datasource varchar NOT NULL,
+	day date NOT NULL,
+	samples integer NOT NULL,
+	temperature_min integer NOT NULL,
+	temperature_max integer NOT NULL,
+	temperature_sum bigint NOT NULL,
+	windspeed_max integer NOT NULL,
+	windspeed_sum bigint NOT NULL,
+	PRIMARY KEY (city, datasource, day)
+);
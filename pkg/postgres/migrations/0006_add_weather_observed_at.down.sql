@@ -0,0 +1,3 @@
+ALTER TABLE public.weather DROP CONSTRAINT IF EXISTS weather_city_datasource_observed_at_key;
+ALTER TABLE public.weather DROP COLUMN IF EXISTS observed_at;
+ALTER TABLE public.weather_latest DROP COLUMN IF EXISTS observed_at;
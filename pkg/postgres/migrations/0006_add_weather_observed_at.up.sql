@@ -0,0 +1,17 @@
+-- When the provider observed the weather, existing rows fall back to when they were fetched
+ALTER TABLE public.weather ADD COLUMN IF NOT EXISTS observed_at timestamp;
+UPDATE public.weather SET observed_at = updateddate WHERE observed_at IS NULL;
+ALTER TABLE public.weather ALTER COLUMN observed_at SET NOT NULL;
+
+ALTER TABLE public.weather_latest ADD COLUMN IF NOT EXISTS observed_at timestamp;
+UPDATE public.weather_latest SET observed_at = updateddate WHERE observed_at IS NULL;
+ALTER TABLE public.weather_latest ALTER COLUMN observed_at SET NOT NULL;
+
+-- Each observation is stored once
+DELETE FROM public.weather w
+USING public.weather d
+WHERE w.city = d.city
+	AND w.datasource = d.datasource
+	AND w.observed_at = d.observed_at
+	AND w.ctid > d.ctid;
+ALTER TABLE public.weather ADD CONSTRAINT weather_city_datasource_observed_at_key UNIQUE (city, datasource, observed_at);
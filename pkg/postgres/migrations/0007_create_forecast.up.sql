@@ -0,0 +1,8 @@
+-- Latest forecast per city, periods are JSON arrays of storage.ForecastPeriod
+CREATE TABLE IF NOT EXISTS public.forecast (
+	city varchar NOT NULL PRIMARY KEY,
+	datasource varchar NOT NULL,
+	fetched_at timestamp NOT NULL,
+	hourly jsonb NOT NULL,
+	daily jsonb NOT NULL
+);
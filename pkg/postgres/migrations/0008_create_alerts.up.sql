@@ -0,0 +1,8 @@
+-- Latest alerts per location, alerts is a JSON array of storage.Alert
+CREATE TABLE IF NOT EXISTS public.alerts (
+	location_key varchar NOT NULL PRIMARY KEY,
+	lat double precision NOT NULL,
+	lon double precision NOT NULL,
+	fetched_at timestamp NOT NULL,
+	alerts jsonb NOT NULL
+);
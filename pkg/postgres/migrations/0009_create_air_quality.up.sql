@@ -0,0 +1,15 @@
+-- Latest air quality per city, concentrations in µg/m³
+CREATE TABLE IF NOT EXISTS public.air_quality (
+	city varchar NOT NULL PRIMARY KEY,
+	datasource varchar NOT NULL,
+	lat double precision NOT NULL,
+	lon double precision NOT NULL,
+	measured_at timestamp NOT NULL,
+	fetched_at timestamp NOT NULL,
+	pm2_5 double precision NOT NULL,
+	pm10 double precision NOT NULL,
+	o3 double precision NOT NULL,
+	no2 double precision NOT NULL,
+	so2 double precision NOT NULL,
+	co double precision NOT NULL
+);
@@ -0,0 +1,9 @@
+-- Where each city geocodes to
+CREATE TABLE IF NOT EXISTS public.locations (
+	city varchar NOT NULL PRIMARY KEY,
+	name varchar NOT NULL,
+	country varchar NOT NULL,
+	lat double precision NOT NULL,
+	lon double precision NOT NULL,
+	geocoded_at timestamp NOT NULL
+);
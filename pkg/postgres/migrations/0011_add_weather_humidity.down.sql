@@ -0,0 +1,2 @@
+ALTER TABLE public.weather DROP COLUMN IF EXISTS humidity;
+ALTER TABLE public.weather_latest DROP COLUMN IF EXISTS humidity;
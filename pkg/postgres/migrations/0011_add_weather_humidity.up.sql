@@ -0,0 +1,3 @@
+-- Relative humidity in percent, 0 on rows stored before it was
+ALTER TABLE public.weather ADD COLUMN IF NOT EXISTS humidity integer NOT NULL DEFAULT 0;
+ALTER TABLE public.weather_latest ADD COLUMN IF NOT EXISTS humidity integer NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE public.weather DROP COLUMN IF EXISTS condition, DROP COLUMN IF EXISTS night;
+ALTER TABLE public.weather_latest DROP COLUMN IF EXISTS condition, DROP COLUMN IF EXISTS night;
@@ -0,0 +1,5 @@
+-- Canonical weather condition and whether it was observed at night, unknown on rows stored before they were
+ALTER TABLE public.weather ADD COLUMN IF NOT EXISTS condition varchar NOT NULL DEFAULT '';
+ALTER TABLE public.weather ADD COLUMN IF NOT EXISTS night boolean NOT NULL DEFAULT false;
+ALTER TABLE public.weather_latest ADD COLUMN IF NOT EXISTS condition varchar NOT NULL DEFAULT '';
+ALTER TABLE public.weather_latest ADD COLUMN IF NOT EXISTS night boolean NOT NULL DEFAULT false;
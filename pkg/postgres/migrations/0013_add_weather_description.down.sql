@@ -0,0 +1,2 @@
+ALTER TABLE public.weather DROP COLUMN IF EXISTS description, DROP COLUMN IF EXISTS description_language;
+ALTER TABLE public.weather_latest DROP COLUMN IF EXISTS description, DROP COLUMN IF EXISTS description_language;
@@ -0,0 +1,5 @@
+-- The provider's description of the weather and its language
+ALTER TABLE public.weather ADD COLUMN IF NOT EXISTS description varchar NOT NULL DEFAULT '';
+ALTER TABLE public.weather ADD COLUMN IF NOT EXISTS description_language varchar NOT NULL DEFAULT '';
+ALTER TABLE public.weather_latest ADD COLUMN IF NOT EXISTS description varchar NOT NULL DEFAULT '';
+ALTER TABLE public.weather_latest ADD COLUMN IF NOT EXISTS description_language varchar NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- IANA timezone or fixed offset of each location, unknown on rows stored before it was
+ALTER TABLE public.locations ADD COLUMN IF NOT EXISTS timezone varchar NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- The city's timezone when the weather was fetched, unknown on rows stored before it was
+ALTER TABLE public.weather ADD COLUMN IF NOT EXISTS timezone varchar NOT NULL DEFAULT '';
+ALTER TABLE public.weather_latest ADD COLUMN IF NOT EXISTS timezone varchar NOT NULL DEFAULT '';
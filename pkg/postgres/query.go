@@ -1,82 +1,295 @@
 package postgres
 
 import (
-	"database/sql"
-	"time"
+	"context"
+	"errors"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 )
 
-func (c *Client) InitTables() error {
+const (
+	// Observations already stored are skipped
+	insertWeatherQuery = `INSERT INTO public.weather (datasource, city, temperature, windspeed, updateddate, sources, observed_at, humidity, condition, night, description, description_language, timezone)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			ON CONFLICT (city, datasource, observed_at) DO NOTHING;`
+
+	// Older observations arriving late don't replace newer ones, the later fetch breaking ties
+	upsertLatestQuery = `INSERT INTO public.weather_latest AS l (datasource, city, temperature, windspeed, updateddate, sources, observed_at, humidity, condition, night, description, description_language, timezone)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			ON CONFLICT (city, datasource) DO UPDATE SET
+				temperature = EXCLUDED.temperature,
+				windspeed = EXCLUDED.windspeed,
+				updateddate = EXCLUDED.updateddate,
+				sources = EXCLUDED.sources,
+				observed_at = EXCLUDED.observed_at,
+				humidity = EXCLUDED.humidity,
+				condition = EXCLUDED.condition,
+				night = EXCLUDED.night,
+				description = EXCLUDED.description,
+				description_language = EXCLUDED.description_language,
+				timezone = EXCLUDED.timezone
+			WHERE (l.observed_at, l.updateddate) <= (EXCLUDED.observed_at, EXCLUDED.updateddate);`
+
+	getLatestQuery = `SELECT datasource,
+				city,
+				temperature,
+				windspeed,
+				updateddate,
+				sources,
+				observed_at,
+				humidity,
+				condition,
+				night,
+				description,
+				description_language,
+				timezone
+			FROM public.weather_latest
+			WHERE city = $1
+			ORDER BY observed_at desc, updateddate desc
+			LIMIT 1;`
 
-	script := `CREATE TABLE IF NOT EXISTS public.weather (
-		datasource varchar NOT NULL,
-		city varchar NOT NULL,
-		temperature integer NOT NULL,
-		windspeed integer NOT NULL,
-		updateddate timestamp NOT NULL
-	);`
+	getLatestBatchQuery = `SELECT DISTINCT ON (city)
+				datasource,
+				city,
+				temperature,
+				windspeed,
+				updateddate,
+				sources,
+				observed_at,
+				humidity,
+				condition,
+				night,
+				description,
+				description_language,
+				timezone
+			FROM public.weather_latest
+			WHERE city = ANY($1)
+			ORDER BY city, observed_at desc, updateddate desc;`
 
-	_, err := c.database.Exec(script)
+	reserveProviderCallQuery = `INSERT INTO public.provider_usage AS u (provider, period, calls)
+			VALUES ($1, $2, 1)
+			ON CONFLICT (provider, period) DO UPDATE SET calls = u.calls + 1
+			WHERE $3 <= 0 OR u.calls < $3
+			RETURNING calls;`
+)
+
+// preparedStatements are the hot path queries, prepared by name on each connection the first time it runs them
+var preparedStatements = map[string]string{
+	"insert_weather":        insertWeatherQuery,
+	"upsert_latest":         upsertLatestQuery,
+	"get_latest":            getLatestQuery,
+	"get_latest_batch":      getLatestBatchQuery,
+	"reserve_provider_call": reserveProviderCallQuery,
+}
+
+// acquirePrepared takes a pooled connection with the named statements prepared.
+// Preparing is a no-op once the connection has them, and waiting until first use means it works before migrations have run.
+func (c *Client) acquirePrepared(ctx context.Context, names ...string) (*pgxpool.Conn, error) {
+	conn, err := c.pool.Acquire(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	for _, name := range names {
+		_, err = conn.Conn().Prepare(ctx, name, preparedStatements[name])
+		if err != nil {
+			conn.Release()
+			return nil, err
+		}
+	}
+
+	return conn, nil
 }
 
-type WeatherData struct {
-	DataSource  string
-	City        string
-	Temperature int
-	WindSpeed   int
-	UpdatedDate time.Time
+// InitTables creates or updates the tables by applying any pending migrations
+func (c *Client) InitTables(ctx context.Context) error {
+	_, err := c.MigrateUp(ctx)
+	return err
 }
 
-// InsertWeatherData inserts weather data into database row
-func (c *Client) InsertWeatherData(weatherData *WeatherData) error {
-	query := `INSERT INTO public.weather VALUES ($1, $2, $3, $4, $5);`
+// InsertWeatherData inserts weather data into the history table and updates the city's latest row for the source
+func (c *Client) InsertWeatherData(ctx context.Context, weatherData *storage.WeatherData) (bool, error) {
+	inserted, err := c.InsertWeatherDataBatch(ctx, []*storage.WeatherData{weatherData})
+	return inserted == 1, err
+}
+
+// InsertWeatherDataBatch inserts the rows in one round trip. A batch runs in an implicit transaction, so either every
+// row is stored or none are. Returns how many of the observations were new.
+func (c *Client) InsertWeatherDataBatch(ctx context.Context, weatherData []*storage.WeatherData) (_ int, err error) {
+	if len(weatherData) == 0 {
+		return 0, nil
+	}
+	if err := c.available(); err != nil {
+		return 0, err
+	}
+	defer c.observe(ctx, &err)
 
-	_, err := c.database.Exec(query, weatherData.DataSource, weatherData.City, weatherData.Temperature, weatherData.WindSpeed, weatherData.UpdatedDate)
+	conn, err := c.acquirePrepared(ctx, "insert_weather", "upsert_latest")
 	if err != nil {
-		return err
+		return 0, err
+	}
+	defer conn.Release()
+
+	batch := &pgx.Batch{}
+	for _, data := range weatherData {
+		args := []interface{}{data.DataSource, data.City, data.Temperature, data.WindSpeed, data.UpdatedDate, data.Sources, data.ObservationTime().UTC(), data.Humidity, data.Condition, data.Night, data.Description, data.DescriptionLanguage, data.Timezone}
+		batch.Queue("insert_weather", args...)
+		batch.Queue("upsert_latest", args...)
+	}
+
+	inserted := 0
+	results := conn.SendBatch(ctx, batch)
+	for range weatherData {
+		tag, err := results.Exec()
+		if err != nil {
+			results.Close()
+			return 0, err
+		}
+		inserted += int(tag.RowsAffected())
+
+		_, err = results.Exec()
+		if err != nil {
+			results.Close()
+			return 0, err
+		}
 	}
 
-	return nil
+	err = results.Close()
+	if err != nil {
+		return 0, err
+	}
+	return inserted, nil
 }
 
-// GetLatestWeatherData returns latest weather data sorted by updated date
-func (c *Client) GetLatestWeatherData(city string) (*WeatherData, error) {
-	query := `SELECT datasource,
-				city,
-				temperature,
-				windspeed,
-				updateddate
-			FROM public.weather
-			WHERE city = $1
-			ORDER BY updateddate desc
-			LIMIT 1;`
+// GetLatestWeatherData returns the city's latest weather data across sources
+func (c *Client) GetLatestWeatherData(ctx context.Context, city string) (_ *storage.WeatherData, err error) {
+	if err := c.available(); err != nil {
+		return nil, err
+	}
+	defer c.observe(ctx, &err)
 
-	row := c.database.QueryRow(query, city)
+	conn, err := c.acquirePrepared(ctx, "get_latest")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
 
-	dataSource := ""
-	city = ""
-	temp := 0
-	windSpeed := 0
-	updatedDate := time.Time{}
-	err := row.Scan(&dataSource, &city, &temp, &windSpeed, &updatedDate)
+	out, err := scanWeatherData(conn.QueryRow(ctx, "get_latest", city))
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
 		return nil, err
 	}
 
-	out := &WeatherData{
-		DataSource:  dataSource,
-		City:        city,
-		Temperature: temp,
-		WindSpeed:   windSpeed,
-		UpdatedDate: updatedDate,
+	return out, nil
+}
+
+// GetLatestWeatherDataBatch returns each city's latest weather data across sources in one query, keyed by city
+func (c *Client) GetLatestWeatherDataBatch(ctx context.Context, cities []string) (_ map[string]*storage.WeatherData, err error) {
+	out := map[string]*storage.WeatherData{}
+	if len(cities) == 0 {
+		return out, nil
+	}
+	if err := c.available(); err != nil {
+		return nil, err
+	}
+	defer c.observe(ctx, &err)
+
+	conn, err := c.acquirePrepared(ctx, "get_latest_batch")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, "get_latest_batch", cities)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
+
+	for rows.Next() {
+		data, err := scanWeatherData(rows)
+		if err != nil {
+			return nil, err
+		}
+		out[data.City] = data
+	}
+
+	return out, rows.Err()
+}
+
+// scanWeatherData scans a weather_latest row selected in getLatestQuery's column order
+func scanWeatherData(row pgx.Row) (*storage.WeatherData, error) {
+	out := &storage.WeatherData{}
+	sources := []string{}
+	err := row.Scan(&out.DataSource, &out.City, &out.Temperature, &out.WindSpeed, &out.UpdatedDate, &sources, &out.ObservedAt, &out.Humidity, &out.Condition, &out.Night, &out.Description, &out.DescriptionLanguage, &out.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	out.Sources = sources
 
 	return out, nil
 }
+
+// ReserveProviderCall atomically counts a call to provider for the billing period,
+// unless the period's calls have already reached limit (limit <= 0 is unlimited)
+func (c *Client) ReserveProviderCall(ctx context.Context, provider string, period string, limit int) (_ bool, err error) {
+	if err := c.available(); err != nil {
+		return false, err
+	}
+	defer c.observe(ctx, &err)
+
+	conn, err := c.acquirePrepared(ctx, "reserve_provider_call")
+	if err != nil {
+		return false, err
+	}
+	defer conn.Release()
+
+	calls := 0
+	err = conn.QueryRow(ctx, "reserve_provider_call", provider, period, limit).Scan(&calls)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ReleaseProviderCall gives back a call reserved by ReserveProviderCall that wasn't made
+func (c *Client) ReleaseProviderCall(ctx context.Context, provider string, period string) (err error) {
+	if err := c.available(); err != nil {
+		return err
+	}
+	defer c.observe(ctx, &err)
+
+	query := `UPDATE public.provider_usage SET calls = calls - 1 WHERE provider = $1 AND period = $2 AND calls > 0;`
+
+	_, err = c.pool.Exec(ctx, query, provider, period)
+	return err
+}
+
+// GetProviderUsage returns the number of calls made to provider in the billing period
+func (c *Client) GetProviderUsage(ctx context.Context, provider string, period string) (_ int, err error) {
+	if err := c.available(); err != nil {
+		return 0, err
+	}
+	defer c.observe(ctx, &err)
+
+	query := `SELECT calls FROM public.provider_usage WHERE provider = $1 AND period = $2;`
+
+	calls := 0
+	err = c.pool.QueryRow(ctx, query, provider, period).Scan(&calls)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return calls, nil
+}
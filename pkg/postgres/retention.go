@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+)
+
+const (
+	defaultPruneBatchSize = 5000
+)
+
+// PruneWeatherData deletes history rows observed before the policy's retention in batches,
+// rolling each batch up into daily aggregates of its observation days in the same statement when enabled
+func (c *Client) PruneWeatherData(ctx context.Context, policy storage.RetentionPolicy) (_ *storage.PruneResult, err error) {
+	if err := c.available(); err != nil {
+		return nil, err
+	}
+	defer c.observe(ctx, &err)
+
+	query := `WITH batch AS (
+				DELETE FROM public.weather
+				WHERE ctid IN (SELECT ctid FROM public.weather WHERE COALESCE(observed_at, updateddate) < $1 LIMIT $2)
+				RETURNING datasource, city, temperature, windspeed, COALESCE(observed_at, updateddate) AS observed_at
+			), rollup AS (
+				INSERT INTO public.weather_daily AS d (city, datasource, day, samples, temperature_min, temperature_max, temperature_sum, windspeed_max, windspeed_sum)
+				SELECT city, datasource, observed_at::date, count(*), min(temperature), max(temperature), sum(temperature), max(windspeed), sum(windspeed)
+				FROM batch
+				WHERE $3
+				GROUP BY city, datasource, observed_at::date
+				ON CONFLICT (city, datasource, day) DO UPDATE SET
+					samples = d.samples + EXCLUDED.samples,
+					temperature_min = LEAST(d.temperature_min, EXCLUDED.temperature_min),
+					temperature_max = GREATEST(d.temperature_max, EXCLUDED.temperature_max),
+					temperature_sum = d.temperature_sum + EXCLUDED.temperature_sum,
+					windspeed_max = GREATEST(d.windspeed_max, EXCLUDED.windspeed_max),
+					windspeed_sum = d.windspeed_sum + EXCLUDED.windspeed_sum
+			)
+			SELECT count(*) FROM batch;`
+
+	batchSize := policy.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultPruneBatchSize
+	}
+
+	result := &storage.PruneResult{
+		Cutoff: time.Now().UTC().Add(-policy.RawRetention),
+	}
+	for {
+		pruned := 0
+		err = c.pool.QueryRow(ctx, query, result.Cutoff, batchSize, policy.Rollup).Scan(&pruned)
+		if err != nil {
+			return result, err
+		}
+
+		result.Pruned += pruned
+		result.Batches++
+		if pruned < batchSize {
+			return result, nil
+		}
+	}
+}
@@ -1,23 +1,82 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/TomSED/weather-api/pkg/postgres"
 )
 
+// Usage: go run pkg/postgres/setup/main.go [migrate up|down|status]
+// With no arguments, pending migrations are applied.
 func main() {
 
-	client, err := postgres.NewClient(os.Getenv("PG_RDS_HOST"), os.Getenv("PG_RDS_PORT"), os.Getenv("PG_USERNAME"), os.Getenv("PG_PASSWORD"), os.Getenv("PG_DB_NAME"))
+	command := "up"
+	if len(os.Args) > 1 {
+		if os.Args[1] != "migrate" || len(os.Args) != 3 {
+			fmt.Println("usage: setup [migrate up|down|status]")
+			os.Exit(2)
+		}
+		command = os.Args[2]
+	}
+
+	config, err := postgres.LoadConfig(os.Getenv)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
+	for _, warning := range config.Warnings {
+		fmt.Println(warning)
+	}
 
-	err = client.InitTables()
+	client, err := postgres.NewClient(config)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
+	defer client.Close()
+
+	ctx := context.Background()
+	switch command {
+	case "up":
+		applied, err := client.MigrateUp(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		rolledBack, err := client.MigrateDown(ctx)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if rolledBack == nil {
+			fmt.Println("no migrations to roll back")
+		} else {
+			fmt.Printf("rolled back %04d_%s\n", rolledBack.Version, rolledBack.Name)
+		}
+	case "status":
+		statuses, err := client.MigrationStatus(ctx)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+	default:
+		fmt.Println("usage: setup [migrate up|down|status]")
+		os.Exit(2)
+	}
 }
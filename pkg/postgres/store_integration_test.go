@@ -0,0 +1,42 @@
+//go:build integration
+// +build integration
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/TomSED/weather-api/pkg/storage/storagetest"
+)
+
+// Runs the storage conformance suite against the database in the PG_* env vars, which is migrated and truncated.
+// go test -tags integration ./pkg/postgres/...
+func TestClientStore(t *testing.T) {
+	config, err := LoadConfig(os.Getenv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.MigrateUp(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storagetest.TestStore(t, func(t *testing.T) storage.Store {
+		_, err := client.pool.Exec(ctx, `TRUNCATE public.weather, public.weather_latest, public.weather_daily, public.provider_usage, public.forecast, public.alerts, public.air_quality, public.locations;`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	})
+}
@@ -0,0 +1,180 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrLimited is returned when a token isn't available in time
+var ErrLimited = errors.New("ratelimit: rate limit exceeded")
+
+// Clock is the time source of a Limiter, swapped out in tests
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Limiter is a token bucket refilled at limit tokens per period, holding up to burst tokens
+type Limiter struct {
+	rate  float64 // tokens per second
+	burst float64
+	wait  bool
+	clock Clock
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter creates a Limiter allowing limit calls per period, starting with a full bucket of 1 token.
+// By default Take fails fast when no token is available.
+func NewLimiter(limit int, per time.Duration) *Limiter {
+	clock := realClock{}
+	return &Limiter{
+		rate:   float64(limit) / per.Seconds(),
+		burst:  1,
+		clock:  clock,
+		tokens: 1,
+		last:   clock.Now(),
+	}
+}
+
+// SetBurst sets how many calls can be made back to back, refilling the bucket
+func (l *Limiter) SetBurst(burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.burst = float64(burst)
+	l.tokens = l.burst
+}
+
+// SetWait makes Take wait for a token when it will be available before the context's deadline
+func (l *Limiter) SetWait(wait bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.wait = wait
+}
+
+// SetClock overrides the clock, refilling the bucket
+func (l *Limiter) SetClock(clock Clock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clock = clock
+	l.last = clock.Now()
+	l.tokens = l.burst
+}
+
+// Take takes a token, waiting for one if enabled, or returns ErrLimited
+func (l *Limiter) Take(ctx context.Context) error {
+	l.mu.Lock()
+	wait := l.wait
+	l.mu.Unlock()
+
+	if !wait {
+		if !l.Allow() {
+			return ErrLimited
+		}
+		return nil
+	}
+	return l.Wait(ctx)
+}
+
+// Allow takes a token if one is available now
+func (l *Limiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// Wait takes a token, waiting until it is available.
+// Fails fast with ErrLimited if the token won't be available before ctx's deadline.
+func (l *Limiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	l.refill()
+	l.tokens--
+	if l.tokens >= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	deadline, hasDeadline := ctx.Deadline()
+	if hasDeadline && l.clock.Now().Add(delay).After(deadline) {
+		l.tokens++
+		l.mu.Unlock()
+		return ErrLimited
+	}
+	clock := l.clock
+	l.mu.Unlock()
+
+	select {
+	case <-clock.After(delay):
+		return nil
+	case <-ctx.Done():
+		// Give the reserved token back
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// refill adds the tokens accrued since the last call, must hold mu
+func (l *Limiter) refill() {
+	now := l.clock.Now()
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+	if elapsed <= 0 {
+		return
+	}
+
+	l.tokens += elapsed * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// ParseRate parses rates like 60/m, 1000/h or 5/10s into a limit and period
+func ParseRate(rate string) (int, time.Duration, error) {
+	parts := strings.SplitN(rate, "/", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid rate %q, expected limit/period", rate)
+	}
+
+	limit, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || limit <= 0 {
+		return 0, 0, fmt.Errorf("invalid rate limit %q", parts[0])
+	}
+
+	var per time.Duration
+	switch unit := strings.TrimSpace(parts[1]); unit {
+	case "s":
+		per = time.Second
+	case "m":
+		per = time.Minute
+	case "h":
+		per = time.Hour
+	default:
+		per, err = time.ParseDuration(unit)
+		if err != nil || per <= 0 {
+			return 0, 0, fmt.Errorf("invalid rate period %q", unit)
+		}
+	}
+
+	return limit, per, nil
+}
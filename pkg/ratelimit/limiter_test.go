@@ -0,0 +1,188 @@
+package ratelimit_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock only moves when advanced
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+	waiting chan struct{}
+}
+
+type waiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{
+		now:     time.Date(2021, 5, 16, 0, 0, 0, 0, time.UTC),
+		waiting: make(chan struct{}, 16),
+	}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, waiter{at: c.now.Add(d), ch: ch})
+	c.waiting <- struct{}{}
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	remaining := []waiter{}
+	for _, w := range c.waiters {
+		if !w.at.After(c.now) {
+			w.ch <- c.now
+		} else {
+			remaining = append(remaining, w)
+		}
+	}
+	c.waiters = remaining
+}
+
+func TestAllow(t *testing.T) {
+
+	t.Run("It should allow the burst then refill at the rate", func(t *testing.T) {
+		clock := newFakeClock()
+		limiter := ratelimit.NewLimiter(60, time.Minute)
+		limiter.SetClock(clock)
+		limiter.SetBurst(3)
+
+		assert.True(t, limiter.Allow())
+		assert.True(t, limiter.Allow())
+		assert.True(t, limiter.Allow())
+		assert.False(t, limiter.Allow())
+
+		clock.Advance(500 * time.Millisecond)
+		assert.False(t, limiter.Allow())
+		clock.Advance(500 * time.Millisecond)
+		assert.True(t, limiter.Allow())
+
+		// Never refills past the burst
+		clock.Advance(time.Hour)
+		assert.True(t, limiter.Allow())
+		assert.True(t, limiter.Allow())
+		assert.True(t, limiter.Allow())
+		assert.False(t, limiter.Allow())
+	})
+
+	t.Run("Take should fail fast by default", func(t *testing.T) {
+		limiter := ratelimit.NewLimiter(1, time.Minute)
+		limiter.SetClock(newFakeClock())
+
+		assert.Nil(t, limiter.Take(context.Background()))
+		assert.Equal(t, ratelimit.ErrLimited, limiter.Take(context.Background()))
+	})
+}
+
+func TestWait(t *testing.T) {
+
+	t.Run("It should wait for the next token", func(t *testing.T) {
+		clock := newFakeClock()
+		limiter := ratelimit.NewLimiter(60, time.Minute)
+		limiter.SetClock(clock)
+		limiter.SetWait(true)
+
+		assert.Nil(t, limiter.Take(context.Background()))
+
+		done := make(chan error)
+		go func() {
+			done <- limiter.Take(context.Background())
+		}()
+
+		<-clock.waiting
+		select {
+		case <-done:
+			t.Fatal("should still be waiting")
+		default:
+		}
+
+		clock.Advance(time.Second)
+		assert.Nil(t, <-done)
+	})
+
+	t.Run("It should fail fast when the token won't be available before the deadline", func(t *testing.T) {
+		clock := newFakeClock()
+		limiter := ratelimit.NewLimiter(1, time.Minute)
+		limiter.SetClock(clock)
+		limiter.SetWait(true)
+
+		assert.Nil(t, limiter.Take(context.Background()))
+
+		ctx, cancel := context.WithDeadline(context.Background(), clock.Now().Add(30*time.Second))
+		defer cancel()
+		assert.Equal(t, ratelimit.ErrLimited, limiter.Take(ctx))
+
+		// The failed call didn't use up the next token
+		clock.Advance(time.Minute)
+		assert.True(t, limiter.Allow())
+	})
+
+	t.Run("It should give the token back when the context is cancelled", func(t *testing.T) {
+		clock := newFakeClock()
+		limiter := ratelimit.NewLimiter(1, time.Minute)
+		limiter.SetClock(clock)
+
+		assert.True(t, limiter.Allow())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- limiter.Wait(ctx)
+		}()
+		<-clock.waiting
+		cancel()
+		assert.Equal(t, context.Canceled, <-done)
+
+		clock.Advance(time.Minute)
+		assert.True(t, limiter.Allow())
+	})
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		rate  string
+		limit int
+		per   time.Duration
+		err   bool
+	}{
+		{rate: "60/m", limit: 60, per: time.Minute},
+		{rate: "1000/h", limit: 1000, per: time.Hour},
+		{rate: "5/s", limit: 5, per: time.Second},
+		{rate: "5/10s", limit: 5, per: 10 * time.Second},
+		{rate: "60", err: true},
+		{rate: "0/m", err: true},
+		{rate: "60/fortnight", err: true},
+	}
+
+	for _, test := range tests {
+		limit, per, err := ratelimit.ParseRate(test.rate)
+		if test.err {
+			assert.NotNil(t, err, test.rate)
+			continue
+		}
+		assert.Nil(t, err, test.rate)
+		assert.Equal(t, test.limit, limit, test.rate)
+		assert.Equal(t, test.per, per, test.rate)
+	}
+}
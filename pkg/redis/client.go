@@ -0,0 +1,332 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTimeout = 250 * time.Millisecond
+	defaultMaxIdle = 4
+	defaultPort    = "6379"
+)
+
+// ErrClosed is returned by commands on a closed client
+var ErrClosed = errors.New("redis: client is closed")
+
+// Error is an error reply from the server, e.g. WRONGTYPE. The connection is still usable after one.
+type Error string
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+// Client is a minimal client for Redis compatible servers using the RESP protocol, with a small pool of idle connections
+type Client struct {
+	addr     string
+	password string
+	db       int
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	idle   []*conn
+	closed bool
+}
+
+type conn struct {
+	netConn net.Conn
+	reader  *bufio.Reader
+}
+
+// NewClient creates a client for the server at addr (host:port). Connections are made on first use.
+func NewClient(addr string) *Client {
+	return &Client{
+		addr:    addr,
+		timeout: defaultTimeout,
+	}
+}
+
+// ParseURL creates a client from a redis://[:password@]host[:port][/db] url
+func ParseURL(rawURL string) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("redis: unsupported url scheme %q", u.Scheme)
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), defaultPort)
+	}
+	c := NewClient(host)
+
+	if password, ok := u.User.Password(); ok {
+		c.SetPassword(password)
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		n, err := strconv.Atoi(db)
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid db %q", db)
+		}
+		c.SetDB(n)
+	}
+
+	return c, nil
+}
+
+// SetPassword sets the password sent with AUTH on each new connection
+func (c *Client) SetPassword(password string) {
+	c.password = password
+}
+
+// SetDB sets the database selected on each new connection
+func (c *Client) SetDB(db int) {
+	c.db = db
+}
+
+// SetTimeout sets the dial and per command timeout used when the context has no earlier deadline
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// Close closes the idle connections, commands in flight finish on their own
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	for _, cn := range c.idle {
+		cn.netConn.Close()
+	}
+	c.idle = nil
+	return nil
+}
+
+// Do sends a command and returns its reply: string for simple strings, []byte for bulk strings, int64 for integers,
+// []interface{} for arrays and nil for nil replies. Error replies are returned as Error.
+func (c *Client) Do(ctx context.Context, args ...string) (interface{}, error) {
+	deadline := time.Now().Add(c.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	cn, err := c.get(ctx, deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := cn.do(deadline, args)
+	if err != nil {
+		var replyErr Error
+		if !errors.As(err, &replyErr) {
+			// The connection's state is unknown after an I/O error
+			cn.netConn.Close()
+			return nil, err
+		}
+	}
+	c.put(cn)
+
+	return reply, err
+}
+
+// Ping checks the server is reachable
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+// Get returns the value of key, nil if it doesn't exist
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	reply, err := c.Do(ctx, "GET", key)
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, nil
+	}
+
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("redis: unexpected GET reply %T", reply)
+	}
+	return value, nil
+}
+
+// Set sets key to value, expiring after ttl (0 never expires)
+func (c *Client) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+
+	_, err := c.Do(ctx, args...)
+	return err
+}
+
+// Del deletes the keys, returning how many existed
+func (c *Client) Del(ctx context.Context, keys ...string) (int, error) {
+	reply, err := c.Do(ctx, append([]string{"DEL"}, keys...)...)
+	if err != nil {
+		return 0, err
+	}
+
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected DEL reply %T", reply)
+	}
+	return int(n), nil
+}
+
+// get takes an idle connection or dials a new one, authenticating and selecting the db
+func (c *Client) get(ctx context.Context, deadline time.Time) (*conn, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrClosed
+	}
+	if n := len(c.idle); n > 0 {
+		cn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return cn, nil
+	}
+	c.mu.Unlock()
+
+	dialer := net.Dialer{Deadline: deadline}
+	netConn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, err
+	}
+	cn := &conn{
+		netConn: netConn,
+		reader:  bufio.NewReader(netConn),
+	}
+
+	if c.password != "" {
+		_, err = cn.do(deadline, []string{"AUTH", c.password})
+		if err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	if c.db != 0 {
+		_, err = cn.do(deadline, []string{"SELECT", strconv.Itoa(c.db)})
+		if err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+
+	return cn, nil
+}
+
+// put returns a connection to the idle pool, closing it if the pool is full
+func (c *Client) put(cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed || len(c.idle) >= defaultMaxIdle {
+		cn.netConn.Close()
+		return
+	}
+	c.idle = append(c.idle, cn)
+}
+
+// do writes the command as an array of bulk strings and reads one reply
+func (cn *conn) do(deadline time.Time, args []string) (interface{}, error) {
+	err := cn.netConn.SetDeadline(deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	_, err = cn.netConn.Write(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return readReply(cn.reader)
+}
+
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid bulk length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		_, err = io.ReadFull(r, buf)
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid array length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		out := make([]interface{}, n)
+		for i := range out {
+			// Errors inside arrays (e.g. from EXEC) are returned as values
+			out[i], err = readReply(r)
+			if err != nil {
+				if replyErr, ok := err.(Error); ok {
+					out[i] = replyErr
+					continue
+				}
+				return nil, err
+			}
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasSuffix(line, "\r\n") {
+		return "", fmt.Errorf("redis: malformed reply %q", line)
+	}
+	return line[:len(line)-2], nil
+}
@@ -0,0 +1,123 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/redis"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient(t *testing.T) {
+
+	ctx := context.Background()
+
+	t.Run("It should set, get and delete values", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(server.Addr())
+		defer client.Close()
+
+		assert.Nil(t, client.Ping(ctx))
+
+		value, err := client.Get(ctx, "missing")
+		assert.Nil(t, err)
+		assert.Nil(t, value)
+
+		// Values are binary safe
+		assert.Nil(t, client.Set(ctx, "key", []byte("a\r\nb"), 0))
+		value, err = client.Get(ctx, "key")
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("a\r\nb"), value)
+
+		deleted, err := client.Del(ctx, "key", "missing")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, deleted)
+
+		value, err = client.Get(ctx, "key")
+		assert.Nil(t, err)
+		assert.Nil(t, value)
+	})
+
+	t.Run("It should expire values after the ttl", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(server.Addr())
+		defer client.Close()
+
+		assert.Nil(t, client.Set(ctx, "key", []byte("value"), 2*time.Second))
+		assert.Equal(t, 2*time.Second, server.TTL("key"))
+
+		server.FastForward(3 * time.Second)
+		value, err := client.Get(ctx, "key")
+		assert.Nil(t, err)
+		assert.Nil(t, value)
+	})
+
+	t.Run("It should return error replies and keep the connection usable", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(server.Addr())
+		defer client.Close()
+
+		server.Lpush("list", "value")
+		_, err := client.Get(ctx, "list")
+		if !assert.NotNil(t, err) {
+			t.FailNow()
+		}
+		_, ok := err.(redis.Error)
+		assert.True(t, ok)
+
+		reply, err := client.Do(ctx, "LRANGE", "list", "0", "-1")
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{[]byte("value")}, reply)
+	})
+
+	t.Run("It should authenticate and select the db from the url", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		server.RequireAuth("secret")
+
+		client, err := redis.ParseURL("redis://:secret@" + server.Addr() + "/2")
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		defer client.Close()
+
+		assert.Nil(t, client.Set(ctx, "key", []byte("value"), 0))
+		server.Select(2)
+		value, err := server.Get("key")
+		assert.Nil(t, err)
+		assert.Equal(t, "value", value)
+
+		unauthenticated := redis.NewClient(server.Addr())
+		defer unauthenticated.Close()
+		assert.NotNil(t, unauthenticated.Ping(ctx))
+	})
+
+	t.Run("It should reject invalid urls", func(t *testing.T) {
+		_, err := redis.ParseURL("http://localhost:6379")
+		assert.NotNil(t, err)
+		_, err = redis.ParseURL("redis://localhost:6379/db")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("It should reconnect after the server restarts", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(server.Addr())
+		defer client.Close()
+
+		assert.Nil(t, client.Ping(ctx))
+		server.Close()
+		assert.NotNil(t, client.Ping(ctx))
+
+		assert.Nil(t, server.Restart())
+		assert.Nil(t, client.Ping(ctx))
+	})
+
+	t.Run("It should fail once closed", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(server.Addr())
+
+		assert.Nil(t, client.Close())
+		assert.Equal(t, redis.ErrClosed, client.Ping(ctx))
+	})
+}
@@ -0,0 +1,158 @@
+package sensorfeed_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+)
+
+// testBroker is a minimal in-process MQTT 3.1.1 broker supporting QoS 0 only.
+// Every publish is forwarded to all clients that have subscribed to something; topic routing is left to the client.
+type testBroker struct {
+	listener net.Listener
+
+	mu          sync.Mutex
+	subscribers map[net.Conn]bool
+	subscribed  chan struct{}
+}
+
+func newTestBroker() (*testBroker, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	b := &testBroker{
+		listener:    listener,
+		subscribers: map[net.Conn]bool{},
+		subscribed:  make(chan struct{}, 16),
+	}
+	go b.serve()
+	return b, nil
+}
+
+func (b *testBroker) URL() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+func (b *testBroker) Close() {
+	b.listener.Close()
+	b.mu.Lock()
+	for conn := range b.subscribers {
+		conn.Close()
+	}
+	b.mu.Unlock()
+}
+
+func (b *testBroker) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(conn)
+	}
+}
+
+func (b *testBroker) handle(conn net.Conn) {
+	defer func() {
+		b.mu.Lock()
+		delete(b.subscribers, conn)
+		b.mu.Unlock()
+		conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	for {
+		header, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+
+		switch header >> 4 {
+		case 1: // CONNECT
+			conn.Write([]byte{0x20, 0x02, 0x00, 0x00})
+		case 3: // PUBLISH
+			b.forward(header, body)
+		case 8: // SUBSCRIBE
+			topics := 0
+			for i := 2; i+2 <= len(body); {
+				i += 2 + int(binary.BigEndian.Uint16(body[i:])) + 1
+				topics++
+			}
+			suback := []byte{0x90, byte(2 + topics), body[0], body[1]}
+			suback = append(suback, make([]byte, topics)...)
+			b.mu.Lock()
+			b.subscribers[conn] = true
+			conn.Write(suback)
+			b.mu.Unlock()
+			b.subscribed <- struct{}{}
+		case 12: // PINGREQ
+			conn.Write([]byte{0xD0, 0x00})
+		case 14: // DISCONNECT
+			return
+		}
+	}
+}
+
+// forward re-sends a publish to subscribers as QoS 0
+func (b *testBroker) forward(header byte, body []byte) {
+	topicLen := int(binary.BigEndian.Uint16(body))
+	topic := body[2 : 2+topicLen]
+	payload := body[2+topicLen:]
+	if qos := (header >> 1) & 0x03; qos > 0 {
+		payload = payload[2:]
+	}
+
+	packet := []byte{0x30}
+	packet = append(packet, encodeLength(2+len(topic)+len(payload))...)
+	packet = append(packet, body[:2+topicLen]...)
+	packet = append(packet, payload...)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for conn := range b.subscribers {
+		conn.Write(packet)
+	}
+}
+
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length, multiplier := 0, 1
+	for {
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(digit&0x7F) * multiplier
+		if digit&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	return header, body, err
+}
+
+func encodeLength(length int) []byte {
+	var out []byte
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		out = append(out, digit)
+		if length == 0 {
+			return out
+		}
+	}
+}
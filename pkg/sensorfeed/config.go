@@ -0,0 +1,106 @@
+package sensorfeed
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatNumber = "number"
+	FormatJSON   = "json"
+
+	FieldTemperature = "temperature"
+	FieldWindSpeed   = "wind_speed"
+
+	defaultDataSource = "mqtt"
+	defaultClientID   = "weather-api-sensorfeed"
+)
+
+// Config describes the broker to connect to and how each topic maps onto weather data
+type Config struct {
+	Broker     string         `yaml:"broker"`
+	ClientID   string         `yaml:"client_id"`
+	Username   string         `yaml:"username"`
+	Password   string         `yaml:"password"`
+	DataSource string         `yaml:"data_source"`
+	Topics     []TopicMapping `yaml:"topics"`
+}
+
+// TopicMapping maps the payloads of a topic (wildcards allowed) to a city's weather fields.
+// Plain numeric payloads set a single Field, JSON payloads set each of Fields from a dotted key path.
+type TopicMapping struct {
+	Topic  string             `yaml:"topic"`
+	City   string             `yaml:"city"`
+	Format string             `yaml:"format"`
+	Field  string             `yaml:"field"`
+	Fields map[string]string  `yaml:"fields"`
+	Scale  map[string]float64 `yaml:"scale"`
+}
+
+// LoadConfig reads a YAML (or JSON) mapping config from path
+func LoadConfig(path string) (*Config, error) {
+	byt, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	err = yaml.Unmarshal(byt, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, config.Validate()
+}
+
+// Validate checks the config and fills in defaults
+func (c *Config) Validate() error {
+	if c.Broker == "" {
+		return fmt.Errorf("missing broker")
+	}
+	if c.ClientID == "" {
+		c.ClientID = defaultClientID
+	}
+	if c.DataSource == "" {
+		c.DataSource = defaultDataSource
+	}
+	if len(c.Topics) == 0 {
+		return fmt.Errorf("no topics configured")
+	}
+
+	for i := range c.Topics {
+		t := &c.Topics[i]
+		if t.Topic == "" || t.City == "" {
+			return fmt.Errorf("topics[%d]: topic and city are required", i)
+		}
+		if t.Format == "" {
+			t.Format = FormatNumber
+		}
+
+		switch t.Format {
+		case FormatNumber:
+			if !validField(t.Field) {
+				return fmt.Errorf("topics[%d]: invalid field %q", i, t.Field)
+			}
+		case FormatJSON:
+			if len(t.Fields) == 0 {
+				return fmt.Errorf("topics[%d]: json topics need at least one entry in fields", i)
+			}
+			for field, key := range t.Fields {
+				if !validField(field) || key == "" {
+					return fmt.Errorf("topics[%d]: invalid fields entry %q: %q", i, field, key)
+				}
+			}
+		default:
+			return fmt.Errorf("topics[%d]: unknown format %q", i, t.Format)
+		}
+	}
+
+	return nil
+}
+
+func validField(field string) bool {
+	return field == FieldTemperature || field == FieldWindSpeed
+}
@@ -0,0 +1,244 @@
+package sensorfeed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/sirupsen/logrus"
+)
+
+// Store is where the subscriber writes combined observations (i.e. a storage.Store)
+type Store interface {
+	InsertWeatherData(context.Context, *storage.WeatherData) (bool, error)
+}
+
+// reading holds the latest sensor values received for a city
+type reading struct {
+	temperature *float64
+	windSpeed   *float64
+}
+
+// Subscriber listens to sensor topics and writes observations once both temperature and windspeed are known for a city
+type Subscriber struct {
+	config *Config
+	store  Store
+	logger *logrus.Logger
+
+	mu       sync.Mutex
+	readings map[string]*reading
+}
+
+// NewSubscriber creates a new Subscriber
+func NewSubscriber(config *Config, store Store) (*Subscriber, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Subscriber{
+		config:   config,
+		store:    store,
+		readings: map[string]*reading{},
+	}, nil
+}
+
+func (s *Subscriber) SetLogger(logger *logrus.Logger) {
+	s.logger = logger
+}
+
+// Run connects to the broker and subscribes to the configured topics, blocking until ctx is done. Connecting is retried
+// until it succeeds or ctx is done.
+func (s *Subscriber) Run(ctx context.Context) error {
+	opts := mqtt.NewClientOptions().
+		AddBroker(s.config.Broker).
+		SetClientID(s.config.ClientID).
+		SetUsername(s.config.Username).
+		SetPassword(s.config.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetOnConnectHandler(func(client mqtt.Client) {
+			// Subscriptions are re-made on every (re)connect
+			for _, t := range s.config.Topics {
+				token := client.Subscribe(t.Topic, 0, func(_ mqtt.Client, msg mqtt.Message) {
+					s.onMessage(ctx, msg)
+				})
+				if token.Wait() && token.Error() != nil && s.logger != nil {
+					s.logger.Errorf("client.Subscribe %s error: %v\n", t.Topic, token.Error())
+				}
+			}
+		})
+
+	client := mqtt.NewClient(opts)
+	token := client.Connect()
+	// Wait ignores ctx, shutting down mustn't hang while the broker is unreachable
+	select {
+	case <-token.Done():
+	case <-ctx.Done():
+		client.Disconnect(0)
+		return nil
+	}
+	if token.Error() != nil {
+		return token.Error()
+	}
+	defer client.Disconnect(250)
+
+	<-ctx.Done()
+	return nil
+}
+
+func (s *Subscriber) onMessage(ctx context.Context, msg mqtt.Message) {
+	err := s.HandleMessage(ctx, msg.Topic(), msg.Payload())
+	if err != nil && s.logger != nil {
+		s.logger.Errorf("s.HandleMessage %s error: %v\n", msg.Topic(), err)
+	}
+}
+
+// HandleMessage parses a payload received on topic and writes an observation for every city it completes
+func (s *Subscriber) HandleMessage(ctx context.Context, topic string, payload []byte) error {
+	matched := false
+	for _, t := range s.config.Topics {
+		if !topicMatches(t.Topic, topic) {
+			continue
+		}
+		matched = true
+
+		values, err := parsePayload(t, payload)
+		if err != nil {
+			return err
+		}
+
+		weatherData := s.update(t.City, values)
+		if weatherData == nil {
+			continue
+		}
+
+		_, err = s.store.InsertWeatherData(ctx, weatherData)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !matched {
+		return fmt.Errorf("no mapping for topic %s", topic)
+	}
+	return nil
+}
+
+// update records new values for a city and returns the combined observation if it is complete
+func (s *Subscriber) update(city string, values map[string]float64) *storage.WeatherData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, exist := s.readings[city]
+	if !exist {
+		r = &reading{}
+		s.readings[city] = r
+	}
+	for field, value := range values {
+		v := value
+		switch field {
+		case FieldTemperature:
+			r.temperature = &v
+		case FieldWindSpeed:
+			r.windSpeed = &v
+		}
+	}
+
+	if r.temperature == nil || r.windSpeed == nil {
+		return nil
+	}
+
+	return &storage.WeatherData{
+		DataSource:  s.config.DataSource,
+		City:        city,
+		Temperature: int(math.Round(*r.temperature)),
+		WindSpeed:   int(math.Round(*r.windSpeed)),
+		UpdatedDate: time.Now().UTC(),
+	}
+}
+
+// parsePayload extracts field values from a payload according to the topic mapping
+func parsePayload(t TopicMapping, payload []byte) (map[string]float64, error) {
+	values := map[string]float64{}
+
+	switch t.Format {
+	case FormatJSON:
+		var doc interface{}
+		err := json.Unmarshal(payload, &doc)
+		if err != nil {
+			return nil, err
+		}
+		for field, key := range t.Fields {
+			value, err := lookupNumber(doc, key)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+			values[field] = value
+		}
+	default:
+		value, err := strconv.ParseFloat(strings.TrimSpace(string(payload)), 64)
+		if err != nil {
+			return nil, err
+		}
+		values[t.Field] = value
+	}
+
+	for field, value := range values {
+		if scale, exist := t.Scale[field]; exist {
+			values[field] = value * scale
+		}
+	}
+
+	return values, nil
+}
+
+// lookupNumber walks a dotted key path (e.g. readings.temp) through a decoded json document
+func lookupNumber(doc interface{}, key string) (float64, error) {
+	current := doc
+	for _, part := range strings.Split(key, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return 0, fmt.Errorf("not an object at %q", part)
+		}
+		current, ok = obj[part]
+		if !ok {
+			return 0, fmt.Errorf("missing %q", part)
+		}
+	}
+
+	switch v := current.(type) {
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	}
+	return 0, fmt.Errorf("not a number: %v", current)
+}
+
+// topicMatches reports whether topic matches an mqtt subscription filter containing + and # wildcards
+func topicMatches(filter, topic string) bool {
+	filterParts := strings.Split(filter, "/")
+	topicParts := strings.Split(topic, "/")
+
+	for i, part := range filterParts {
+		if part == "#" {
+			return true
+		}
+		if i >= len(topicParts) {
+			return false
+		}
+		if part != "+" && part != topicParts[i] {
+			return false
+		}
+	}
+
+	return len(filterParts) == len(topicParts)
+}
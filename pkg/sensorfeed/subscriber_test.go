@@ -0,0 +1,202 @@
+package sensorfeed_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/sensorfeed"
+	"github.com/TomSED/weather-api/pkg/storage"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStore struct {
+	mu   sync.Mutex
+	rows []*storage.WeatherData
+}
+
+func (f *fakeStore) InsertWeatherData(ctx context.Context, weatherData *storage.WeatherData) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rows = append(f.rows, weatherData)
+	return true, nil
+}
+
+func (f *fakeStore) Rows() []*storage.WeatherData {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*storage.WeatherData{}, f.rows...)
+}
+
+func testConfig(broker string) *sensorfeed.Config {
+	return &sensorfeed.Config{
+		Broker: broker,
+		Topics: []sensorfeed.TopicMapping{
+			{
+				Topic: "sites/sydney/temperature",
+				City:  "Sydney",
+				Field: sensorfeed.FieldTemperature,
+			},
+			{
+				Topic: "sites/sydney/+/anemometer",
+				City:  "Sydney",
+				Field: sensorfeed.FieldWindSpeed,
+				Scale: map[string]float64{sensorfeed.FieldWindSpeed: 3.6},
+			},
+			{
+				Topic:  "sites/melbourne/#",
+				City:   "Melbourne",
+				Format: sensorfeed.FormatJSON,
+				Fields: map[string]string{
+					sensorfeed.FieldTemperature: "readings.temp_c",
+					sensorfeed.FieldWindSpeed:   "readings.wind_kmh",
+				},
+			},
+		},
+	}
+}
+
+func TestHandleMessage(t *testing.T) {
+
+	t.Run("It should only write once both fields are known", func(t *testing.T) {
+		store := &fakeStore{}
+		subscriber, err := sensorfeed.NewSubscriber(testConfig("tcp://localhost:1883"), store)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+
+		err = subscriber.HandleMessage(context.Background(), "sites/sydney/temperature", []byte("14.6"))
+		assert.Nil(t, err)
+		assert.Len(t, store.Rows(), 0)
+
+		err = subscriber.HandleMessage(context.Background(), "sites/sydney/mast1/anemometer", []byte(" 5 "))
+		assert.Nil(t, err)
+		if !assert.Len(t, store.Rows(), 1) {
+			t.Fatal()
+		}
+		row := store.Rows()[0]
+		assert.Equal(t, "mqtt", row.DataSource)
+		assert.Equal(t, "Sydney", row.City)
+		assert.Equal(t, 15, row.Temperature)
+		assert.Equal(t, 18, row.WindSpeed)
+	})
+
+	t.Run("It should parse json payloads using the key paths", func(t *testing.T) {
+		store := &fakeStore{}
+		subscriber, err := sensorfeed.NewSubscriber(testConfig("tcp://localhost:1883"), store)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+
+		err = subscriber.HandleMessage(context.Background(), "sites/melbourne/station", []byte(`{"readings":{"temp_c":"11.2","wind_kmh":30}}`))
+		assert.Nil(t, err)
+		if !assert.Len(t, store.Rows(), 1) {
+			t.Fatal()
+		}
+		assert.Equal(t, "Melbourne", store.Rows()[0].City)
+		assert.Equal(t, 11, store.Rows()[0].Temperature)
+		assert.Equal(t, 30, store.Rows()[0].WindSpeed)
+	})
+
+	t.Run("It should reject bad payloads and unknown topics", func(t *testing.T) {
+		store := &fakeStore{}
+		subscriber, err := sensorfeed.NewSubscriber(testConfig("tcp://localhost:1883"), store)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+
+		assert.NotNil(t, subscriber.HandleMessage(context.Background(), "sites/sydney/temperature", []byte("warm")))
+		assert.NotNil(t, subscriber.HandleMessage(context.Background(), "sites/melbourne/station", []byte(`{"readings":{}}`)))
+		assert.NotNil(t, subscriber.HandleMessage(context.Background(), "sites/perth/temperature", []byte("20")))
+		assert.Len(t, store.Rows(), 0)
+	})
+
+	t.Run("It should reject invalid configs", func(t *testing.T) {
+		config := testConfig("tcp://localhost:1883")
+		config.Topics[0].Field = "humidity"
+		_, err := sensorfeed.NewSubscriber(config, &fakeStore{})
+		assert.NotNil(t, err)
+
+		_, err = sensorfeed.NewSubscriber(&sensorfeed.Config{Broker: "tcp://localhost:1883"}, &fakeStore{})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestRun(t *testing.T) {
+
+	t.Run("It should write observations published to the broker", func(t *testing.T) {
+		broker, err := newTestBroker()
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		defer broker.Close()
+
+		store := &fakeStore{}
+		subscriber, err := sensorfeed.NewSubscriber(testConfig(broker.URL()), store)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- subscriber.Run(ctx)
+		}()
+
+		// Wait for all three subscriptions before publishing
+		for i := 0; i < 3; i++ {
+			select {
+			case <-broker.subscribed:
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for subscriptions")
+			}
+		}
+
+		publisher := mqtt.NewClient(mqtt.NewClientOptions().AddBroker(broker.URL()).SetClientID("publisher"))
+		token := publisher.Connect()
+		if token.Wait() && token.Error() != nil {
+			t.Fatal(token.Error())
+		}
+		defer publisher.Disconnect(0)
+
+		publisher.Publish("sites/melbourne/station", 0, false, `{"readings":{"temp_c":12,"wind_kmh":20}}`).Wait()
+
+		assert.Eventually(t, func() bool {
+			return len(store.Rows()) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, "Melbourne", store.Rows()[0].City)
+
+		cancel()
+		assert.Nil(t, <-done)
+	})
+
+	t.Run("It should stop retrying to connect once ctx is done", func(t *testing.T) {
+		// Nothing listens on a closed listener's port
+		broker, err := newTestBroker()
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		broker.Close()
+
+		subscriber, err := sensorfeed.NewSubscriber(testConfig(broker.URL()), &fakeStore{})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		done := make(chan error)
+		go func() {
+			done <- subscriber.Run(ctx)
+		}()
+
+		select {
+		case err := <-done:
+			assert.Nil(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run didn't return after ctx was done")
+		}
+	})
+}
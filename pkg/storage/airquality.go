@@ -0,0 +1,20 @@
+package storage
+
+import "time"
+
+// AirQuality is a provider's air pollution reading for a city, concentrations are in µg/m³
+type AirQuality struct {
+	DataSource string
+	City       string
+	Lat        float64
+	Lon        float64
+	// MeasuredAt is when the provider measured the concentrations, FetchedAt when they were fetched
+	MeasuredAt time.Time
+	FetchedAt  time.Time
+	PM25       float64
+	PM10       float64
+	O3         float64
+	NO2        float64
+	SO2        float64
+	CO         float64
+}
@@ -0,0 +1,54 @@
+package storage
+
+import "time"
+
+// Alert severities and urgencies, as in the Common Alerting Protocol
+const (
+	SeverityExtreme  = "extreme"
+	SeveritySevere   = "severe"
+	SeverityModerate = "moderate"
+	SeverityMinor    = "minor"
+	SeverityUnknown  = "unknown"
+
+	UrgencyImmediate = "immediate"
+	UrgencyExpected  = "expected"
+	UrgencyFuture    = "future"
+	UrgencyPast      = "past"
+	UrgencyUnknown   = "unknown"
+)
+
+// Alerts are the weather alerts in effect at a location when they were fetched
+type Alerts struct {
+	// Key identifies the location, e.g. by city or rounded coordinates
+	Key       string
+	Lat       float64
+	Lon       float64
+	FetchedAt time.Time
+	Alerts    []Alert
+}
+
+// Alert is an official weather warning, normalized across providers
+type Alert struct {
+	// Sources are the providers that reported the alert
+	Sources     []string  `json:"sources"`
+	Sender      string    `json:"sender"`
+	Event       string    `json:"event"`
+	Headline    string    `json:"headline"`
+	Description string    `json:"description"`
+	Severity    string    `json:"severity"`
+	Urgency     string    `json:"urgency"`
+	Start       time.Time `json:"start"`
+	// End is zero when the alert is in effect until further notice
+	End time.Time `json:"end"`
+}
+
+// CopyAlerts returns a copy of alerts that shares no alerts with it
+func CopyAlerts(alerts *Alerts) *Alerts {
+	out := *alerts
+	out.Alerts = make([]Alert, len(alerts.Alerts))
+	for i, alert := range alerts.Alerts {
+		alert.Sources = append([]string(nil), alert.Sources...)
+		out.Alerts[i] = alert
+	}
+	return &out
+}
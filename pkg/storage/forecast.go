@@ -0,0 +1,35 @@
+package storage
+
+import "time"
+
+// Forecast is a provider's forecast for a city, normalized to celsius and km/h
+type Forecast struct {
+	DataSource string
+	City       string
+	// FetchedAt is when the forecast was fetched
+	FetchedAt time.Time
+	// Hourly are the provider's forecast steps (e.g. every 3 hours), Daily one per local day, both in time order
+	Hourly []ForecastPeriod
+	Daily  []ForecastPeriod
+}
+
+// ForecastPeriod is the forecast weather from Time until the next period
+type ForecastPeriod struct {
+	// Time is when the period starts, daily periods start at local midnight
+	Time           time.Time `json:"time"`
+	Temperature    int       `json:"temperature"`
+	TemperatureMin int       `json:"temperature_min"`
+	TemperatureMax int       `json:"temperature_max"`
+	WindSpeed      int       `json:"wind_speed"`
+	// PrecipitationChance is the probability of precipitation as a percentage
+	PrecipitationChance int    `json:"precipitation_chance"`
+	Description         string `json:"description"`
+}
+
+// CopyForecast returns a copy of forecast that shares no periods with it
+func CopyForecast(forecast *Forecast) *Forecast {
+	out := *forecast
+	out.Hourly = append([]ForecastPeriod(nil), forecast.Hourly...)
+	out.Daily = append([]ForecastPeriod(nil), forecast.Daily...)
+	return &out
+}
@@ -0,0 +1,18 @@
+package storage
+
+import "time"
+
+// Location is where a city geocodes to. Cities don't move, so locations are kept until replaced.
+type Location struct {
+	// City is the lower cased city queried
+	City    string
+	Name    string
+	Country string
+	Lat     float64
+	Lon     float64
+	// Timezone is the IANA timezone, e.g. "Australia/Sydney", or a fixed offset like "UTC+10:00" when a provider only
+	// reported that. Empty if unknown.
+	Timezone string
+	// GeocodedAt is when the city was geocoded
+	GeocodedAt time.Time
+}
@@ -0,0 +1,298 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+)
+
+// Store is an in-memory storage.Store for tests and local development.
+// Nothing is persisted and pruned rows are not rolled up.
+type Store struct {
+	mu      sync.Mutex
+	history []*storage.WeatherData
+	// observed holds the history's city, source and observation time keys
+	observed  map[string]bool
+	latest    map[string]*storage.WeatherData
+	usage     map[string]int
+	forecasts map[string]*storage.Forecast
+	alerts    map[string]*storage.Alerts
+	air       map[string]*storage.AirQuality
+	locations map[string]*storage.Location
+}
+
+var _ storage.Store = &Store{}
+
+// NewStore creates an empty Store
+func NewStore() *Store {
+	return &Store{
+		observed:  map[string]bool{},
+		latest:    map[string]*storage.WeatherData{},
+		usage:     map[string]int{},
+		forecasts: map[string]*storage.Forecast{},
+		alerts:    map[string]*storage.Alerts{},
+		air:       map[string]*storage.AirQuality{},
+		locations: map[string]*storage.Location{},
+	}
+}
+
+// InsertWeatherData adds an observation to the history and updates the latest row for its city and source
+func (s *Store) InsertWeatherData(ctx context.Context, weatherData *storage.WeatherData) (bool, error) {
+	inserted, err := s.InsertWeatherDataBatch(ctx, []*storage.WeatherData{weatherData})
+	return inserted == 1, err
+}
+
+// InsertWeatherDataBatch inserts the observations in order
+func (s *Store) InsertWeatherDataBatch(ctx context.Context, weatherData []*storage.WeatherData) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inserted := 0
+	for _, data := range weatherData {
+		if s.insert(data) {
+			inserted++
+		}
+	}
+	return inserted, nil
+}
+
+func (s *Store) insert(weatherData *storage.WeatherData) bool {
+	row := copyWeatherData(weatherData)
+
+	key := weatherData.City + "\x00" + weatherData.DataSource
+	if current, exist := s.latest[key]; !exist || !current.NewerThan(row) {
+		s.latest[key] = row
+	}
+
+	observedKey := observationKey(row)
+	if s.observed[observedKey] {
+		return false
+	}
+	s.observed[observedKey] = true
+	s.history = append(s.history, row)
+	return true
+}
+
+func observationKey(weatherData *storage.WeatherData) string {
+	return weatherData.City + "\x00" + weatherData.DataSource + "\x00" + weatherData.ObservationTime().UTC().String()
+}
+
+// GetLatestWeatherData returns the city's most recent observation across sources, nil if there is none
+func (s *Store) GetLatestWeatherData(ctx context.Context, city string) (*storage.WeatherData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.latestFor(city), nil
+}
+
+// GetLatestWeatherDataBatch returns each city's most recent observation across sources, keyed by city
+func (s *Store) GetLatestWeatherDataBatch(ctx context.Context, cities []string) (map[string]*storage.WeatherData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := map[string]*storage.WeatherData{}
+	for _, city := range cities {
+		if data := s.latestFor(city); data != nil {
+			out[city] = data
+		}
+	}
+	return out, nil
+}
+
+// latestFor returns a copy of the city's most recent latest row, nil if there is none
+func (s *Store) latestFor(city string) *storage.WeatherData {
+	var out *storage.WeatherData
+	for _, row := range s.latest {
+		if row.City != city {
+			continue
+		}
+		if out == nil || row.NewerThan(out) {
+			out = row
+		}
+	}
+
+	if out == nil {
+		return nil
+	}
+	return copyWeatherData(out)
+}
+
+// ReserveProviderCall counts a call to provider for the billing period unless it has reached limit (limit <= 0 is unlimited)
+func (s *Store) ReserveProviderCall(ctx context.Context, provider string, period string, limit int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := provider + "\x00" + period
+	if limit > 0 && s.usage[key] >= limit {
+		return false, nil
+	}
+	s.usage[key]++
+	return true, nil
+}
+
+// ReleaseProviderCall gives back a call reserved by ReserveProviderCall that wasn't made
+func (s *Store) ReleaseProviderCall(ctx context.Context, provider string, period string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := provider + "\x00" + period
+	if s.usage[key] > 0 {
+		s.usage[key]--
+	}
+	return nil
+}
+
+// GetProviderUsage returns the number of calls made to provider in the billing period
+func (s *Store) GetProviderUsage(ctx context.Context, provider string, period string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.usage[provider+"\x00"+period], nil
+}
+
+// PruneWeatherData deletes history observed before the policy's retention
+func (s *Store) PruneWeatherData(ctx context.Context, policy storage.RetentionPolicy) (*storage.PruneResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := &storage.PruneResult{
+		Cutoff:  time.Now().UTC().Add(-policy.RawRetention),
+		Batches: 1,
+	}
+
+	kept := []*storage.WeatherData{}
+	for _, row := range s.history {
+		if !row.ObservationTime().Before(result.Cutoff) {
+			kept = append(kept, row)
+			continue
+		}
+
+		delete(s.observed, observationKey(row))
+		result.Pruned++
+	}
+	s.history = kept
+
+	return result, nil
+}
+
+// InsertForecast stores the city's forecast unless a later one is stored
+func (s *Store) InsertForecast(ctx context.Context, forecast *storage.Forecast) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, exist := s.forecasts[forecast.City]; exist && current.FetchedAt.After(forecast.FetchedAt) {
+		return nil
+	}
+	s.forecasts[forecast.City] = storage.CopyForecast(forecast)
+	return nil
+}
+
+// GetForecast returns the city's stored forecast, nil if there is none
+func (s *Store) GetForecast(ctx context.Context, city string) (*storage.Forecast, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	forecast, exist := s.forecasts[city]
+	if !exist {
+		return nil, nil
+	}
+	return storage.CopyForecast(forecast), nil
+}
+
+// InsertAlerts stores the location's alerts unless later ones are stored
+func (s *Store) InsertAlerts(ctx context.Context, alerts *storage.Alerts) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, exist := s.alerts[alerts.Key]; exist && current.FetchedAt.After(alerts.FetchedAt) {
+		return nil
+	}
+	s.alerts[alerts.Key] = storage.CopyAlerts(alerts)
+	return nil
+}
+
+// GetAlerts returns the location's stored alerts, nil if there are none
+func (s *Store) GetAlerts(ctx context.Context, key string) (*storage.Alerts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alerts, exist := s.alerts[key]
+	if !exist {
+		return nil, nil
+	}
+	return storage.CopyAlerts(alerts), nil
+}
+
+// InsertAirQuality stores the city's air quality unless a later reading is stored
+func (s *Store) InsertAirQuality(ctx context.Context, airQuality *storage.AirQuality) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, exist := s.air[airQuality.City]; exist && current.FetchedAt.After(airQuality.FetchedAt) {
+		return nil
+	}
+	out := *airQuality
+	s.air[airQuality.City] = &out
+	return nil
+}
+
+// GetAirQuality returns the city's stored air quality, nil if there is none
+func (s *Store) GetAirQuality(ctx context.Context, city string) (*storage.AirQuality, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	airQuality, exist := s.air[city]
+	if !exist {
+		return nil, nil
+	}
+	out := *airQuality
+	return &out, nil
+}
+
+// InsertLocation stores where the city geocodes to
+func (s *Store) InsertLocation(ctx context.Context, location *storage.Location) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := *location
+	s.locations[location.City] = &out
+	return nil
+}
+
+// GetLocation returns the city's stored location, nil if there is none
+func (s *Store) GetLocation(ctx context.Context, city string) (*storage.Location, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	location, exist := s.locations[city]
+	if !exist {
+		return nil, nil
+	}
+	out := *location
+	return &out, nil
+}
+
+// GetLocationBatch returns the cities' stored locations keyed by city, cities without one are left out
+func (s *Store) GetLocationBatch(ctx context.Context, cities []string) (map[string]*storage.Location, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := map[string]*storage.Location{}
+	for _, city := range cities {
+		if location, exist := s.locations[city]; exist {
+			copied := *location
+			out[city] = &copied
+		}
+	}
+	return out, nil
+}
+
+func copyWeatherData(weatherData *storage.WeatherData) *storage.WeatherData {
+	out := *weatherData
+	if weatherData.Sources != nil {
+		out.Sources = append([]string{}, weatherData.Sources...)
+	}
+	return &out
+}
@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/TomSED/weather-api/pkg/storage/memory"
+	"github.com/TomSED/weather-api/pkg/storage/storagetest"
+)
+
+func TestStore(t *testing.T) {
+	storagetest.TestStore(t, func(t *testing.T) storage.Store {
+		return memory.NewStore()
+	})
+}
@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+)
+
+// InsertAirQuality replaces the city's air quality unless a later reading is stored
+func (c *Client) InsertAirQuality(ctx context.Context, airQuality *storage.AirQuality) error {
+	query := `INSERT INTO air_quality (city, datasource, lat, lon, measured_at, fetched_at, pm2_5, pm10, o3, no2, so2, co)
+			VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12)
+			ON CONFLICT (city) DO UPDATE SET
+				datasource = excluded.datasource,
+				lat = excluded.lat,
+				lon = excluded.lon,
+				measured_at = excluded.measured_at,
+				fetched_at = excluded.fetched_at,
+				pm2_5 = excluded.pm2_5,
+				pm10 = excluded.pm10,
+				o3 = excluded.o3,
+				no2 = excluded.no2,
+				so2 = excluded.so2,
+				co = excluded.co
+			WHERE fetched_at <= excluded.fetched_at;`
+
+	_, err := c.database.ExecContext(ctx, query, airQuality.City, airQuality.DataSource, airQuality.Lat, airQuality.Lon,
+		formatTime(airQuality.MeasuredAt), formatTime(airQuality.FetchedAt),
+		airQuality.PM25, airQuality.PM10, airQuality.O3, airQuality.NO2, airQuality.SO2, airQuality.CO)
+	return err
+}
+
+// GetAirQuality returns the city's stored air quality, nil if there is none
+func (c *Client) GetAirQuality(ctx context.Context, city string) (*storage.AirQuality, error) {
+	query := `SELECT datasource, city, lat, lon, measured_at, fetched_at, pm2_5, pm10, o3, no2, so2, co FROM air_quality WHERE city = ?1;`
+
+	out := &storage.AirQuality{}
+	measuredAt := ""
+	fetchedAt := ""
+	err := c.database.QueryRowContext(ctx, query, city).Scan(&out.DataSource, &out.City, &out.Lat, &out.Lon, &measuredAt, &fetchedAt,
+		&out.PM25, &out.PM10, &out.O3, &out.NO2, &out.SO2, &out.CO)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	out.MeasuredAt, err = time.Parse(timeFormat, measuredAt)
+	if err != nil {
+		return nil, err
+	}
+	out.FetchedAt, err = time.Parse(timeFormat, fetchedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,57 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+)
+
+// InsertAlerts replaces the location's alerts unless later ones are stored
+func (c *Client) InsertAlerts(ctx context.Context, alerts *storage.Alerts) error {
+	query := `INSERT INTO alerts (location_key, lat, lon, fetched_at, alerts)
+			VALUES (?1, ?2, ?3, ?4, ?5)
+			ON CONFLICT (location_key) DO UPDATE SET
+				lat = excluded.lat,
+				lon = excluded.lon,
+				fetched_at = excluded.fetched_at,
+				alerts = excluded.alerts
+			WHERE fetched_at <= excluded.fetched_at;`
+
+	byt, err := json.Marshal(alerts.Alerts)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.database.ExecContext(ctx, query, alerts.Key, alerts.Lat, alerts.Lon, formatTime(alerts.FetchedAt), string(byt))
+	return err
+}
+
+// GetAlerts returns the location's stored alerts, nil if there are none
+func (c *Client) GetAlerts(ctx context.Context, key string) (*storage.Alerts, error) {
+	query := `SELECT location_key, lat, lon, fetched_at, alerts FROM alerts WHERE location_key = ?1;`
+
+	out := &storage.Alerts{}
+	fetchedAt := ""
+	alerts := ""
+	err := c.database.QueryRowContext(ctx, query, key).Scan(&out.Key, &out.Lat, &out.Lon, &fetchedAt, &alerts)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	out.FetchedAt, err = time.Parse(timeFormat, fetchedAt)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(alerts), &out.Alerts)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
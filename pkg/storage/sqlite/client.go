@@ -0,0 +1,103 @@
+package sqlite
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	_ "modernc.org/sqlite"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Client is a storage.Store backed by a SQLite database file, for single node deployments
+type Client struct {
+	database *sql.DB
+}
+
+var _ storage.Store = &Client{}
+
+// NewClient opens (or creates) the database at path and applies any pending migrations.
+// ":memory:" opens a throwaway in-memory database.
+func NewClient(dbPath string) (*Client, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite has a single writer, and every connection to ":memory:" would be a separate database
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`PRAGMA busy_timeout = 5000; PRAGMA journal_mode = WAL;`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	c := &Client{database: db}
+	err = c.migrate()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Close closes the database
+func (c *Client) Close() error {
+	return c.database.Close()
+}
+
+// migrate applies the embedded migrations newer than the database's user_version, in order
+func (c *Client) migrate() error {
+	version := 0
+	err := c.database.QueryRow(`PRAGMA user_version;`).Scan(&version)
+	if err != nil {
+		return err
+	}
+
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		if i+1 <= version {
+			continue
+		}
+
+		byt, err := migrationFiles.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return err
+		}
+
+		tx, err := c.database.Begin()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(string(byt))
+		if err == nil {
+			_, err = tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d;`, i+1))
+		}
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %s: %v", name, err)
+		}
+		err = tx.Commit()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
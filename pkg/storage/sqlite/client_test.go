@@ -0,0 +1,45 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/TomSED/weather-api/pkg/storage/sqlite"
+	"github.com/TomSED/weather-api/pkg/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient(t *testing.T) {
+	storagetest.TestStore(t, func(t *testing.T) storage.Store {
+		client, err := sqlite.NewClient(filepath.Join(t.TempDir(), "weather.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { client.Close() })
+		return client
+	})
+
+	t.Run("Should keep data and skip applied migrations when reopened", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "weather.db")
+
+		client, err := sqlite.NewClient(path)
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		ok, err := client.ReserveProviderCall(context.Background(), "weatherstack", "2021-05", 0)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		assert.Nil(t, client.Close())
+
+		client, err = sqlite.NewClient(path)
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		defer client.Close()
+		calls, err := client.GetProviderUsage(context.Background(), "weatherstack", "2021-05")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, calls)
+	})
+}
@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+)
+
+// InsertForecast replaces the city's forecast unless a later one is stored
+func (c *Client) InsertForecast(ctx context.Context, forecast *storage.Forecast) error {
+	query := `INSERT INTO forecast (city, datasource, fetched_at, hourly, daily)
+			VALUES (?1, ?2, ?3, ?4, ?5)
+			ON CONFLICT (city) DO UPDATE SET
+				datasource = excluded.datasource,
+				fetched_at = excluded.fetched_at,
+				hourly = excluded.hourly,
+				daily = excluded.daily
+			WHERE fetched_at <= excluded.fetched_at;`
+
+	hourly, err := json.Marshal(forecast.Hourly)
+	if err != nil {
+		return err
+	}
+	daily, err := json.Marshal(forecast.Daily)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.database.ExecContext(ctx, query, forecast.City, forecast.DataSource, formatTime(forecast.FetchedAt), string(hourly), string(daily))
+	return err
+}
+
+// GetForecast returns the city's stored forecast, nil if there is none
+func (c *Client) GetForecast(ctx context.Context, city string) (*storage.Forecast, error) {
+	query := `SELECT datasource, city, fetched_at, hourly, daily FROM forecast WHERE city = ?1;`
+
+	out := &storage.Forecast{}
+	fetchedAt := ""
+	hourly := ""
+	daily := ""
+	err := c.database.QueryRowContext(ctx, query, city).Scan(&out.DataSource, &out.City, &fetchedAt, &hourly, &daily)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	out.FetchedAt, err = time.Parse(timeFormat, fetchedAt)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(hourly), &out.Hourly)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(daily), &out.Daily)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+)
+
+// InsertLocation replaces where the city geocodes to
+func (c *Client) InsertLocation(ctx context.Context, location *storage.Location) error {
+	query := `INSERT INTO locations (city, name, country, lat, lon, timezone, geocoded_at)
+			VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)
+			ON CONFLICT (city) DO UPDATE SET
+				name = excluded.name,
+				country = excluded.country,
+				lat = excluded.lat,
+				lon = excluded.lon,
+				timezone = excluded.timezone,
+				geocoded_at = excluded.geocoded_at;`
+
+	_, err := c.database.ExecContext(ctx, query, location.City, location.Name, location.Country, location.Lat, location.Lon, location.Timezone, formatTime(location.GeocodedAt))
+	return err
+}
+
+// GetLocation returns the city's stored location, nil if there is none
+func (c *Client) GetLocation(ctx context.Context, city string) (*storage.Location, error) {
+	query := `SELECT city, name, country, lat, lon, timezone, geocoded_at FROM locations WHERE city = ?1;`
+
+	out := &storage.Location{}
+	geocodedAt := ""
+	err := c.database.QueryRowContext(ctx, query, city).Scan(&out.City, &out.Name, &out.Country, &out.Lat, &out.Lon, &out.Timezone, &geocodedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	out.GeocodedAt, err = time.Parse(timeFormat, geocodedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// GetLocationBatch returns the cities' stored locations in one query keyed by city, cities without one are left out
+func (c *Client) GetLocationBatch(ctx context.Context, cities []string) (map[string]*storage.Location, error) {
+	out := map[string]*storage.Location{}
+	if len(cities) == 0 {
+		return out, nil
+	}
+
+	placeholders := make([]string, len(cities))
+	args := make([]interface{}, len(cities))
+	for i, city := range cities {
+		placeholders[i] = "?"
+		args[i] = city
+	}
+	query := `SELECT city, name, country, lat, lon, timezone, geocoded_at
+			FROM locations
+			WHERE city IN (` + strings.Join(placeholders, ", ") + `);`
+
+	rows, err := c.database.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		location := &storage.Location{}
+		geocodedAt := ""
+		err = rows.Scan(&location.City, &location.Name, &location.Country, &location.Lat, &location.Lon, &location.Timezone, &geocodedAt)
+		if err != nil {
+			return nil, err
+		}
+		location.GeocodedAt, err = time.Parse(timeFormat, geocodedAt)
+		if err != nil {
+			return nil, err
+		}
+		out[location.City] = location
+	}
+
+	return out, rows.Err()
+}
@@ -0,0 +1,41 @@
+-- Times are stored as fixed width UTC text (2006-01-02T15:04:05.000000Z) so they sort and compare as strings
+CREATE TABLE IF NOT EXISTS weather (
+	datasource TEXT NOT NULL,
+	city TEXT NOT NULL,
+	temperature INTEGER NOT NULL,
+	windspeed INTEGER NOT NULL,
+	updateddate TEXT NOT NULL,
+	sources TEXT
+);
+CREATE INDEX IF NOT EXISTS weather_city_updateddate_idx ON weather (city, updateddate);
+CREATE INDEX IF NOT EXISTS weather_updateddate_idx ON weather (updateddate);
+
+CREATE TABLE IF NOT EXISTS weather_latest (
+	city TEXT NOT NULL,
+	datasource TEXT NOT NULL,
+	temperature INTEGER NOT NULL,
+	windspeed INTEGER NOT NULL,
+	updateddate TEXT NOT NULL,
+	sources TEXT,
+	PRIMARY KEY (city, datasource)
+);
+
+CREATE TABLE IF NOT EXISTS weather_daily (
+	city TEXT NOT NULL,
+	datasource TEXT NOT NULL,
+	day TEXT NOT NULL,
+	samples INTEGER NOT NULL,
+	temperature_min INTEGER NOT NULL,
+	temperature_max INTEGER NOT NULL,
+	temperature_sum INTEGER NOT NULL,
+	windspeed_max INTEGER NOT NULL,
+	windspeed_sum INTEGER NOT NULL,
+	PRIMARY KEY (city, datasource, day)
+);
+
+CREATE TABLE IF NOT EXISTS provider_usage (
+	provider TEXT NOT NULL,
+	period TEXT NOT NULL,
+	calls INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (provider, period)
+);
@@ -0,0 +1,11 @@
+-- When the provider observed the weather, existing rows fall back to when they were fetched
+ALTER TABLE weather ADD COLUMN observed_at TEXT NOT NULL DEFAULT '';
+UPDATE weather SET observed_at = updateddate WHERE observed_at = '';
+ALTER TABLE weather_latest ADD COLUMN observed_at TEXT NOT NULL DEFAULT '';
+UPDATE weather_latest SET observed_at = updateddate WHERE observed_at = '';
+
+-- Each observation is stored once
+DELETE FROM weather WHERE rowid NOT IN (
+	SELECT min(rowid) FROM weather GROUP BY city, datasource, observed_at
+);
+CREATE UNIQUE INDEX IF NOT EXISTS weather_city_datasource_observed_at_idx ON weather (city, datasource, observed_at);
@@ -0,0 +1,8 @@
+-- Latest forecast per city, periods are JSON arrays of storage.ForecastPeriod
+CREATE TABLE IF NOT EXISTS forecast (
+	city TEXT NOT NULL PRIMARY KEY,
+	datasource TEXT NOT NULL,
+	fetched_at TEXT NOT NULL,
+	hourly TEXT NOT NULL,
+	daily TEXT NOT NULL
+);
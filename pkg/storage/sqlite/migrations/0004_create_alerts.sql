@@ -0,0 +1,8 @@
+-- Latest alerts per location, alerts is a JSON array of storage.Alert
+CREATE TABLE IF NOT EXISTS alerts (
+	location_key TEXT NOT NULL PRIMARY KEY,
+	lat REAL NOT NULL,
+	lon REAL NOT NULL,
+	fetched_at TEXT NOT NULL,
+	alerts TEXT NOT NULL
+);
@@ -0,0 +1,15 @@
+-- Latest air quality per city, concentrations in µg/m³
+CREATE TABLE IF NOT EXISTS air_quality (
+	city TEXT NOT NULL PRIMARY KEY,
+	datasource TEXT NOT NULL,
+	lat REAL NOT NULL,
+	lon REAL NOT NULL,
+	measured_at TEXT NOT NULL,
+	fetched_at TEXT NOT NULL,
+	pm2_5 REAL NOT NULL,
+	pm10 REAL NOT NULL,
+	o3 REAL NOT NULL,
+	no2 REAL NOT NULL,
+	so2 REAL NOT NULL,
+	co REAL NOT NULL
+);
@@ -0,0 +1,9 @@
+-- Where each city geocodes to
+CREATE TABLE IF NOT EXISTS locations (
+	city TEXT NOT NULL PRIMARY KEY,
+	name TEXT NOT NULL,
+	country TEXT NOT NULL,
+	lat REAL NOT NULL,
+	lon REAL NOT NULL,
+	geocoded_at TEXT NOT NULL
+);
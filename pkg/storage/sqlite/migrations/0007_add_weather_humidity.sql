@@ -0,0 +1,3 @@
+-- Relative humidity in percent, 0 on rows stored before it was
+ALTER TABLE weather ADD COLUMN humidity INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE weather_latest ADD COLUMN humidity INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+-- Canonical weather condition and whether it was observed at night, unknown on rows stored before they were
+ALTER TABLE weather ADD COLUMN condition TEXT NOT NULL DEFAULT '';
+ALTER TABLE weather ADD COLUMN night INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE weather_latest ADD COLUMN condition TEXT NOT NULL DEFAULT '';
+ALTER TABLE weather_latest ADD COLUMN night INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+-- The provider's description of the weather and its language
+ALTER TABLE weather ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE weather ADD COLUMN description_language TEXT NOT NULL DEFAULT '';
+ALTER TABLE weather_latest ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE weather_latest ADD COLUMN description_language TEXT NOT NULL DEFAULT '';
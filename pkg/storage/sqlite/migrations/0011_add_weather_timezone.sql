@@ -0,0 +1,3 @@
+-- The city's timezone when the weather was fetched, unknown on rows stored before it was
+ALTER TABLE weather ADD COLUMN timezone TEXT NOT NULL DEFAULT '';
+ALTER TABLE weather_latest ADD COLUMN timezone TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,313 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+)
+
+const (
+	timeFormat            = "2006-01-02T15:04:05.000000Z"
+	defaultPruneBatchSize = 5000
+)
+
+const (
+	// Observations already stored are skipped
+	insertWeatherQuery = `INSERT INTO weather (datasource, city, temperature, windspeed, updateddate, sources, observed_at, humidity, condition, night, description, description_language, timezone)
+			VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13)
+			ON CONFLICT (city, datasource, observed_at) DO NOTHING;`
+
+	// Older observations arriving late don't replace newer ones, the later fetch breaking ties
+	upsertLatestQuery = `INSERT INTO weather_latest (datasource, city, temperature, windspeed, updateddate, sources, observed_at, humidity, condition, night, description, description_language, timezone)
+			VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13)
+			ON CONFLICT (city, datasource) DO UPDATE SET
+				temperature = excluded.temperature,
+				windspeed = excluded.windspeed,
+				updateddate = excluded.updateddate,
+				sources = excluded.sources,
+				observed_at = excluded.observed_at,
+				humidity = excluded.humidity,
+				condition = excluded.condition,
+				night = excluded.night,
+				description = excluded.description,
+				description_language = excluded.description_language,
+				timezone = excluded.timezone
+			WHERE (observed_at, updateddate) <= (excluded.observed_at, excluded.updateddate);`
+)
+
+// InsertWeatherData adds an observation to the history and updates the latest row for its city and source
+func (c *Client) InsertWeatherData(ctx context.Context, weatherData *storage.WeatherData) (bool, error) {
+	inserted, err := c.InsertWeatherDataBatch(ctx, []*storage.WeatherData{weatherData})
+	return inserted == 1, err
+}
+
+// InsertWeatherDataBatch inserts the observations in one transaction, returning how many were new
+func (c *Client) InsertWeatherDataBatch(ctx context.Context, weatherData []*storage.WeatherData) (int, error) {
+	tx, err := c.database.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	insertStmt, err := tx.PrepareContext(ctx, insertWeatherQuery)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	upsertStmt, err := tx.PrepareContext(ctx, upsertLatestQuery)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	inserted := 0
+	for _, data := range weatherData {
+		sources, err := encodeSources(data.Sources)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		args := []interface{}{data.DataSource, data.City, data.Temperature, data.WindSpeed, formatTime(data.UpdatedDate), sources, formatTime(data.ObservationTime()), data.Humidity, data.Condition, data.Night, data.Description, data.DescriptionLanguage, data.Timezone}
+
+		res, err := insertStmt.ExecContext(ctx, args...)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		inserted += int(rows)
+
+		_, err = upsertStmt.ExecContext(ctx, args...)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
+
+// GetLatestWeatherData returns the city's most recent observation across sources, nil if there is none
+func (c *Client) GetLatestWeatherData(ctx context.Context, city string) (*storage.WeatherData, error) {
+	query := `SELECT ` + latestColumns + `
+			FROM weather_latest
+			WHERE city = ?1
+			ORDER BY observed_at DESC, updateddate DESC
+			LIMIT 1;`
+
+	out, err := scanWeatherData(c.database.QueryRowContext(ctx, query, city))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// GetLatestWeatherDataBatch returns each city's most recent observation across sources in one query, keyed by city
+func (c *Client) GetLatestWeatherDataBatch(ctx context.Context, cities []string) (map[string]*storage.WeatherData, error) {
+	out := map[string]*storage.WeatherData{}
+	if len(cities) == 0 {
+		return out, nil
+	}
+
+	placeholders := make([]string, len(cities))
+	args := make([]interface{}, len(cities))
+	for i, city := range cities {
+		placeholders[i] = "?"
+		args[i] = city
+	}
+	// Every source's latest row is read and the newest per city kept, a city only has a few
+	query := `SELECT ` + latestColumns + `
+			FROM weather_latest
+			WHERE city IN (` + strings.Join(placeholders, ", ") + `);`
+
+	rows, err := c.database.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		data, err := scanWeatherData(rows)
+		if err != nil {
+			return nil, err
+		}
+		if current, exist := out[data.City]; !exist || data.NewerThan(current) {
+			out[data.City] = data
+		}
+	}
+
+	return out, rows.Err()
+}
+
+// latestColumns are the weather_latest columns read by scanWeatherData
+const latestColumns = `datasource, city, temperature, windspeed, updateddate, sources, observed_at, humidity, condition, night, description, description_language, timezone`
+
+// scanWeatherData scans a row of latestColumns
+func scanWeatherData(row interface {
+	Scan(dest ...interface{}) error
+}) (*storage.WeatherData, error) {
+	out := &storage.WeatherData{}
+	updatedDate := ""
+	observedAt := ""
+	sources := sql.NullString{}
+	err := row.Scan(&out.DataSource, &out.City, &out.Temperature, &out.WindSpeed, &updatedDate, &sources, &observedAt, &out.Humidity, &out.Condition, &out.Night, &out.Description, &out.DescriptionLanguage, &out.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	out.UpdatedDate, err = time.Parse(timeFormat, updatedDate)
+	if err != nil {
+		return nil, err
+	}
+	out.ObservedAt, err = time.Parse(timeFormat, observedAt)
+	if err != nil {
+		return nil, err
+	}
+	if sources.Valid {
+		err = json.Unmarshal([]byte(sources.String), &out.Sources)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+// ReserveProviderCall counts a call to provider for the billing period unless it has reached limit (limit <= 0 is unlimited)
+func (c *Client) ReserveProviderCall(ctx context.Context, provider string, period string, limit int) (bool, error) {
+	query := `INSERT INTO provider_usage (provider, period, calls)
+			VALUES (?1, ?2, 1)
+			ON CONFLICT (provider, period) DO UPDATE SET calls = calls + 1
+			WHERE ?3 <= 0 OR calls < ?3
+			RETURNING calls;`
+
+	calls := 0
+	err := c.database.QueryRowContext(ctx, query, provider, period, limit).Scan(&calls)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ReleaseProviderCall gives back a call reserved by ReserveProviderCall that wasn't made
+func (c *Client) ReleaseProviderCall(ctx context.Context, provider string, period string) error {
+	query := `UPDATE provider_usage SET calls = calls - 1 WHERE provider = ?1 AND period = ?2 AND calls > 0;`
+
+	_, err := c.database.ExecContext(ctx, query, provider, period)
+	return err
+}
+
+// GetProviderUsage returns the number of calls made to provider in the billing period
+func (c *Client) GetProviderUsage(ctx context.Context, provider string, period string) (int, error) {
+	query := `SELECT calls FROM provider_usage WHERE provider = ?1 AND period = ?2;`
+
+	calls := 0
+	err := c.database.QueryRowContext(ctx, query, provider, period).Scan(&calls)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return calls, nil
+}
+
+// PruneWeatherData deletes history observed before the policy's retention in batches,
+// rolling each batch up into weather_daily by observation day in the same transaction when enabled
+func (c *Client) PruneWeatherData(ctx context.Context, policy storage.RetentionPolicy) (*storage.PruneResult, error) {
+	batch := `SELECT rowid FROM weather WHERE COALESCE(observed_at, updateddate) < ?1 ORDER BY rowid LIMIT ?2`
+
+	rollupQuery := `INSERT INTO weather_daily (city, datasource, day, samples, temperature_min, temperature_max, temperature_sum, windspeed_max, windspeed_sum)
+			SELECT city, datasource, substr(COALESCE(observed_at, updateddate), 1, 10), count(*), min(temperature), max(temperature), sum(temperature), max(windspeed), sum(windspeed)
+			FROM weather
+			WHERE rowid IN (` + batch + `)
+			GROUP BY city, datasource, substr(COALESCE(observed_at, updateddate), 1, 10)
+			ON CONFLICT (city, datasource, day) DO UPDATE SET
+				samples = samples + excluded.samples,
+				temperature_min = min(temperature_min, excluded.temperature_min),
+				temperature_max = max(temperature_max, excluded.temperature_max),
+				temperature_sum = temperature_sum + excluded.temperature_sum,
+				windspeed_max = max(windspeed_max, excluded.windspeed_max),
+				windspeed_sum = windspeed_sum + excluded.windspeed_sum;`
+
+	deleteQuery := `DELETE FROM weather WHERE rowid IN (` + batch + `);`
+
+	batchSize := policy.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultPruneBatchSize
+	}
+
+	result := &storage.PruneResult{
+		Cutoff: time.Now().UTC().Add(-policy.RawRetention),
+	}
+	cutoff := formatTime(result.Cutoff)
+	for {
+		tx, err := c.database.BeginTx(ctx, nil)
+		if err != nil {
+			return result, err
+		}
+
+		if policy.Rollup {
+			_, err = tx.ExecContext(ctx, rollupQuery, cutoff, batchSize)
+			if err != nil {
+				tx.Rollback()
+				return result, err
+			}
+		}
+
+		res, err := tx.ExecContext(ctx, deleteQuery, cutoff, batchSize)
+		if err != nil {
+			tx.Rollback()
+			return result, err
+		}
+		err = tx.Commit()
+		if err != nil {
+			return result, err
+		}
+
+		pruned, err := res.RowsAffected()
+		if err != nil {
+			return result, err
+		}
+		result.Pruned += int(pruned)
+		result.Batches++
+		if int(pruned) < batchSize {
+			return result, nil
+		}
+	}
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+func encodeSources(sources []string) (interface{}, error) {
+	if sources == nil {
+		return nil, nil
+	}
+	byt, err := json.Marshal(sources)
+	if err != nil {
+		return nil, err
+	}
+	return string(byt), nil
+}
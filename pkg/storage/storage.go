@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+//go:generate moq -pkg mocks -out ../../mocks/mock_store.go . Store
+
+// Store is the weather database, implemented by postgres, sqlite, dynamo and memory.
+// Methods stop waiting on the database when ctx is done, e.g. as the lambda deadline approaches.
+type Store interface {
+	// InsertWeatherData adds an observation to the history and updates the latest row for its city and source.
+	// History is unique per city, source and observation time, so storing an observation again only updates the latest
+	// row, returning false.
+	InsertWeatherData(ctx context.Context, weatherData *WeatherData) (bool, error)
+	// InsertWeatherDataBatch inserts the observations together, as if by InsertWeatherData in order, returning how many
+	// were new
+	InsertWeatherDataBatch(ctx context.Context, weatherData []*WeatherData) (int, error)
+	// GetLatestWeatherData returns the city's most recent observation across sources, nil if there is none
+	GetLatestWeatherData(ctx context.Context, city string) (*WeatherData, error)
+	// GetLatestWeatherDataBatch returns the latest observation of each city keyed by city, leaving out cities with none
+	GetLatestWeatherDataBatch(ctx context.Context, cities []string) (map[string]*WeatherData, error)
+	// ReserveProviderCall atomically counts a call to provider for the billing period,
+	// unless the period's calls have already reached limit (limit <= 0 is unlimited)
+	ReserveProviderCall(ctx context.Context, provider string, period string, limit int) (bool, error)
+	// ReleaseProviderCall gives back a call reserved by ReserveProviderCall that wasn't made
+	ReleaseProviderCall(ctx context.Context, provider string, period string) error
+	// GetProviderUsage returns the number of calls made to provider in the billing period
+	GetProviderUsage(ctx context.Context, provider string, period string) (int, error)
+	// PruneWeatherData deletes history observed before the policy's retention, optionally rolling it up into daily
+	// aggregates of its observation days
+	PruneWeatherData(ctx context.Context, policy RetentionPolicy) (*PruneResult, error)
+	// InsertForecast stores the city's forecast, unless a forecast fetched later is already stored
+	InsertForecast(ctx context.Context, forecast *Forecast) error
+	// GetForecast returns the city's stored forecast, nil if there is none
+	GetForecast(ctx context.Context, city string) (*Forecast, error)
+	// InsertAlerts stores the location's alerts, unless alerts fetched later are already stored
+	InsertAlerts(ctx context.Context, alerts *Alerts) error
+	// GetAlerts returns the stored alerts of the location key, nil if there are none
+	GetAlerts(ctx context.Context, key string) (*Alerts, error)
+	// InsertAirQuality stores the city's air quality, unless a reading fetched later is already stored
+	InsertAirQuality(ctx context.Context, airQuality *AirQuality) error
+	// GetAirQuality returns the city's stored air quality, nil if there is none
+	GetAirQuality(ctx context.Context, city string) (*AirQuality, error)
+	// InsertLocation stores where the city geocodes to, replacing any stored location
+	InsertLocation(ctx context.Context, location *Location) error
+	// GetLocation returns the city's stored location, nil if there is none
+	GetLocation(ctx context.Context, city string) (*Location, error)
+	// GetLocationBatch returns the cities' stored locations keyed by city, cities without one are left out
+	GetLocationBatch(ctx context.Context, cities []string) (map[string]*Location, error)
+}
+
+type WeatherData struct {
+	DataSource string
+	// Sources are the providers blended into a consensus row
+	Sources     []string
+	City        string
+	Temperature int
+	WindSpeed   int
+	// Humidity is the relative humidity in percent, 0 if unknown
+	Humidity int
+	// Condition is the canonical conditions.Condition, empty if unknown
+	Condition string
+	// Night is whether it was observed between sunset and sunrise
+	Night bool
+	// Description is the provider's description of the weather, in DescriptionLanguage (an ISO 639-1 code)
+	Description         string
+	DescriptionLanguage string
+	// Timezone is the city's stored timezone when the data was fetched, empty if it wasn't known
+	Timezone string
+	// UpdatedDate is when the data was fetched
+	UpdatedDate time.Time
+	// ObservedAt is when the provider observed the weather, zero if unknown
+	ObservedAt time.Time
+}
+
+// ObservationTime is the time the observation is stored under, ObservedAt falling back to UpdatedDate
+func (d *WeatherData) ObservationTime() time.Time {
+	if d.ObservedAt.IsZero() {
+		return d.UpdatedDate
+	}
+	return d.ObservedAt
+}
+
+// NewerThan reports whether the observation is newer than other's, the later fetch breaking ties
+func (d *WeatherData) NewerThan(other *WeatherData) bool {
+	observed, otherObserved := d.ObservationTime(), other.ObservationTime()
+	if !observed.Equal(otherObserved) {
+		return observed.After(otherObserved)
+	}
+	return d.UpdatedDate.After(other.UpdatedDate)
+}
+
+// RetentionPolicy controls how long raw observations are kept
+type RetentionPolicy struct {
+	// RawRetention is how long rows are kept in the weather history
+	RawRetention time.Duration
+	// Rollup keeps daily aggregates of pruned rows
+	Rollup bool
+	// BatchSize is the number of rows pruned per statement, keeping locks short
+	BatchSize int
+}
+
+// PruneResult reports what a prune run did
+type PruneResult struct {
+	Cutoff  time.Time
+	Pruned  int
+	Batches int
+}
+
+// ErrUnavailable is returned (wrapped) by stores that are skipping their database after failing to reach it.
+// Callers should carry on without the store, e.g. serving uncached weather.
+var ErrUnavailable = errors.New("store unavailable")
@@ -0,0 +1,640 @@
+// Package storagetest is the conformance suite every storage.Store implementation must pass
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStore runs the conformance suite, calling newStore for an empty store in each subtest
+func TestStore(t *testing.T, newStore func(t *testing.T) storage.Store) {
+
+	// Backends store times to different precisions, so test data sticks to whole seconds
+	now := time.Now().UTC().Truncate(time.Second)
+	ctx := context.Background()
+
+	t.Run("Should return nil for a city with no data", func(t *testing.T) {
+		store := newStore(t)
+
+		data, err := store.GetLatestWeatherData(ctx, "sydney")
+		assert.Nil(t, err)
+		assert.Nil(t, data)
+	})
+
+	t.Run("Should return the most recent row for the city across sources", func(t *testing.T) {
+		store := newStore(t)
+
+		rows := []*storage.WeatherData{
+			{DataSource: "weatherstack", City: "sydney", Temperature: 10, WindSpeed: 1, UpdatedDate: now.Add(-2 * time.Minute)},
+			{DataSource: "openweathermap", City: "sydney", Temperature: 11, WindSpeed: 2, UpdatedDate: now.Add(-time.Minute)},
+			{DataSource: "weatherstack", City: "melbourne", Temperature: 12, WindSpeed: 3, UpdatedDate: now},
+		}
+		for _, row := range rows {
+			insertWeatherData(t, store, row)
+		}
+
+		data, err := store.GetLatestWeatherData(ctx, "sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assertWeatherData(t, rows[1], data)
+
+		data, err = store.GetLatestWeatherData(ctx, "melbourne")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assertWeatherData(t, rows[2], data)
+	})
+
+	t.Run("Should return the most recent row of each city in a batch", func(t *testing.T) {
+		store := newStore(t)
+
+		rows := []*storage.WeatherData{
+			{DataSource: "weatherstack", City: "sydney", Temperature: 10, WindSpeed: 1, UpdatedDate: now.Add(-2 * time.Minute)},
+			{DataSource: "openweathermap", City: "sydney", Temperature: 11, WindSpeed: 2, UpdatedDate: now.Add(-time.Minute)},
+			{DataSource: "weatherstack", City: "melbourne", Temperature: 12, WindSpeed: 3, UpdatedDate: now},
+			{DataSource: "weatherstack", City: "perth", Temperature: 13, WindSpeed: 4, UpdatedDate: now},
+		}
+		for _, row := range rows {
+			insertWeatherData(t, store, row)
+		}
+
+		data, err := store.GetLatestWeatherDataBatch(ctx, []string{"sydney", "melbourne", "hobart"})
+		if !assert.Nil(t, err) || !assert.Len(t, data, 2) {
+			t.FailNow()
+		}
+		assertWeatherData(t, rows[1], data["sydney"])
+		assertWeatherData(t, rows[2], data["melbourne"])
+
+		data, err = store.GetLatestWeatherDataBatch(ctx, []string{})
+		assert.Nil(t, err)
+		assert.Empty(t, data)
+	})
+
+	t.Run("Should not replace the latest row with an older observation", func(t *testing.T) {
+		store := newStore(t)
+
+		newer := &storage.WeatherData{DataSource: "weatherstack", City: "sydney", Temperature: 20, WindSpeed: 5, UpdatedDate: now}
+		older := &storage.WeatherData{DataSource: "weatherstack", City: "sydney", Temperature: 15, WindSpeed: 4, UpdatedDate: now.Add(-time.Hour)}
+		insertWeatherData(t, store, newer)
+		insertWeatherData(t, store, older)
+
+		data, err := store.GetLatestWeatherData(ctx, "sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assertWeatherData(t, newer, data)
+	})
+
+	t.Run("Should keep the newest observation over an older one fetched later", func(t *testing.T) {
+		store := newStore(t)
+
+		// e.g. failing over to a provider whose observations lag behind
+		newer := &storage.WeatherData{DataSource: "weatherstack", City: "sydney", Temperature: 20, WindSpeed: 5, UpdatedDate: now.Add(-time.Minute), ObservedAt: now.Add(-10 * time.Minute)}
+		older := &storage.WeatherData{DataSource: "openweathermap", City: "sydney", Temperature: 15, WindSpeed: 4, UpdatedDate: now, ObservedAt: now.Add(-time.Hour)}
+		insertWeatherData(t, store, newer)
+		insertWeatherData(t, store, older)
+
+		data, err := store.GetLatestWeatherData(ctx, "sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assertWeatherData(t, newer, data)
+
+		batch, err := store.GetLatestWeatherDataBatch(ctx, []string{"sydney"})
+		if !assert.Nil(t, err) || !assert.Len(t, batch, 1) {
+			t.FailNow()
+		}
+		assertWeatherData(t, newer, batch["sydney"])
+
+		// The same source's latest row isn't replaced either
+		insertWeatherData(t, store, &storage.WeatherData{DataSource: "weatherstack", City: "sydney", Temperature: 10, WindSpeed: 3, UpdatedDate: now, ObservedAt: now.Add(-time.Hour)})
+		data, err = store.GetLatestWeatherData(ctx, "sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assertWeatherData(t, newer, data)
+	})
+
+	t.Run("Should insert a batch, keeping the newest row per city and source", func(t *testing.T) {
+		store := newStore(t)
+
+		rows := []*storage.WeatherData{
+			{DataSource: "weatherstack", City: "sydney", Temperature: 10, WindSpeed: 1, UpdatedDate: now},
+			{DataSource: "weatherstack", City: "sydney", Temperature: 9, WindSpeed: 1, UpdatedDate: now.Add(-time.Hour)},
+			{DataSource: "openweathermap", City: "melbourne", Temperature: 12, WindSpeed: 3, UpdatedDate: now},
+		}
+		inserted, err := store.InsertWeatherDataBatch(ctx, rows)
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, 3, inserted)
+
+		data, err := store.GetLatestWeatherData(ctx, "sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assertWeatherData(t, rows[0], data)
+
+		// Only new observations are counted
+		inserted, err = store.InsertWeatherDataBatch(ctx, rows)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, inserted)
+
+		data, err = store.GetLatestWeatherData(ctx, "melbourne")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assertWeatherData(t, rows[2], data)
+
+		inserted, err = store.InsertWeatherDataBatch(ctx, []*storage.WeatherData{})
+		assert.Nil(t, err)
+		assert.Equal(t, 0, inserted)
+	})
+
+	t.Run("Should store an observation once, refreshing the latest row when it is fetched again", func(t *testing.T) {
+		store := newStore(t)
+
+		observedAt := now.Add(-10 * time.Minute)
+		first := &storage.WeatherData{DataSource: "weatherstack", City: "sydney", Temperature: 20, WindSpeed: 5, UpdatedDate: now.Add(-time.Minute), ObservedAt: observedAt}
+		inserted, err := store.InsertWeatherData(ctx, first)
+		assert.Nil(t, err)
+		assert.True(t, inserted)
+
+		again := &storage.WeatherData{DataSource: "weatherstack", City: "sydney", Temperature: 20, WindSpeed: 5, UpdatedDate: now, ObservedAt: observedAt}
+		inserted, err = store.InsertWeatherData(ctx, again)
+		assert.Nil(t, err)
+		assert.False(t, inserted)
+
+		data, err := store.GetLatestWeatherData(ctx, "sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assertWeatherData(t, again, data)
+
+		// The same time from another source or city is a different observation
+		inserted, err = store.InsertWeatherData(ctx, &storage.WeatherData{DataSource: "openweathermap", City: "sydney", UpdatedDate: now, ObservedAt: observedAt})
+		assert.Nil(t, err)
+		assert.True(t, inserted)
+		inserted, err = store.InsertWeatherData(ctx, &storage.WeatherData{DataSource: "weatherstack", City: "melbourne", UpdatedDate: now, ObservedAt: observedAt})
+		assert.Nil(t, err)
+		assert.True(t, inserted)
+
+		// Without an observation time, the fetch time identifies the observation
+		inserted, err = store.InsertWeatherData(ctx, &storage.WeatherData{DataSource: "weatherstack", City: "perth", UpdatedDate: now})
+		assert.Nil(t, err)
+		assert.True(t, inserted)
+		inserted, err = store.InsertWeatherData(ctx, &storage.WeatherData{DataSource: "weatherstack", City: "perth", UpdatedDate: now})
+		assert.Nil(t, err)
+		assert.False(t, inserted)
+	})
+
+	t.Run("Should round trip consensus sources", func(t *testing.T) {
+		store := newStore(t)
+
+		row := &storage.WeatherData{DataSource: "consensus", Sources: []string{"weatherstack", "openweathermap"}, City: "sydney", Temperature: 18, WindSpeed: 7, UpdatedDate: now}
+		insertWeatherData(t, store, row)
+
+		data, err := store.GetLatestWeatherData(ctx, "sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assertWeatherData(t, row, data)
+	})
+
+	t.Run("Should round trip humidity, conditions and the timezone", func(t *testing.T) {
+		store := newStore(t)
+
+		row := &storage.WeatherData{DataSource: "weatherstack", City: "sydney", Temperature: 18, WindSpeed: 7, Humidity: 64, Condition: "partly_cloudy", Night: true, Description: "Nubes dispersas", DescriptionLanguage: "es", Timezone: "Australia/Sydney", UpdatedDate: now}
+		insertWeatherData(t, store, row)
+
+		data, err := store.GetLatestWeatherData(ctx, "sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assertWeatherData(t, row, data)
+
+		batch, err := store.GetLatestWeatherDataBatch(ctx, []string{"sydney"})
+		if !assert.Nil(t, err) || !assert.NotNil(t, batch["sydney"]) {
+			t.FailNow()
+		}
+		assert.Equal(t, 64, batch["sydney"].Humidity)
+		assert.Equal(t, "partly_cloudy", batch["sydney"].Condition)
+		assert.True(t, batch["sydney"].Night)
+		assert.Equal(t, "Nubes dispersas", batch["sydney"].Description)
+		assert.Equal(t, "es", batch["sydney"].DescriptionLanguage)
+		assert.Equal(t, "Australia/Sydney", batch["sydney"].Timezone)
+	})
+
+	t.Run("Should not mutate stored rows through returned data", func(t *testing.T) {
+		store := newStore(t)
+
+		row := &storage.WeatherData{DataSource: "weatherstack", City: "sydney", Temperature: 18, WindSpeed: 7, UpdatedDate: now}
+		insertWeatherData(t, store, row)
+		row.Temperature = 99
+
+		data, err := store.GetLatestWeatherData(ctx, "sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assert.Equal(t, 18, data.Temperature)
+		data.Temperature = 99
+
+		data, err = store.GetLatestWeatherData(ctx, "sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assert.Equal(t, 18, data.Temperature)
+	})
+
+	t.Run("Should reserve provider calls up to the limit per period", func(t *testing.T) {
+		store := newStore(t)
+
+		for i := 0; i < 2; i++ {
+			ok, err := store.ReserveProviderCall(ctx, "weatherstack", "2021-05", 2)
+			assert.Nil(t, err)
+			assert.True(t, ok)
+		}
+		ok, err := store.ReserveProviderCall(ctx, "weatherstack", "2021-05", 2)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+
+		// Other providers and periods are counted separately
+		ok, err = store.ReserveProviderCall(ctx, "openweathermap", "2021-05", 2)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		ok, err = store.ReserveProviderCall(ctx, "weatherstack", "2021-06", 2)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+
+		calls, err := store.GetProviderUsage(ctx, "weatherstack", "2021-05")
+		assert.Nil(t, err)
+		assert.Equal(t, 2, calls)
+		calls, err = store.GetProviderUsage(ctx, "openweathermap", "2021-05")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Should release reserved provider calls, not going below zero", func(t *testing.T) {
+		store := newStore(t)
+
+		for i := 0; i < 2; i++ {
+			ok, err := store.ReserveProviderCall(ctx, "weatherstack", "2021-05", 2)
+			assert.Nil(t, err)
+			assert.True(t, ok)
+		}
+		assert.Nil(t, store.ReleaseProviderCall(ctx, "weatherstack", "2021-05"))
+
+		// The released call can be reserved again
+		ok, err := store.ReserveProviderCall(ctx, "weatherstack", "2021-05", 2)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+
+		for i := 0; i < 3; i++ {
+			assert.Nil(t, store.ReleaseProviderCall(ctx, "weatherstack", "2021-05"))
+		}
+		assert.Nil(t, store.ReleaseProviderCall(ctx, "openweathermap", "2021-05"))
+
+		calls, err := store.GetProviderUsage(ctx, "weatherstack", "2021-05")
+		assert.Nil(t, err)
+		assert.Equal(t, 0, calls)
+		calls, err = store.GetProviderUsage(ctx, "openweathermap", "2021-05")
+		assert.Nil(t, err)
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("Should count calls without a limit", func(t *testing.T) {
+		store := newStore(t)
+
+		for i := 0; i < 3; i++ {
+			ok, err := store.ReserveProviderCall(ctx, "weatherstack", "2021-05", 0)
+			assert.Nil(t, err)
+			assert.True(t, ok)
+		}
+
+		calls, err := store.GetProviderUsage(ctx, "weatherstack", "2021-05")
+		assert.Nil(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("Should return zero usage for an unused provider", func(t *testing.T) {
+		store := newStore(t)
+
+		calls, err := store.GetProviderUsage(ctx, "weatherstack", "2021-05")
+		assert.Nil(t, err)
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("Should prune history older than the retention in batches", func(t *testing.T) {
+		store := newStore(t)
+
+		for i := 0; i < 5; i++ {
+			insertWeatherData(t, store, &storage.WeatherData{DataSource: "weatherstack", City: "sydney", Temperature: 10 + i, WindSpeed: i, UpdatedDate: now.Add(-time.Duration(40+i) * 24 * time.Hour)})
+		}
+		recent := &storage.WeatherData{DataSource: "weatherstack", City: "melbourne", Temperature: 20, WindSpeed: 3, UpdatedDate: now}
+		insertWeatherData(t, store, recent)
+
+		result, err := store.PruneWeatherData(ctx, storage.RetentionPolicy{RawRetention: 30 * 24 * time.Hour, Rollup: true, BatchSize: 2})
+		if !assert.Nil(t, err) || !assert.NotNil(t, result) {
+			t.FailNow()
+		}
+		assert.Equal(t, 5, result.Pruned)
+		assert.WithinDuration(t, now.Add(-30*24*time.Hour), result.Cutoff, time.Minute)
+
+		result, err = store.PruneWeatherData(ctx, storage.RetentionPolicy{RawRetention: 30 * 24 * time.Hour, Rollup: true, BatchSize: 2})
+		if !assert.Nil(t, err) || !assert.NotNil(t, result) {
+			t.FailNow()
+		}
+		assert.Equal(t, 0, result.Pruned)
+
+		// The latest rows are kept regardless of age
+		data, err := store.GetLatestWeatherData(ctx, "sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assert.Equal(t, 10, data.Temperature)
+
+		data, err = store.GetLatestWeatherData(ctx, "melbourne")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assertWeatherData(t, recent, data)
+	})
+
+	t.Run("Should prune history by when it was observed rather than when it was fetched", func(t *testing.T) {
+		store := newStore(t)
+
+		old := &storage.WeatherData{DataSource: "weatherstack", City: "sydney", Temperature: 10, WindSpeed: 1, UpdatedDate: now, ObservedAt: now.Add(-40 * 24 * time.Hour)}
+		insertWeatherData(t, store, old)
+		recent := &storage.WeatherData{DataSource: "weatherstack", City: "sydney", Temperature: 12, WindSpeed: 2, UpdatedDate: now, ObservedAt: now.Add(-time.Hour)}
+		insertWeatherData(t, store, recent)
+
+		result, err := store.PruneWeatherData(ctx, storage.RetentionPolicy{RawRetention: 30 * 24 * time.Hour, Rollup: true})
+		if !assert.Nil(t, err) || !assert.NotNil(t, result) {
+			t.FailNow()
+		}
+		assert.Equal(t, 1, result.Pruned)
+
+		// Only the pruned observation is new again
+		inserted, err := store.InsertWeatherData(ctx, old)
+		assert.Nil(t, err)
+		assert.True(t, inserted)
+		inserted, err = store.InsertWeatherData(ctx, recent)
+		assert.Nil(t, err)
+		assert.False(t, inserted)
+	})
+
+	t.Run("Should return nil for a city with no forecast", func(t *testing.T) {
+		store := newStore(t)
+
+		forecast, err := store.GetForecast(ctx, "sydney")
+		assert.Nil(t, err)
+		assert.Nil(t, forecast)
+	})
+
+	t.Run("Should round trip a forecast, keeping the latest fetched", func(t *testing.T) {
+		store := newStore(t)
+
+		forecast := &storage.Forecast{
+			DataSource: "openweathermap",
+			City:       "sydney",
+			FetchedAt:  now,
+			Hourly: []storage.ForecastPeriod{
+				{Time: now.Add(time.Hour), Temperature: 17, TemperatureMin: 17, TemperatureMax: 17, WindSpeed: 9, PrecipitationChance: 20, Description: "light rain"},
+				{Time: now.Add(4 * time.Hour), Temperature: 15, TemperatureMin: 15, TemperatureMax: 15, WindSpeed: 12, Description: "clear sky"},
+			},
+			Daily: []storage.ForecastPeriod{
+				{Time: now.Truncate(24 * time.Hour), Temperature: 16, TemperatureMin: 15, TemperatureMax: 17, WindSpeed: 12, PrecipitationChance: 20, Description: "light rain"},
+			},
+		}
+		assert.Nil(t, store.InsertForecast(ctx, forecast))
+
+		older := storage.CopyForecast(forecast)
+		older.FetchedAt = now.Add(-time.Hour)
+		older.DataSource = "weatherstack"
+		assert.Nil(t, store.InsertForecast(ctx, older))
+
+		data, err := store.GetForecast(ctx, "sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assert.Equal(t, "openweathermap", data.DataSource)
+		assert.Equal(t, "sydney", data.City)
+		assert.True(t, now.Equal(data.FetchedAt), "expected FetchedAt %v, got %v", now, data.FetchedAt)
+		assertForecastPeriods(t, forecast.Hourly, data.Hourly)
+		assertForecastPeriods(t, forecast.Daily, data.Daily)
+
+		newer := storage.CopyForecast(forecast)
+		newer.FetchedAt = now.Add(time.Hour)
+		newer.Hourly = newer.Hourly[1:]
+		assert.Nil(t, store.InsertForecast(ctx, newer))
+
+		data, err = store.GetForecast(ctx, "sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assertForecastPeriods(t, newer.Hourly, data.Hourly)
+	})
+
+	t.Run("Should round trip alerts, keeping the latest fetched", func(t *testing.T) {
+		store := newStore(t)
+
+		data, err := store.GetAlerts(ctx, "city:sydney")
+		assert.Nil(t, err)
+		assert.Nil(t, data)
+
+		alerts := &storage.Alerts{
+			Key:       "city:sydney",
+			Lat:       -33.8698,
+			Lon:       151.2083,
+			FetchedAt: now,
+			Alerts: []storage.Alert{
+				{Sources: []string{"openweathermap", "nws"}, Sender: "Bureau of Meteorology", Event: "Severe Thunderstorm Warning", Severity: storage.SeveritySevere, Urgency: storage.UrgencyImmediate, Start: now, End: now.Add(3 * time.Hour)},
+			},
+		}
+		assert.Nil(t, store.InsertAlerts(ctx, alerts))
+
+		older := storage.CopyAlerts(alerts)
+		older.FetchedAt = now.Add(-time.Hour)
+		older.Alerts = nil
+		assert.Nil(t, store.InsertAlerts(ctx, older))
+
+		data, err = store.GetAlerts(ctx, "city:sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assert.Equal(t, "city:sydney", data.Key)
+		assert.InDelta(t, -33.8698, data.Lat, 0.00001)
+		assert.InDelta(t, 151.2083, data.Lon, 0.00001)
+		assert.True(t, now.Equal(data.FetchedAt), "expected FetchedAt %v, got %v", now, data.FetchedAt)
+		if assert.Len(t, data.Alerts, 1) {
+			assert.True(t, alerts.Alerts[0].Start.Equal(data.Alerts[0].Start))
+			assert.True(t, alerts.Alerts[0].End.Equal(data.Alerts[0].End))
+			data.Alerts[0].Start = alerts.Alerts[0].Start
+			data.Alerts[0].End = alerts.Alerts[0].End
+			assert.Equal(t, alerts.Alerts[0], data.Alerts[0])
+		}
+
+		newer := storage.CopyAlerts(alerts)
+		newer.FetchedAt = now.Add(time.Hour)
+		newer.Alerts = []storage.Alert{}
+		assert.Nil(t, store.InsertAlerts(ctx, newer))
+
+		data, err = store.GetAlerts(ctx, "city:sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assert.Empty(t, data.Alerts)
+	})
+
+	t.Run("Should round trip air quality, keeping the latest fetched", func(t *testing.T) {
+		store := newStore(t)
+
+		data, err := store.GetAirQuality(ctx, "sydney")
+		assert.Nil(t, err)
+		assert.Nil(t, data)
+
+		airQuality := &storage.AirQuality{
+			DataSource: "openweathermap",
+			City:       "sydney",
+			Lat:        -33.8698,
+			Lon:        151.2083,
+			MeasuredAt: now.Add(-30 * time.Minute),
+			FetchedAt:  now,
+			PM25:       8.5,
+			PM10:       17.25,
+			O3:         60.08,
+			NO2:        12.34,
+			SO2:        1.5,
+			CO:         230.31,
+		}
+		assert.Nil(t, store.InsertAirQuality(ctx, airQuality))
+
+		older := *airQuality
+		older.FetchedAt = now.Add(-time.Hour)
+		older.PM25 = 100
+		assert.Nil(t, store.InsertAirQuality(ctx, &older))
+
+		data, err = store.GetAirQuality(ctx, "sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assert.True(t, airQuality.MeasuredAt.Equal(data.MeasuredAt), "expected MeasuredAt %v, got %v", airQuality.MeasuredAt, data.MeasuredAt)
+		assert.True(t, now.Equal(data.FetchedAt), "expected FetchedAt %v, got %v", now, data.FetchedAt)
+		data.MeasuredAt = airQuality.MeasuredAt
+		data.FetchedAt = airQuality.FetchedAt
+		assert.Equal(t, airQuality, data)
+
+		newer := *airQuality
+		newer.FetchedAt = now.Add(time.Hour)
+		newer.PM25 = 20
+		assert.Nil(t, store.InsertAirQuality(ctx, &newer))
+
+		data, err = store.GetAirQuality(ctx, "sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assert.Equal(t, 20.0, data.PM25)
+	})
+
+	t.Run("Should round trip locations, replacing them and reading them in a batch", func(t *testing.T) {
+		store := newStore(t)
+
+		data, err := store.GetLocation(ctx, "sydney")
+		assert.Nil(t, err)
+		assert.Nil(t, data)
+
+		location := &storage.Location{
+			City:       "sydney",
+			Name:       "Sydney",
+			Country:    "AU",
+			Lat:        -33.8698,
+			Lon:        151.2083,
+			Timezone:   "Australia/Sydney",
+			GeocodedAt: now,
+		}
+		assert.Nil(t, store.InsertLocation(ctx, location))
+
+		data, err = store.GetLocation(ctx, "sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assert.True(t, now.Equal(data.GeocodedAt), "expected GeocodedAt %v, got %v", now, data.GeocodedAt)
+		data.GeocodedAt = location.GeocodedAt
+		assert.Equal(t, location, data)
+
+		moved := *location
+		moved.Lat = -33.87
+		moved.Timezone = "UTC+11:00"
+		moved.GeocodedAt = now.Add(-time.Hour)
+		assert.Nil(t, store.InsertLocation(ctx, &moved))
+
+		data, err = store.GetLocation(ctx, "sydney")
+		if !assert.Nil(t, err) || !assert.NotNil(t, data) {
+			t.FailNow()
+		}
+		assert.Equal(t, -33.87, data.Lat)
+		assert.Equal(t, "UTC+11:00", data.Timezone)
+
+		assert.Nil(t, store.InsertLocation(ctx, &storage.Location{City: "perth", Name: "Perth", Country: "AU", Lat: -31.9523, Lon: 115.8613, Timezone: "Australia/Perth", GeocodedAt: now}))
+		batch, err := store.GetLocationBatch(ctx, []string{"sydney", "perth", "hobart"})
+		if !assert.Nil(t, err) || !assert.Len(t, batch, 2) {
+			t.FailNow()
+		}
+		assert.Equal(t, "UTC+11:00", batch["sydney"].Timezone)
+		assert.Equal(t, "Australia/Perth", batch["perth"].Timezone)
+		assert.True(t, now.Equal(batch["perth"].GeocodedAt), "expected GeocodedAt %v, got %v", now, batch["perth"].GeocodedAt)
+
+		batch, err = store.GetLocationBatch(ctx, []string{})
+		assert.Nil(t, err)
+		assert.Empty(t, batch)
+	})
+}
+
+func assertForecastPeriods(t *testing.T, expected, actual []storage.ForecastPeriod) {
+	t.Helper()
+
+	if !assert.Len(t, actual, len(expected)) {
+		return
+	}
+	for i := range expected {
+		assert.True(t, expected[i].Time.Equal(actual[i].Time), "expected Time %v, got %v", expected[i].Time, actual[i].Time)
+		actual[i].Time = expected[i].Time
+		assert.Equal(t, expected[i], actual[i])
+	}
+}
+
+func assertWeatherData(t *testing.T, expected, actual *storage.WeatherData) {
+	t.Helper()
+
+	assert.Equal(t, expected.DataSource, actual.DataSource)
+	assert.Equal(t, expected.City, actual.City)
+	assert.Equal(t, expected.Temperature, actual.Temperature)
+	assert.Equal(t, expected.WindSpeed, actual.WindSpeed)
+	assert.Equal(t, expected.Humidity, actual.Humidity)
+	assert.Equal(t, expected.Condition, actual.Condition)
+	assert.Equal(t, expected.Night, actual.Night)
+	assert.Equal(t, expected.Description, actual.Description)
+	assert.Equal(t, expected.DescriptionLanguage, actual.DescriptionLanguage)
+	assert.Equal(t, expected.Timezone, actual.Timezone)
+	assert.True(t, expected.UpdatedDate.Equal(actual.UpdatedDate), "expected UpdatedDate %v, got %v", expected.UpdatedDate, actual.UpdatedDate)
+	assert.True(t, expected.ObservationTime().Equal(actual.ObservationTime()), "expected observation time %v, got %v", expected.ObservationTime(), actual.ObservationTime())
+	if len(expected.Sources) == 0 {
+		assert.Empty(t, actual.Sources)
+	} else {
+		assert.Equal(t, expected.Sources, actual.Sources)
+	}
+}
+
+func insertWeatherData(t *testing.T, store storage.Store, weatherData *storage.WeatherData) {
+	t.Helper()
+
+	_, err := store.InsertWeatherData(context.Background(), weatherData)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+}
@@ -1,5 +1,13 @@
 package weatherstack
 
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
 const (
 	defaultBaseURL = "http://api.weatherstack.com"
 )
@@ -20,3 +28,32 @@ func NewClient(baseURL string, apiKey string) *Client {
 		apiKey:  apiKey,
 	}
 }
+
+// get calls the api path with the query params, decoding the response into out
+func (c *Client) get(path string, queryParams url.Values, out interface{}) error {
+
+	queryParams.Add("access_key", c.apiKey)
+	url := fmt.Sprintf("%v%v?%v", c.baseURL, path, queryParams.Encode())
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	byt, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Response status code: %d, body: %s", resp.StatusCode, string(byt))
+	}
+
+	return json.Unmarshal(byt, out)
+}
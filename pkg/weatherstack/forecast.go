@@ -0,0 +1,112 @@
+package weatherstack
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ForecastResponse is the current weather with a daily forecast, each day broken down into hourly steps
+type ForecastResponse struct {
+	APIResponse
+	Forecast map[string]ForecastDay `json:"forecast"`
+	// Success is false when the request failed, e.g. because the plan doesn't include forecasts
+	Success *bool `json:"success"`
+	Error   struct {
+		Code int    `json:"code"`
+		Type string `json:"type"`
+		Info string `json:"info"`
+	} `json:"error"`
+}
+
+// ForecastDay is a day of the forecast, keyed by its local date
+type ForecastDay struct {
+	Date      string         `json:"date"`
+	DateEpoch int            `json:"date_epoch"`
+	MinTemp   int            `json:"mintemp"`
+	MaxTemp   int            `json:"maxtemp"`
+	AvgTemp   int            `json:"avgtemp"`
+	TotalSnow float64        `json:"totalsnow"`
+	SunHour   float64        `json:"sunhour"`
+	UvIndex   int            `json:"uv_index"`
+	Hourly    []ForecastHour `json:"hourly"`
+}
+
+// ForecastHour is a step of a forecast day, starting at its local Time (e.g. "0", "300", "1500")
+type ForecastHour struct {
+	Time                string   `json:"time"`
+	Temperature         int      `json:"temperature"`
+	WindSpeed           int      `json:"wind_speed"`
+	WindDegree          int      `json:"wind_degree"`
+	WindDir             string   `json:"wind_dir"`
+	WeatherCode         int      `json:"weather_code"`
+	WeatherDescriptions []string `json:"weather_descriptions"`
+	Precip              float64  `json:"precip"`
+	Humidity            int      `json:"humidity"`
+	Cloudcover          int      `json:"cloudcover"`
+	ChanceOfRain        int      `json:"chanceofrain"`
+	ChanceOfSnow        int      `json:"chanceofsnow"`
+}
+
+// Days returns the forecast days in date order
+func (r *ForecastResponse) Days() []ForecastDay {
+	days := []ForecastDay{}
+	for _, day := range r.Forecast {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Date < days[j].Date
+	})
+	return days
+}
+
+// DayStart returns when the day starts, local midnight as a UTC time
+func (r *ForecastResponse) DayStart(day ForecastDay) (time.Time, error) {
+	offset, err := r.utcOffset()
+	if err != nil {
+		return time.Time{}, err
+	}
+	date, err := time.Parse("2006-01-02", day.Date)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return date.Add(-offset), nil
+}
+
+// HourStart returns when the step of the day starts as a UTC time
+func (r *ForecastResponse) HourStart(day ForecastDay, hour ForecastHour) (time.Time, error) {
+	start, err := r.DayStart(day)
+	if err != nil {
+		return time.Time{}, err
+	}
+	clock, err := strconv.Atoi(hour.Time)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return start.Add(time.Duration(clock/100)*time.Hour + time.Duration(clock%100)*time.Minute), nil
+}
+
+// GetForecast returns the city's forecast for days days with 3 hourly steps.
+// Forecasts need a paid plan, other plans get an error.
+func (c *Client) GetForecast(city string, days int) (*ForecastResponse, error) {
+
+	queryParams := url.Values{}
+	queryParams.Add("query", city)
+	queryParams.Add("forecast_days", strconv.Itoa(days))
+	queryParams.Add("hourly", "1")
+
+	out := &ForecastResponse{}
+	err := c.get("/forecast", queryParams, out)
+	if err != nil {
+		return nil, err
+	}
+
+	// Failed requests are still a 200
+	if out.Success != nil && !*out.Success {
+		return nil, fmt.Errorf("Response error: %d %s: %s", out.Error.Code, out.Error.Type, out.Error.Info)
+	}
+
+	return out, nil
+}
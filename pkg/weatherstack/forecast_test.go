@@ -0,0 +1,83 @@
+package weatherstack_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/weatherstack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetForecast(t *testing.T) {
+
+	t.Run("Test Parse Response", func(t *testing.T) {
+		cannedResponse := `{"request":{"type":"City","query":"Sydney, Australia","language":"en","unit":"m"},"location":{"name":"Sydney","country":"Australia","region":"New South Wales","lat":"-33.883","lon":"151.217","timezone_id":"Australia/Sydney","localtime":"2021-05-16 11:37","localtime_epoch":1621165020,"utc_offset":"10.0"},"current":{"observation_time":"01:37 AM","temperature":15,"wind_speed":24},"forecast":{"2021-05-18":{"date":"2021-05-18","date_epoch":1621296000,"mintemp":10,"maxtemp":17,"avgtemp":13,"hourly":[{"time":"0","temperature":11,"wind_speed":8,"weather_descriptions":["Clear"],"chanceofrain":0}]},"2021-05-17":{"date":"2021-05-17","date_epoch":1621209600,"mintemp":11,"maxtemp":19,"avgtemp":15,"hourly":[{"time":"0","temperature":12,"wind_speed":9,"weather_descriptions":["Clear"],"chanceofrain":0},{"time":"1330","temperature":19,"wind_speed":20,"weather_descriptions":["Light rain"],"chanceofrain":60}]}}}`
+		testAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// Canned Response
+			w.WriteHeader(200)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(cannedResponse))
+		}))
+
+		client := weatherstack.NewClient(testAPI.URL, "dummykey")
+
+		resp, err := client.GetForecast("Sydney", 2)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		days := resp.Days()
+		if !assert.Len(t, days, 2) {
+			t.Fatal()
+		}
+		assert.Equal(t, "2021-05-17", days[0].Date)
+		assert.Equal(t, 19, days[0].MaxTemp)
+		assert.Equal(t, 60, days[0].Hourly[1].ChanceOfRain)
+
+		start, err := resp.DayStart(days[0])
+		assert.Nil(t, err)
+		assert.Equal(t, time.Date(2021, 5, 16, 14, 0, 0, 0, time.UTC), start)
+		start, err = resp.HourStart(days[0], days[0].Hourly[1])
+		assert.Nil(t, err)
+		assert.Equal(t, time.Date(2021, 5, 17, 3, 30, 0, 0, time.UTC), start)
+	})
+
+	t.Run("It should return the error of a failed request", func(t *testing.T) {
+		cannedResponse := `{"success":false,"error":{"code":603,"type":"function_access_restricted","info":"Access Restricted - Your current Subscription Plan does not support this API Function."}}`
+		testAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(200)
+			w.Write([]byte(cannedResponse))
+		}))
+
+		client := weatherstack.NewClient(testAPI.URL, "dummykey")
+
+		resp, err := client.GetForecast("Sydney", 2)
+		assert.Nil(t, resp)
+		if assert.NotNil(t, err) {
+			assert.Contains(t, err.Error(), "function_access_restricted")
+		}
+	})
+
+	t.Run("Check Request", func(t *testing.T) {
+
+		var testRequest *http.Request
+		testAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// Canned Response
+			w.WriteHeader(200)
+			testRequest = req
+		}))
+
+		client := weatherstack.NewClient(testAPI.URL, "dummykey")
+
+		_, _ = client.GetForecast("Sydney", 7)
+		if !assert.NotNil(t, testRequest) {
+			t.Fatal()
+		}
+		assert.Equal(t, "dummykey", testRequest.URL.Query().Get("access_key"))
+		assert.Equal(t, "Sydney", testRequest.URL.Query().Get("query"))
+		assert.Equal(t, "7", testRequest.URL.Query().Get("forecast_days"))
+		assert.Equal(t, "1", testRequest.URL.Query().Get("hourly"))
+		assert.Equal(t, "/forecast", testRequest.URL.Path)
+	})
+}
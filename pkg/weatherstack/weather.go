@@ -1,11 +1,9 @@
 package weatherstack
 
 import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 )
 
 type APIResponse struct {
@@ -46,34 +44,80 @@ type APIResponse struct {
 	} `json:"current"`
 }
 
-func (c *Client) GetWeather(city string) (*APIResponse, error) {
-
-	queryParams := url.Values{}
-	queryParams.Add("access_key", c.apiKey)
-	queryParams.Add("query", city)
-	url := fmt.Sprintf("%v/current?%v", c.baseURL, queryParams.Encode())
+// ObservedAt returns when the current weather was observed, zero if the response doesn't say.
+// observation_time is a UTC time of day (e.g. "01:37 AM"), so its date comes from localtime_epoch. That is the location's
+// local wall clock encoded as if it were UTC, so it is shifted back by utc_offset first. Without an observation_time,
+// the response's local time is used.
+func (r *APIResponse) ObservedAt() time.Time {
+	if r.Location.LocaltimeEpoch == 0 {
+		return time.Time{}
+	}
+	offset, err := r.utcOffset()
+	if err != nil {
+		return time.Time{}
+	}
+	localtime := time.Unix(int64(r.Location.LocaltimeEpoch), 0).UTC().Add(-offset)
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	clock, err := time.Parse("3:04 PM", r.Current.ObservationTime)
 	if err != nil {
-		return nil, err
+		return localtime
+	}
+
+	year, month, day := localtime.Date()
+	observedAt := time.Date(year, month, day, clock.Hour(), clock.Minute(), 0, 0, time.UTC)
+	// Observed before midnight UTC, requested after
+	if observedAt.After(localtime) {
+		observedAt = observedAt.AddDate(0, 0, -1)
+	}
+	return observedAt
+}
+
+// Night reports whether the current weather was observed at night (is_day is "no")
+func (r *APIResponse) Night() bool {
+	return r.Current.IsDay == "no"
+}
+
+// Timezone returns the location's IANA timezone (timezone_id, empty if the response doesn't say) and its UTC offset.
+// If utc_offset can't be parsed, the offset is the timezone's current one. ok is false if neither is usable.
+func (r *APIResponse) Timezone() (name string, offset time.Duration, ok bool) {
+	offset, err := r.utcOffset()
+	if err == nil {
+		return r.Location.TimezoneID, offset, true
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	// timezone_id is the more authoritative of the two, the offset is only needed without it
+	if r.Location.TimezoneID == "" {
+		return "", 0, false
+	}
+	zone, err := time.LoadLocation(r.Location.TimezoneID)
 	if err != nil {
-		return nil, err
+		return "", 0, false
 	}
+	_, seconds := time.Now().In(zone).Zone()
+	return r.Location.TimezoneID, time.Duration(seconds) * time.Second, true
+}
 
-	byt, err := ioutil.ReadAll(resp.Body)
+// utcOffset parses the location's utc_offset, in hours (e.g. "10.0" or "5.5")
+func (r *APIResponse) utcOffset() (time.Duration, error) {
+	offset, err := strconv.ParseFloat(r.Location.UtcOffset, 64)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
+	return time.Duration(offset * float64(time.Hour)), nil
+}
+
+// GetWeather returns the city's current weather, with weather_descriptions in the language (an ISO 639-1 code) unless
+// it's empty. Languages other than English need a paid plan.
+func (c *Client) GetWeather(city string, language string) (*APIResponse, error) {
 
-	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return nil, fmt.Errorf("Response status code: %d, body: %s", resp.StatusCode, string(byt))
+	queryParams := url.Values{}
+	queryParams.Add("query", city)
+	if language != "" {
+		queryParams.Add("language", language)
 	}
 
 	out := &APIResponse{}
-	err = json.Unmarshal(byt, out)
+	err := c.get("/current", queryParams, out)
 	if err != nil {
 		return nil, err
 	}
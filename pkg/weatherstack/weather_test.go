@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/TomSED/weather-api/pkg/weatherstack"
 	"github.com/stretchr/testify/assert"
@@ -22,12 +23,15 @@ func TestGetWeather(t *testing.T) {
 
 		client := weatherstack.NewClient(testAPI.URL, "dummykey")
 
-		resp, err := client.GetWeather("Sydney")
+		resp, err := client.GetWeather("Sydney", "")
 		if !assert.Nil(t, err) {
 			t.Fatal(err)
 		}
 		assert.Equal(t, 24, resp.Current.WindSpeed)
 		assert.Equal(t, 15, resp.Current.Temperature)
+		assert.Equal(t, time.Date(2021, 5, 16, 1, 37, 0, 0, time.UTC), resp.ObservedAt())
+		assert.Equal(t, 113, resp.Current.WeatherCode)
+		assert.False(t, resp.Night())
 	})
 
 	t.Run("Check Request", func(t *testing.T) {
@@ -41,7 +45,7 @@ func TestGetWeather(t *testing.T) {
 
 		client := weatherstack.NewClient(testAPI.URL, "dummykey")
 
-		_, _ = client.GetWeather("Sydney")
+		_, _ = client.GetWeather("Sydney", "")
 		if !assert.NotNil(t, testRequest) {
 			t.Fatal()
 		}
@@ -49,5 +53,89 @@ func TestGetWeather(t *testing.T) {
 		assert.Equal(t, "dummykey", testRequest.URL.Query().Get("access_key"))
 		assert.Equal(t, "Sydney", testRequest.URL.Query().Get("query"))
 		assert.Equal(t, "/current", testRequest.URL.Path)
+		_, exist := testRequest.URL.Query()["language"]
+		assert.False(t, exist)
+
+		_, _ = client.GetWeather("Sydney", "fr")
+		assert.Equal(t, "fr", testRequest.URL.Query().Get("language"))
+	})
+}
+
+func TestObservedAt(t *testing.T) {
+
+	newResponse := func(localtimeEpoch int, utcOffset string, observationTime string) *weatherstack.APIResponse {
+		resp := &weatherstack.APIResponse{}
+		resp.Location.LocaltimeEpoch = localtimeEpoch
+		resp.Location.UtcOffset = utcOffset
+		resp.Current.ObservationTime = observationTime
+		return resp
+	}
+
+	t.Run("It should date the UTC observation time from the location's local time", func(t *testing.T) {
+		// 2021-05-16 11:37 in Sydney is 01:37 UTC
+		assert.Equal(t, time.Date(2021, 5, 16, 1, 20, 0, 0, time.UTC), newResponse(1621165020, "10.0", "01:20 AM").ObservedAt())
+		// 2021-05-16 07:07 in Kolkata is 01:37 UTC
+		assert.Equal(t, time.Date(2021, 5, 16, 1, 30, 0, 0, time.UTC), newResponse(1621148820, "5.5", "01:30 AM").ObservedAt())
+	})
+
+	t.Run("It should use the previous day for observations made before midnight UTC", func(t *testing.T) {
+		// 2021-05-16 10:05 in Sydney is 00:05 UTC
+		assert.Equal(t, time.Date(2021, 5, 15, 23, 50, 0, 0, time.UTC), newResponse(1621159500, "10.0", "11:50 PM").ObservedAt())
+	})
+
+	t.Run("It should fall back to the local time, or zero without one", func(t *testing.T) {
+		assert.Equal(t, time.Date(2021, 5, 16, 1, 37, 0, 0, time.UTC), newResponse(1621165020, "10.0", "").ObservedAt())
+		assert.True(t, newResponse(0, "", "01:20 AM").ObservedAt().IsZero())
+		assert.True(t, newResponse(1621165020, "", "01:20 AM").ObservedAt().IsZero())
+	})
+}
+
+func TestTimezone(t *testing.T) {
+
+	t.Run("It should return the timezone_id with the utc_offset", func(t *testing.T) {
+		resp := &weatherstack.APIResponse{}
+		resp.Location.TimezoneID = "Asia/Kolkata"
+		resp.Location.UtcOffset = "5.5"
+		name, offset, ok := resp.Timezone()
+		assert.True(t, ok)
+		assert.Equal(t, "Asia/Kolkata", name)
+		assert.Equal(t, 5*time.Hour+30*time.Minute, offset)
+	})
+
+	t.Run("It should return the utc_offset without a timezone_id", func(t *testing.T) {
+		resp := &weatherstack.APIResponse{}
+		resp.Location.UtcOffset = "-3.0"
+		name, offset, ok := resp.Timezone()
+		assert.True(t, ok)
+		assert.Equal(t, "", name)
+		assert.Equal(t, -3*time.Hour, offset)
+	})
+
+	t.Run("If the utc_offset is missing or can't be parsed, it should return the timezone_id with its current offset", func(t *testing.T) {
+		zone, err := time.LoadLocation("Asia/Kolkata")
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		_, expected := time.Now().In(zone).Zone()
+
+		for _, utcOffset := range []string{"", "+05:30", "unknown"} {
+			resp := &weatherstack.APIResponse{}
+			resp.Location.TimezoneID = "Asia/Kolkata"
+			resp.Location.UtcOffset = utcOffset
+			name, offset, ok := resp.Timezone()
+			assert.True(t, ok, "utc_offset %q", utcOffset)
+			assert.Equal(t, "Asia/Kolkata", name)
+			assert.Equal(t, time.Duration(expected)*time.Second, offset)
+		}
+	})
+
+	t.Run("It should not be ok if neither the utc_offset nor the timezone_id can be used", func(t *testing.T) {
+		for _, timezoneID := range []string{"", "Mars/Olympus_Mons"} {
+			resp := &weatherstack.APIResponse{}
+			resp.Location.TimezoneID = timezoneID
+			resp.Location.UtcOffset = "unknown"
+			_, _, ok := resp.Timezone()
+			assert.False(t, ok, "timezone_id %q", timezoneID)
+		}
 	})
 }
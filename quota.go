@@ -0,0 +1,125 @@
+package weatherapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+var errQuotaExceeded = errors.New("provider is over its soft limit for the billing period")
+
+// ProviderQuota is a provider's request budget per monthly billing period
+type ProviderQuota struct {
+	// Limit is the provider's hard cap, 0 is unlimited
+	Limit int
+	// SoftLimit is where the provider stops being called, defaults to Limit
+	SoftLimit int
+}
+
+// SetQuotas enables provider call accounting, calls to providers over their soft limit are skipped.
+// Providers without a limit aren't counted, saving a database round trip per call.
+func (ws *WeatherService) SetQuotas(quotas map[string]ProviderQuota) {
+	ws.quotas = quotas
+}
+
+func (q ProviderQuota) softLimit() int {
+	if q.SoftLimit > 0 {
+		return q.SoftLimit
+	}
+	return q.Limit
+}
+
+// billingPeriod is the monthly period calls are counted against
+func billingPeriod(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// reserveCall counts a call against the provider's budget, failing if it is over its soft limit. reserved reports
+// whether the call was counted and should be released if it isn't made.
+// Accounting errors are logged and the call is allowed, a database blip shouldn't stop providers being used.
+func (ws *WeatherService) reserveCall(ctx context.Context, provider string) (reserved bool, err error) {
+	limit := ws.quotas[provider].softLimit()
+	if limit <= 0 {
+		return false, nil
+	}
+
+	reserved, err = ws.store.ReserveProviderCall(ctx, provider, billingPeriod(time.Now()), limit)
+	if err != nil {
+		ws.logStoreError("ReserveProviderCall", err)
+		return false, nil
+	}
+	if !reserved {
+		return false, errQuotaExceeded
+	}
+	return true, nil
+}
+
+// releaseCall gives back a call reserved by reserveCall that wasn't made.
+// The caller's context may be what stopped the call, so releasing doesn't use it.
+func (ws *WeatherService) releaseCall(provider string) {
+	err := ws.store.ReleaseProviderCall(context.Background(), provider, billingPeriod(time.Now()))
+	if err != nil {
+		ws.logStoreError("ReleaseProviderCall", err)
+	}
+}
+
+// ProviderBudget is a provider's usage in the GetQuota api response
+type ProviderBudget struct {
+	Source    string `json:"source"`
+	Used      int    `json:"used"`
+	Limit     int    `json:"limit,omitempty"`
+	SoftLimit int    `json:"soft_limit,omitempty"`
+	Remaining *int   `json:"remaining,omitempty"`
+}
+
+// GetQuotaResponse is the struct for the GetQuota api response
+type GetQuotaResponse struct {
+	Period    string           `json:"period"`
+	Providers []ProviderBudget `json:"providers"`
+}
+
+// GetQuota is the endpoint for retrieving each provider's usage and remaining budget this billing period
+func (ws *WeatherService) GetQuota(ctx context.Context, e events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	period := billingPeriod(time.Now())
+	quota := &GetQuotaResponse{
+		Period:    period,
+		Providers: []ProviderBudget{},
+	}
+
+	for _, source := range ws.weatherSources() {
+		used, err := ws.store.GetProviderUsage(ctx, source.name, period)
+		if err != nil {
+			if ws.logger != nil {
+				ws.logger.Errorf("ws.store.GetProviderUsage error: %v\n", err)
+			}
+			return internalServerError(), nil
+		}
+
+		budget := ProviderBudget{
+			Source: source.name,
+			Used:   used,
+		}
+		if q, exist := ws.quotas[source.name]; exist && q.Limit > 0 {
+			remaining := maxInt(q.Limit-used, 0)
+			budget.Limit = q.Limit
+			budget.SoftLimit = q.softLimit()
+			budget.Remaining = &remaining
+		}
+		quota.Providers = append(quota.Providers, budget)
+	}
+
+	// Marshal resp
+	byt, err := json.Marshal(quota)
+	if err != nil {
+		if ws.logger != nil {
+			ws.logger.Errorf("json.Marshal error: %v\n", err)
+		}
+		return internalServerError(), nil
+	}
+
+	return success(string(byt)), nil
+}
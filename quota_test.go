@@ -0,0 +1,393 @@
+package weatherapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	weatherapi "github.com/TomSED/weather-api"
+	"github.com/TomSED/weather-api/mocks"
+	"github.com/TomSED/weather-api/pkg/openweathermap"
+	"github.com/TomSED/weather-api/pkg/ratelimit"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/TomSED/weather-api/pkg/storage/memory"
+	"github.com/TomSED/weather-api/pkg/weatherstack"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWeatherWithQuotas(t *testing.T) {
+
+	t.Run("If weatherstack is over its soft limit, it should skip it and use openweathermap", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return nil, nil
+			},
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				return true, nil
+			},
+			ReserveProviderCallFunc: func(ctx context.Context, provider string, period string, limit int) (bool, error) {
+				return provider != weatherapi.WeatherStackSource, nil
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				return &weatherstack.APIResponse{}, nil
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				return &openweathermap.APIResponse{}, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+		mockWeatherService.SetQuotas(map[string]weatherapi.ProviderQuota{
+			weatherapi.WeatherStackSource: {Limit: 250, SoftLimit: 240},
+		})
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Len(t, mockWeatherStackClient.GetWeatherCalls(), 0)
+		assert.Len(t, mockOpenWeatherMapClient.GetWeatherCalls(), 1)
+
+		// openweathermap has no limit so its call isn't counted
+		calls := mockStore.ReserveProviderCallCalls()
+		if !assert.Len(t, calls, 1) {
+			t.Fatal()
+		}
+		assert.Equal(t, weatherapi.WeatherStackSource, calls[0].Provider)
+		assert.Equal(t, 240, calls[0].Limit)
+		assert.Equal(t, time.Now().UTC().Format("2006-01"), calls[0].Period)
+	})
+
+	t.Run("If weatherstack is over quota, it should serve servable cached data rather than spend openweathermap's quota", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return &storage.WeatherData{
+					DataSource:  "weatherstack",
+					Temperature: 1,
+					WindSpeed:   2,
+					UpdatedDate: time.Now().Add(-30 * time.Minute)}, nil
+			},
+			ReserveProviderCallFunc: func(ctx context.Context, provider string, period string, limit int) (bool, error) {
+				return provider != weatherapi.WeatherStackSource, nil
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				return &openweathermap.APIResponse{}, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, mockStore)
+		mockWeatherService.SetQuotas(map[string]weatherapi.ProviderQuota{
+			weatherapi.WeatherStackSource:   {Limit: 250},
+			weatherapi.OpenWeatherMapSource: {Limit: 1000},
+		})
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		weather := decodeWeatherResponse(t, resp.Body)
+		assert.Equal(t, 1, weather.Temperature)
+		assert.Len(t, mockOpenWeatherMapClient.GetWeatherCalls(), 0)
+		assert.Len(t, mockStore.ReserveProviderCallCalls(), 1)
+	})
+
+	t.Run("If no provider has a limit, it shouldn't count their calls", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return nil, nil
+			},
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				return true, nil
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				return &weatherstack.APIResponse{}, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, &mocks.OpenWeatherMapClientMock{}, mockStore)
+		mockWeatherService.SetQuotas(map[string]weatherapi.ProviderQuota{
+			weatherapi.WeatherStackSource:   {},
+			weatherapi.OpenWeatherMapSource: {},
+		})
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Len(t, mockWeatherStackClient.GetWeatherCalls(), 1)
+		assert.Len(t, mockStore.ReserveProviderCallCalls(), 0)
+	})
+
+	t.Run("If every provider is over quota, it should serve stale data from the DB", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return &storage.WeatherData{
+					DataSource:  "weatherstack",
+					Temperature: 1,
+					WindSpeed:   2,
+					UpdatedDate: time.Now().Add(-10 * time.Minute)}, nil
+			},
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				return true, nil
+			},
+			ReserveProviderCallFunc: func(ctx context.Context, provider string, period string, limit int) (bool, error) {
+				return false, nil
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{}
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+		mockWeatherService.SetQuotas(map[string]weatherapi.ProviderQuota{
+			weatherapi.WeatherStackSource:   {Limit: 250},
+			weatherapi.OpenWeatherMapSource: {Limit: 1000},
+		})
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		weather := decodeWeatherResponse(t, resp.Body)
+		assert.Equal(t, 2, weather.WindSpeed)
+		assert.Equal(t, 1, weather.Temperature)
+		assert.InDelta(t, 600, weather.AgeSeconds, 5)
+		assert.Len(t, mockStore.InsertWeatherDataCalls(), 0)
+	})
+
+	t.Run("If the cached data is too old to serve, it should return a 500 response", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return &storage.WeatherData{
+					UpdatedDate: time.Now().Add(-1 * (weatherapi.MAX_STALE_SECONDS + 1) * time.Second)}, nil
+			},
+			ReserveProviderCallFunc: func(ctx context.Context, provider string, period string, limit int) (bool, error) {
+				return false, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, mockStore)
+		mockWeatherService.SetQuotas(map[string]weatherapi.ProviderQuota{
+			weatherapi.WeatherStackSource:   {Limit: 250},
+			weatherapi.OpenWeatherMapSource: {Limit: 1000},
+		})
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 500, resp.StatusCode)
+	})
+
+	t.Run("If accounting fails, it should still call the provider", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return nil, errors.New("db error")
+			},
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				return false, errors.New("db error")
+			},
+			ReserveProviderCallFunc: func(ctx context.Context, provider string, period string, limit int) (bool, error) {
+				return false, errors.New("db error")
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				return &weatherstack.APIResponse{}, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, &mocks.OpenWeatherMapClientMock{}, mockStore)
+		mockWeatherService.SetQuotas(map[string]weatherapi.ProviderQuota{
+			weatherapi.WeatherStackSource: {Limit: 250},
+		})
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Len(t, mockWeatherStackClient.GetWeatherCalls(), 1)
+	})
+
+	t.Run("If a provider is over quota, it shouldn't use up its rate limit token", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return nil, nil
+			},
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				return true, nil
+			},
+			ReserveProviderCallFunc: func(ctx context.Context, provider string, period string, limit int) (bool, error) {
+				return provider != weatherapi.WeatherStackSource, nil
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				return &openweathermap.APIResponse{}, nil
+			},
+		}
+
+		limiter := ratelimit.NewLimiter(1, time.Hour)
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, mockStore)
+		mockWeatherService.SetQuotas(map[string]weatherapi.ProviderQuota{
+			weatherapi.WeatherStackSource: {Limit: 250},
+		})
+		mockWeatherService.SetRateLimiters(map[string]*ratelimit.Limiter{
+			weatherapi.WeatherStackSource: limiter,
+		})
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.True(t, limiter.Allow())
+	})
+
+	t.Run("If a provider is rate limited, it shouldn't use up its quota", func(t *testing.T) {
+		store := memory.NewStore()
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				return &openweathermap.APIResponse{}, nil
+			},
+		}
+
+		limiter := ratelimit.NewLimiter(1, time.Hour)
+		limiter.Allow()
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, mockOpenWeatherMapClient, store)
+		mockWeatherService.SetQuotas(map[string]weatherapi.ProviderQuota{
+			weatherapi.WeatherStackSource:   {Limit: 250},
+			weatherapi.OpenWeatherMapSource: {Limit: 1000},
+		})
+		mockWeatherService.SetRateLimiters(map[string]*ratelimit.Limiter{
+			weatherapi.WeatherStackSource: limiter,
+		})
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		period := time.Now().UTC().Format("2006-01")
+		used, err := store.GetProviderUsage(context.Background(), weatherapi.WeatherStackSource, period)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, used)
+		used, err = store.GetProviderUsage(context.Background(), weatherapi.OpenWeatherMapSource, period)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, used)
+	})
+}
+
+func TestGetQuota(t *testing.T) {
+
+	t.Run("It should return each provider's usage and remaining budget", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetProviderUsageFunc: func(ctx context.Context, provider string, period string) (int, error) {
+				if provider == weatherapi.WeatherStackSource {
+					return 260, nil
+				}
+				return 12, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, mockStore)
+		mockWeatherService.SetQuotas(map[string]weatherapi.ProviderQuota{
+			weatherapi.WeatherStackSource: {Limit: 250, SoftLimit: 240},
+		})
+
+		resp, err := mockWeatherService.GetQuota(context.Background(), events.APIGatewayProxyRequest{})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		quota := &weatherapi.GetQuotaResponse{}
+		err = json.Unmarshal([]byte(resp.Body), quota)
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, time.Now().UTC().Format("2006-01"), quota.Period)
+		if !assert.Len(t, quota.Providers, 2) {
+			t.Fatal()
+		}
+		assert.Equal(t, 260, quota.Providers[0].Used)
+		assert.Equal(t, 240, quota.Providers[0].SoftLimit)
+		assert.Equal(t, 0, *quota.Providers[0].Remaining)
+		assert.Equal(t, 12, quota.Providers[1].Used)
+		assert.Nil(t, quota.Providers[1].Remaining)
+	})
+
+	t.Run("If the DB fails, it should return a 500 response", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetProviderUsageFunc: func(ctx context.Context, provider string, period string) (int, error) {
+				return 0, errors.New("db error")
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		resp, err := mockWeatherService.GetQuota(context.Background(), events.APIGatewayProxyRequest{})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 500, resp.StatusCode)
+	})
+}
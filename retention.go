@@ -0,0 +1,47 @@
+package weatherapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	DEFAULT_RAW_RETENTION = 30 * 24 * time.Hour
+)
+
+// SetRetentionPolicy overrides the default policy used by PruneWeatherData
+// (keep raw observations for 30 days, daily aggregates forever)
+func (ws *WeatherService) SetRetentionPolicy(policy storage.RetentionPolicy) {
+	ws.retention = &policy
+}
+
+// PruneWeatherData is the scheduled handler removing (and rolling up) weather history past its retention
+func (ws *WeatherService) PruneWeatherData(ctx context.Context, e events.CloudWatchEvent) (*storage.PruneResult, error) {
+	policy := storage.RetentionPolicy{
+		RawRetention: DEFAULT_RAW_RETENTION,
+		Rollup:       true,
+	}
+	if ws.retention != nil {
+		policy = *ws.retention
+	}
+
+	result, err := ws.store.PruneWeatherData(ctx, policy)
+	if err != nil {
+		if ws.logger != nil {
+			pruned := 0
+			if result != nil {
+				pruned = result.Pruned
+			}
+			ws.logger.Errorf("ws.store.PruneWeatherData error after pruning %d rows: %v\n", pruned, err)
+		}
+		return result, err
+	}
+
+	if ws.logger != nil {
+		ws.logger.Infof("Pruned %d weather rows older than %v in %d batches\n", result.Pruned, result.Cutoff, result.Batches)
+	}
+	return result, nil
+}
@@ -0,0 +1,75 @@
+package weatherapi_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	weatherapi "github.com/TomSED/weather-api"
+	"github.com/TomSED/weather-api/mocks"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneWeatherData(t *testing.T) {
+
+	t.Run("It should prune with the default policy and report the pruned rows", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			PruneWeatherDataFunc: func(ctx context.Context, policy storage.RetentionPolicy) (*storage.PruneResult, error) {
+				return &storage.PruneResult{Pruned: 12000, Batches: 3}, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		result, err := mockWeatherService.PruneWeatherData(context.Background(), events.CloudWatchEvent{})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 12000, result.Pruned)
+
+		calls := mockStore.PruneWeatherDataCalls()
+		if !assert.Len(t, calls, 1) {
+			t.Fatal()
+		}
+		assert.Equal(t, 30*24*time.Hour, calls[0].Policy.RawRetention)
+		assert.True(t, calls[0].Policy.Rollup)
+	})
+
+	t.Run("It should use the configured policy", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			PruneWeatherDataFunc: func(ctx context.Context, policy storage.RetentionPolicy) (*storage.PruneResult, error) {
+				return &storage.PruneResult{}, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, mockStore)
+		mockWeatherService.SetRetentionPolicy(storage.RetentionPolicy{RawRetention: 7 * 24 * time.Hour, BatchSize: 100})
+
+		_, err := mockWeatherService.PruneWeatherData(context.Background(), events.CloudWatchEvent{})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+
+		policy := mockStore.PruneWeatherDataCalls()[0].Policy
+		assert.Equal(t, 7*24*time.Hour, policy.RawRetention)
+		assert.Equal(t, 100, policy.BatchSize)
+		assert.False(t, policy.Rollup)
+	})
+
+	t.Run("If the DB fails, it should return the error", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			PruneWeatherDataFunc: func(ctx context.Context, policy storage.RetentionPolicy) (*storage.PruneResult, error) {
+				return &storage.PruneResult{Pruned: 5000, Batches: 1}, errors.New("db error")
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		result, err := mockWeatherService.PruneWeatherData(context.Background(), events.CloudWatchEvent{})
+		assert.NotNil(t, err)
+		assert.Equal(t, 5000, result.Pruned)
+	})
+}
@@ -0,0 +1,127 @@
+package weatherapi
+
+import (
+	"context"
+
+	"github.com/TomSED/weather-api/pkg/conditions"
+	"github.com/TomSED/weather-api/pkg/ratelimit"
+	"github.com/TomSED/weather-api/pkg/storage"
+)
+
+const (
+	WeatherStackSource   = "weatherstack"
+	OpenWeatherMapSource = "openweathermap"
+)
+
+// weatherSource is a provider queried for the current weather of a city, mapped to a database row
+type weatherSource struct {
+	name string
+	// fetch describes the weather in the language if the provider can, English if it's empty. place is where the
+	// provider placed the city with its timezone, nil if the response doesn't say.
+	fetch func(ctx context.Context, city string, language string) (weatherData *storage.WeatherData, place *storage.Location, err error)
+}
+
+// forecastSource is a provider queried for the forecast of a city, normalized for the database
+type forecastSource struct {
+	name  string
+	fetch func(ctx context.Context, city string) (*storage.Forecast, error)
+}
+
+// SetRateLimiters sets an outbound rate limiter per provider, limited calls move on to the next provider
+func (ws *WeatherService) SetRateLimiters(limiters map[string]*ratelimit.Limiter) {
+	ws.limiters = limiters
+}
+
+// beforeCall counts the call against the provider's quota then takes a rate limit token, so calls over quota don't
+// use up tokens. Calls that don't get a token give the quota back, they never reach the provider.
+func (ws *WeatherService) beforeCall(ctx context.Context, provider string) error {
+	reserved, err := ws.reserveCall(ctx, provider)
+	if err != nil {
+		return err
+	}
+	limiter, exist := ws.limiters[provider]
+	if !exist {
+		return nil
+	}
+
+	err = limiter.Take(ctx)
+	if err != nil && reserved {
+		ws.releaseCall(provider)
+	}
+	return err
+}
+
+// weatherSources returns the configured providers in failover order, calls are rate limited and counted against their quota
+func (ws *WeatherService) weatherSources() []weatherSource {
+	return []weatherSource{
+		{
+			name: WeatherStackSource,
+			fetch: func(ctx context.Context, city string, language string) (*storage.WeatherData, *storage.Location, error) {
+				err := ws.beforeCall(ctx, WeatherStackSource)
+				if err != nil {
+					return nil, nil, err
+				}
+				if !ws.weatherStackLanguage {
+					language = ""
+				}
+				resp, err := ws.weatherStackClient.GetWeather(city, providerLanguage(language))
+				if err != nil {
+					return nil, nil, err
+				}
+				return mapWeatherStackResponse(city, resp), weatherStackPlace(city, resp), nil
+			},
+		},
+		{
+			name: OpenWeatherMapSource,
+			fetch: func(ctx context.Context, city string, language string) (*storage.WeatherData, *storage.Location, error) {
+				err := ws.beforeCall(ctx, OpenWeatherMapSource)
+				if err != nil {
+					return nil, nil, err
+				}
+				resp, err := ws.openWeatherMapClient.GetWeather(city, providerLanguage(language))
+				if err != nil {
+					return nil, nil, err
+				}
+				if language == "" {
+					language = conditions.English
+				}
+				return mapOpenWeatherMapResponse(city, language, resp), openWeatherMapPlace(city, resp), nil
+			},
+		},
+	}
+}
+
+// forecastSources returns the providers with forecasts in failover order, open weathermap first as weatherstack only
+// has them on paid plans. Calls are rate limited and counted against their quota.
+func (ws *WeatherService) forecastSources() []forecastSource {
+	return []forecastSource{
+		{
+			name: OpenWeatherMapSource,
+			fetch: func(ctx context.Context, city string) (*storage.Forecast, error) {
+				err := ws.beforeCall(ctx, OpenWeatherMapSource)
+				if err != nil {
+					return nil, err
+				}
+				resp, err := ws.openWeatherMapClient.GetForecast(city, MAX_FORECAST_DAYS)
+				if err != nil {
+					return nil, err
+				}
+				return mapOpenWeatherMapForecast(city, resp), nil
+			},
+		},
+		{
+			name: WeatherStackSource,
+			fetch: func(ctx context.Context, city string) (*storage.Forecast, error) {
+				err := ws.beforeCall(ctx, WeatherStackSource)
+				if err != nil {
+					return nil, err
+				}
+				resp, err := ws.weatherStackClient.GetForecast(city, MAX_FORECAST_DAYS)
+				if err != nil {
+					return nil, err
+				}
+				return mapWeatherStackForecast(city, resp)
+			},
+		},
+	}
+}
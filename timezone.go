@@ -0,0 +1,218 @@
+package weatherapi
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// Lambda runtimes don't ship the IANA timezone database
+	_ "time/tzdata"
+
+	"github.com/TomSED/weather-api/pkg/openweathermap"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/TomSED/weather-api/pkg/weatherstack"
+)
+
+// fixedZonePrefix starts the stored timezone of a location only known by its UTC offset, e.g. "UTC+05:30"
+const fixedZonePrefix = "UTC"
+
+// cityTimezone returns the timezone to store with freshly fetched weather. The city's stored location is only read when
+// the provider placed the city, otherwise the previous row's timezone is kept. Empty if the timezone isn't known.
+func (ws *WeatherService) cityTimezone(ctx context.Context, city string, place *storage.Location, previous *storage.WeatherData) string {
+	if place == nil {
+		return previousTimezone(previous)
+	}
+
+	key := strings.ToLower(city)
+	location, err := ws.store.GetLocation(ctx, key)
+	if err != nil {
+		ws.logStoreError("GetLocation", err)
+		return place.Timezone
+	}
+	return ws.storeTimezone(ctx, key, location, place)
+}
+
+// cityTimezones sets the timezone of each city's freshly fetched weather as cityTimezone does, reading the stored
+// locations of the cities the providers placed in one batch. fetched and places are in the cities' order, nil for
+// cities that weren't fetched or placed.
+func (ws *WeatherService) cityTimezones(ctx context.Context, cities []string, fetched []*storage.WeatherData, places []*storage.Location, previous map[string]*storage.WeatherData) {
+	keys := []string{}
+	for i, city := range cities {
+		if fetched[i] != nil && places[i] != nil {
+			keys = append(keys, strings.ToLower(city))
+		}
+	}
+
+	locations := map[string]*storage.Location{}
+	var err error
+	if len(keys) > 0 {
+		locations, err = ws.store.GetLocationBatch(ctx, keys)
+		if err != nil {
+			ws.logStoreError("GetLocationBatch", err)
+		}
+	}
+
+	for i, city := range cities {
+		switch {
+		case fetched[i] == nil:
+		case places[i] == nil:
+			fetched[i].Timezone = previousTimezone(previous[city])
+		case err != nil:
+			fetched[i].Timezone = places[i].Timezone
+		default:
+			key := strings.ToLower(city)
+			fetched[i].Timezone = ws.storeTimezone(ctx, key, locations[key], places[i])
+		}
+	}
+}
+
+// previousTimezone returns the timezone stored with the city's previous weather, empty if there is none
+func previousTimezone(previous *storage.WeatherData) string {
+	if previous == nil {
+		return ""
+	}
+	return previous.Timezone
+}
+
+// storeTimezone returns the city's timezone, first storing the timezone of where the provider placed it when that tells
+// more than the stored location: a location without one, or an IANA timezone over a fixed offset. Fixed offsets are
+// replaced by newer ones as they follow daylight saving.
+func (ws *WeatherService) storeTimezone(ctx context.Context, key string, location *storage.Location, place *storage.Location) string {
+	if !replacesTimezone(location, place) {
+		if location == nil {
+			return ""
+		}
+		return location.Timezone
+	}
+
+	updated := *place
+	if location != nil {
+		// Keep the geocoded place, only its timezone is new
+		updated = *location
+		updated.Timezone = place.Timezone
+	}
+	updated.City = key
+	err := ws.store.InsertLocation(ctx, &updated)
+	if err != nil {
+		// Non-blocking error, the timezone is stored again next time
+		ws.logStoreError("InsertLocation", err)
+	}
+	return updated.Timezone
+}
+
+// replacesTimezone reports whether the provider's place has a timezone the stored location should take
+func replacesTimezone(location *storage.Location, place *storage.Location) bool {
+	switch {
+	case place == nil || place.Timezone == "":
+		return false
+	case location == nil:
+		return true
+	case location.Timezone == place.Timezone:
+		return false
+	default:
+		return location.Timezone == "" || isFixedZone(location.Timezone) || !isFixedZone(place.Timezone)
+	}
+}
+
+// weatherStackPlace returns where weatherstack placed the city with its timezone, nil if the response doesn't say both
+func weatherStackPlace(city string, resp *weatherstack.APIResponse) *storage.Location {
+	name, offset, ok := resp.Timezone()
+	if !ok {
+		return nil
+	}
+	// A place without coordinates would misplace the city for the endpoints that locate it
+	lat, err := strconv.ParseFloat(resp.Location.Lat, 64)
+	if err != nil {
+		return nil
+	}
+	lon, err := strconv.ParseFloat(resp.Location.Lon, 64)
+	if err != nil {
+		return nil
+	}
+
+	return &storage.Location{
+		City:       strings.ToLower(city),
+		Name:       resp.Location.Name,
+		Country:    resp.Location.Country,
+		Lat:        lat,
+		Lon:        lon,
+		Timezone:   providerTimezone(name, offset),
+		GeocodedAt: time.Now().UTC(),
+	}
+}
+
+// openWeatherMapPlace returns where openweathermap placed the city with its UTC offset, nil if the response doesn't say
+// both
+func openWeatherMapPlace(city string, resp *openweathermap.APIResponse) *storage.Location {
+	offset, ok := resp.UTCOffset()
+	if !ok || (resp.Coord.Lat == 0 && resp.Coord.Lon == 0) {
+		return nil
+	}
+
+	return &storage.Location{
+		City:       strings.ToLower(city),
+		Name:       resp.Name,
+		Country:    resp.Sys.Country,
+		Lat:        resp.Coord.Lat,
+		Lon:        resp.Coord.Lon,
+		Timezone:   providerTimezone("", offset),
+		GeocodedAt: time.Now().UTC(),
+	}
+}
+
+// providerTimezone returns the IANA timezone if it's known to the timezone database, otherwise the fixed offset
+func providerTimezone(name string, offset time.Duration) string {
+	if name != "" {
+		if _, err := time.LoadLocation(name); err == nil {
+			return name
+		}
+	}
+	return fixedZonePrefix + formatUTCOffset(int(offset/time.Second))
+}
+
+// loadTimezone returns the stored timezone as a time.Location, nil if it's empty or isn't one
+func loadTimezone(timezone string) *time.Location {
+	if timezone == "" {
+		return nil
+	}
+	if isFixedZone(timezone) {
+		offset, err := parseUTCOffset(strings.TrimPrefix(timezone, fixedZonePrefix))
+		if err != nil {
+			return nil
+		}
+		return time.FixedZone(timezone, offset)
+	}
+
+	zone, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil
+	}
+	return zone
+}
+
+// isFixedZone reports whether the stored timezone is a fixed offset rather than an IANA timezone
+func isFixedZone(timezone string) bool {
+	return strings.HasPrefix(timezone, fixedZonePrefix+"+") || strings.HasPrefix(timezone, fixedZonePrefix+"-")
+}
+
+// formatUTCOffset formats an offset in seconds east of UTC as ±hh:mm
+func formatUTCOffset(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	return fmt.Sprintf("%c%02d:%02d", sign, offset/3600, offset%3600/60)
+}
+
+// parseUTCOffset parses a ±hh:mm offset into seconds east of UTC
+func parseUTCOffset(offset string) (int, error) {
+	t, err := time.Parse("-07:00", offset)
+	if err != nil {
+		return 0, err
+	}
+	_, seconds := t.Zone()
+	return seconds, nil
+}
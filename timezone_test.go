@@ -0,0 +1,236 @@
+package weatherapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	weatherapi "github.com/TomSED/weather-api"
+	"github.com/TomSED/weather-api/mocks"
+	"github.com/TomSED/weather-api/pkg/openweathermap"
+	"github.com/TomSED/weather-api/pkg/storage"
+	"github.com/TomSED/weather-api/pkg/weatherstack"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// locationStore serves the cached weather and stored location, either can be nil
+func locationStore(cached *storage.WeatherData, location *storage.Location) *mocks.StoreMock {
+	mockStore := cachedStore(cached)
+	mockStore.GetLocationFunc = func(ctx context.Context, city string) (*storage.Location, error) {
+		return location, nil
+	}
+	mockStore.InsertLocationFunc = func(ctx context.Context, location *storage.Location) error {
+		return nil
+	}
+	return mockStore
+}
+
+// openWeatherMapObservation is an observation of a placed city, with the city's offset from UTC in seconds
+func openWeatherMapObservation(timezone int) *openweathermap.APIResponse {
+	resp := &openweathermap.APIResponse{Dt: 1621128600, Timezone: timezone}
+	resp.Coord.Lat = 22.5726
+	resp.Coord.Lon = 88.3639
+	return resp
+}
+
+func TestGetWeatherTimezone(t *testing.T) {
+
+	getWeather := func(t *testing.T, ws *weatherapi.WeatherService) *weatherapi.GetWeatherResponse {
+		t.Helper()
+
+		resp, err := ws.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Kolkata",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		return decodeWeatherResponse(t, resp.Body)
+	}
+
+	t.Run("It should store weatherstack's IANA timezone and render times in it", func(t *testing.T) {
+		mockStore := locationStore(nil, nil)
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				resp := &weatherstack.APIResponse{}
+				err := json.Unmarshal([]byte(`{"location":{"name":"Kolkata","country":"India","lat":"22.570","lon":"88.370","timezone_id":"Asia/Kolkata","localtime_epoch":1621148820,"utc_offset":"5.5"},"current":{"observation_time":"01:30 AM","temperature":30}}`), resp)
+				return resp, err
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		weather := getWeather(t, mockWeatherService)
+		assert.Equal(t, "Asia/Kolkata", weather.Timezone)
+		assert.Equal(t, "+05:30", weather.UTCOffset)
+		assert.Equal(t, time.Date(2021, 5, 16, 1, 30, 0, 0, time.UTC), weather.ObservedAt)
+		if assert.NotNil(t, weather.ObservedAtLocal) {
+			assert.Equal(t, "2021-05-16T07:00:00+05:30", weather.ObservedAtLocal.Format(time.RFC3339))
+		}
+		if assert.NotNil(t, weather.LocalTime) {
+			_, offset := weather.LocalTime.Zone()
+			assert.Equal(t, 5*60*60+30*60, offset)
+			assert.WithinDuration(t, time.Now(), *weather.LocalTime, 5*time.Second)
+		}
+
+		if assert.Len(t, mockStore.InsertLocationCalls(), 1) {
+			location := mockStore.InsertLocationCalls()[0].Location
+			assert.Equal(t, "kolkata", location.City)
+			assert.Equal(t, "Asia/Kolkata", location.Timezone)
+			assert.Equal(t, 22.57, location.Lat)
+			assert.Equal(t, 88.37, location.Lon)
+		}
+		if assert.Len(t, mockStore.InsertWeatherDataCalls(), 1) {
+			assert.Equal(t, "Asia/Kolkata", mockStore.InsertWeatherDataCalls()[0].WeatherData.Timezone)
+		}
+	})
+
+	t.Run("It should render cached data in the timezone stored with it, without reading the location", func(t *testing.T) {
+		cached := &storage.WeatherData{DataSource: "weatherstack", Timezone: "Asia/Kolkata", ObservedAt: time.Now(), UpdatedDate: time.Now()}
+		mockStore := cachedStore(cached)
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		weather := getWeather(t, mockWeatherService)
+		assert.Equal(t, "Asia/Kolkata", weather.Timezone)
+		assert.Equal(t, "+05:30", weather.UTCOffset)
+		assert.NotNil(t, weather.ObservedAtLocal)
+		assert.Len(t, mockStore.GetLocationCalls(), 0)
+	})
+
+	t.Run("If the provider doesn't place the city, it should keep the previous row's timezone", func(t *testing.T) {
+		stale := &storage.WeatherData{DataSource: "weatherstack", Timezone: "Asia/Kolkata", ObservedAt: time.Now().Add(-time.Hour), UpdatedDate: time.Now().Add(-time.Hour)}
+		mockStore := cachedStore(stale)
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				return &weatherstack.APIResponse{}, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		weather := getWeather(t, mockWeatherService)
+		assert.Equal(t, "Asia/Kolkata", weather.Timezone)
+		assert.Len(t, mockStore.GetLocationCalls(), 0)
+		if assert.Len(t, mockStore.InsertWeatherDataCalls(), 1) {
+			assert.Equal(t, "Asia/Kolkata", mockStore.InsertWeatherDataCalls()[0].WeatherData.Timezone)
+		}
+	})
+
+	t.Run("With only openweathermap's offset, it should add it to the geocoded location and leave out the timezone", func(t *testing.T) {
+		geocoded := &storage.Location{City: "kolkata", Name: "Kolkata", Country: "IN", Lat: 22.5726, Lon: 88.3639}
+		mockStore := locationStore(nil, geocoded)
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				return nil, errors.New("weatherstack error")
+			},
+		}
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				return openWeatherMapObservation(19800), nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+
+		weather := getWeather(t, mockWeatherService)
+		assert.Equal(t, "", weather.Timezone)
+		assert.Equal(t, "+05:30", weather.UTCOffset)
+		if assert.NotNil(t, weather.ObservedAtLocal) {
+			assert.Equal(t, "2021-05-16T07:00:00+05:30", weather.ObservedAtLocal.Format(time.RFC3339))
+		}
+
+		if assert.Len(t, mockStore.InsertLocationCalls(), 1) {
+			location := mockStore.InsertLocationCalls()[0].Location
+			assert.Equal(t, "UTC+05:30", location.Timezone)
+			assert.Equal(t, 22.5726, location.Lat)
+			assert.Equal(t, "IN", location.Country)
+		}
+	})
+
+	t.Run("It should keep a stored IANA timezone over openweathermap's offset", func(t *testing.T) {
+		mockStore := locationStore(nil, &storage.Location{City: "kolkata", Timezone: "Asia/Kolkata"})
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				return nil, errors.New("weatherstack error")
+			},
+		}
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				return openWeatherMapObservation(19800), nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+
+		weather := getWeather(t, mockWeatherService)
+		assert.Equal(t, "Asia/Kolkata", weather.Timezone)
+		assert.Len(t, mockStore.InsertLocationCalls(), 0)
+	})
+
+	t.Run("In consensus mode, it should store the IANA timezone over an offset", func(t *testing.T) {
+		mockStore := locationStore(nil, nil)
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				resp := &weatherstack.APIResponse{}
+				err := json.Unmarshal([]byte(`{"location":{"lat":"22.570","lon":"88.370","timezone_id":"Asia/Kolkata","utc_offset":"5.5"}}`), resp)
+				return resp, err
+			},
+		}
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				return openWeatherMapObservation(19800), nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+		mockWeatherService.SetConsensus(&weatherapi.ConsensusConfig{Method: weatherapi.ConsensusMedian})
+
+		weather := getWeather(t, mockWeatherService)
+		assert.Equal(t, "Asia/Kolkata", weather.Timezone)
+		if assert.Len(t, mockStore.InsertLocationCalls(), 1) {
+			assert.Equal(t, "Asia/Kolkata", mockStore.InsertLocationCalls()[0].Location.Timezone)
+		}
+	})
+
+	t.Run("If the timezone isn't known, it should leave out local times", func(t *testing.T) {
+		cached := &storage.WeatherData{DataSource: "weatherstack", ObservedAt: time.Now(), UpdatedDate: time.Now()}
+		mockStore := cachedStore(cached)
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		weather := getWeather(t, mockWeatherService)
+		assert.Equal(t, "", weather.Timezone)
+		assert.Equal(t, "", weather.UTCOffset)
+		assert.Nil(t, weather.LocalTime)
+		assert.Nil(t, weather.ObservedAtLocal)
+	})
+
+	t.Run("If the store can't be used, it should still render times in the provider's timezone", func(t *testing.T) {
+		mockStore := cachedStore(nil)
+		mockStore.GetLocationFunc = func(ctx context.Context, city string) (*storage.Location, error) {
+			return nil, storage.ErrUnavailable
+		}
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				return openWeatherMapObservation(-10800), nil
+			},
+		}
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				return nil, errors.New("weatherstack error")
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+
+		weather := getWeather(t, mockWeatherService)
+		assert.Equal(t, "-03:00", weather.UTCOffset)
+		assert.Len(t, mockStore.InsertLocationCalls(), 0)
+	})
+}
@@ -3,34 +3,50 @@ package weatherapi
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"math"
 	"time"
 
+	"github.com/TomSED/weather-api/pkg/conditions"
 	"github.com/TomSED/weather-api/pkg/openweathermap"
-	"github.com/TomSED/weather-api/pkg/postgres"
+	"github.com/TomSED/weather-api/pkg/ratelimit"
+	"github.com/TomSED/weather-api/pkg/storage"
 	"github.com/TomSED/weather-api/pkg/weatherstack"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/sirupsen/logrus"
 )
 
 const (
+	// Data is refetched at most once per CACHE_SECONDS
 	CACHE_SECONDS = 3
+	// Data is refreshed once its observation is older than this, providers publish new observations about this often
+	MAX_OBSERVATION_AGE_SECONDS = 10 * 60
+	// Cached data up to this old is served when providers can't be used
+	MAX_STALE_SECONDS = 60 * 60
 )
 
 // WeatherService provides the lambda handlers for the weather api
 type WeatherService struct {
 	weatherStackClient   WeatherStackClient
 	openWeatherMapClient OpenWeatherMapClient
-	postgresClient       PostgresClient
+	nwsClient            NWSClient
+	store                storage.Store
+	consensus            *ConsensusConfig
+	quotas               map[string]ProviderQuota
+	limiters             map[string]*ratelimit.Limiter
+	retention            *storage.RetentionPolicy
+	forecastTTL          time.Duration
+	airQualityTTL        time.Duration
+	weatherStackLanguage bool
 	logger               *logrus.Logger
 }
 
 // NewWeatherService creates a new WeatherService
-func NewWeatherService(weatherStackClient WeatherStackClient, openWeatherMapClient OpenWeatherMapClient, postgresClient PostgresClient) *WeatherService {
+func NewWeatherService(weatherStackClient WeatherStackClient, openWeatherMapClient OpenWeatherMapClient, store storage.Store) *WeatherService {
 	return &WeatherService{
 		weatherStackClient:   weatherStackClient,
 		openWeatherMapClient: openWeatherMapClient,
-		postgresClient:       postgresClient,
+		store:                store,
 	}
 }
 
@@ -42,10 +58,30 @@ func (ws *WeatherService) SetLogger(logger *logrus.Logger) {
 type GetWeatherResponse struct {
 	WindSpeed   int `json:"wind_speed"`
 	Temperature int `json:"temperature_degrees"`
+	// Humidity is the relative humidity in percent, left out when the provider didn't report it
+	Humidity *int     `json:"humidity,omitempty"`
+	Comfort  *Comfort `json:"comfort"`
+	// Condition is the canonical condition, with its description and icon key, left out when unknown
+	Condition     string `json:"condition,omitempty"`
+	ConditionText string `json:"condition_text,omitempty"`
+	Icon          string `json:"icon,omitempty"`
+	// Language is the language condition_text is in
+	Language string `json:"language"`
+	// ObservedAt is when the provider observed the weather, in UTC and the location's local time, AgeSeconds how long
+	// ago that was
+	ObservedAt      time.Time  `json:"observed_at"`
+	ObservedAtLocal *time.Time `json:"observed_at_local,omitempty"`
+	AgeSeconds      int        `json:"age_seconds"`
+	// Timezone is the location's IANA timezone, LocalTime the current time there and UTCOffset its current offset
+	// (e.g. "+10:00"). Locations only known by their offset have no Timezone, these and ObservedAtLocal are left out
+	// when neither is known.
+	Timezone  string     `json:"timezone,omitempty"`
+	LocalTime *time.Time `json:"local_time,omitempty"`
+	UTCOffset string     `json:"utc_offset,omitempty"`
 }
 
 // GetWeather is the endpoint for retrieving current temperature and windspeed of a city (via query params city=sydney)
-// Weather sources uses weather stack with a failover of open weathermap
+// Weather sources uses weather stack with a failover of open weathermap, or a blend of both in consensus mode
 func (ws *WeatherService) GetWeather(ctx context.Context, e events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 
 	// Validate input
@@ -56,46 +92,41 @@ func (ws *WeatherService) GetWeather(ctx context.Context, e events.APIGatewayPro
 		}
 		return badRequest("Missing city in query parameter"), nil
 	}
+	language, ok := requestLanguage(e)
+	if !ok {
+		if ws.logger != nil {
+			ws.logger.Errorf(`Invalid e.QueryStringParameters["lang"]: %v\n`, e.QueryStringParameters["lang"])
+		}
+		return badRequest("Invalid lang in query parameter"), nil
+	}
 
 	// Try querying DB
-	weatherData, err := ws.postgresClient.GetLatestWeatherData(city)
-	if err != nil && ws.logger != nil {
-		ws.logger.Errorf("ws.postgresClient.GetLatestWeatherData error: %v\n", err)
-	}
-	// Check if weather data is up to date
-	if err != nil || needsToBeUpdated(weatherData) {
-		// Try weatherstack
-		weatherStackResp, err := ws.weatherStackClient.GetWeather(city)
-		if err != nil {
-			if ws.logger != nil {
-				ws.logger.Errorf("ws.weatherStackClient.GetWeather error: %v\n", err)
-			}
-
-			// Try openweathermap
-			openWeatherMapResp, err := ws.openWeatherMapClient.GetWeather(city)
-			if err != nil {
-				if ws.logger != nil {
-					ws.logger.Errorf("ws.openWeatherMapClient.GetWeather error: %v\n", err)
-				}
-				return internalServerError(), nil
-			}
-			weatherData = mapOpenWeatherMapResponse(city, openWeatherMapResp)
-
-		} else {
-			weatherData = mapWeatherStackResponse(city, weatherStackResp)
-		}
+	cached, err := ws.store.GetLatestWeatherData(ctx, city)
+	if err != nil {
+		ws.logStoreError("GetLatestWeatherData", err)
+		cached = nil
+	}
+
+	weatherData, place, fetched, err := ws.currentWeather(ctx, city, language, cached)
+	if err != nil {
+		return internalServerError(), nil
+	}
+	if fetched {
+		weatherData.Timezone = ws.cityTimezone(ctx, city, place, cached)
 
 		// Update db
-		err = ws.postgresClient.InsertWeatherData(weatherData)
-		if err != nil && ws.logger != nil {
-			ws.logger.Errorf("ws.openWeatherMapClient.GetWeather error: %v\n", err)
+		inserted, err := ws.store.InsertWeatherData(ctx, weatherData)
+		if err != nil {
 			// Non-blocking error, do not need to return a http error, just log error
+			ws.logStoreError("InsertWeatherData", err)
+		} else if !inserted && ws.logger != nil {
+			ws.logger.Debugf("%s observation of %s at %v already stored\n", weatherData.DataSource, city, weatherData.ObservationTime())
 		}
 	}
 
 	// Prepare http response
 	var weather *GetWeatherResponse
-	weather = mapWeatherData(weatherData)
+	weather = mapWeatherData(weatherData, language)
 
 	// Marshal resp
 	byt, err := json.Marshal(weather)
@@ -110,51 +141,186 @@ func (ws *WeatherService) GetWeather(ctx context.Context, e events.APIGatewayPro
 	return success(apiResponseBody), nil
 }
 
-func needsToBeUpdated(weatherData *postgres.WeatherData) bool {
+// currentWeather returns the cached data while it's fresh, otherwise data fetched from the providers. If they can't be
+// used, e.g. when over quota, stale cached data is served while it's young enough. fetched reports whether the data came
+// from the providers and should be stored, place is where they placed the city (nil for cached data).
+func (ws *WeatherService) currentWeather(ctx context.Context, city string, language string, cached *storage.WeatherData) (weatherData *storage.WeatherData, place *storage.Location, fetched bool, err error) {
+	if !needsToBeUpdated(cached) {
+		return cached, nil, false, nil
+	}
+
+	if ws.consensus != nil {
+		weatherData, place, err = ws.getConsensusWeather(ctx, city, language)
+	} else {
+		weatherData, place, err = ws.getFailoverWeather(ctx, city, language, cached)
+	}
+	if err == nil {
+		return weatherData, place, true, nil
+	}
+
+	if servableWhenStale(cached) {
+		return cached, nil, false, nil
+	}
+	return nil, nil, false, err
+}
+
+// getFailoverWeather tries each provider in order, returning the first successful response. Once a provider is over
+// quota it stops while the cached data is servable, rather than spending the next provider's quota.
+func (ws *WeatherService) getFailoverWeather(ctx context.Context, city string, language string, cached *storage.WeatherData) (*storage.WeatherData, *storage.Location, error) {
+	var err error
+	for _, source := range ws.weatherSources() {
+		var weatherData *storage.WeatherData
+		var place *storage.Location
+		weatherData, place, err = source.fetch(ctx, city, language)
+		if err == nil {
+			return weatherData, place, nil
+		}
+		if ws.logger != nil {
+			ws.logger.Errorf("%s GetWeather error: %v\n", source.name, err)
+		}
+		if errors.Is(err, errQuotaExceeded) && servableWhenStale(cached) {
+			return nil, nil, err
+		}
+	}
+	return nil, nil, err
+}
+
+// logStoreError logs a failed store call. While the store is unavailable the service runs without its cache, which is
+// only worth a warning.
+func (ws *WeatherService) logStoreError(method string, err error) {
+	if ws.logger == nil {
+		return
+	}
+	if errors.Is(err, storage.ErrUnavailable) {
+		ws.logger.Warnf("ws.store.%s skipped, running without cache: %v\n", method, err)
+		return
+	}
+	ws.logger.Errorf("ws.store.%s error: %v\n", method, err)
+}
+
+// needsToBeUpdated reports whether the observation is older than MAX_OBSERVATION_AGE_SECONDS, or for data without an
+// observation time whether it was fetched more than CACHE_SECONDS ago. Data fetched within the last CACHE_SECONDS isn't
+// refetched however old its observation, the providers won't have anything newer yet.
+func needsToBeUpdated(weatherData *storage.WeatherData) bool {
 	if weatherData == nil {
 		return true
 	}
 
 	now := time.Now().UTC()
-	duration := now.Sub(weatherData.UpdatedDate.UTC())
-
-	if duration > CACHE_SECONDS*time.Second {
+	if now.Sub(weatherData.UpdatedDate.UTC()) <= CACHE_SECONDS*time.Second {
+		return false
+	}
+	if weatherData.ObservedAt.IsZero() {
 		return true
 	}
 
-	return false
+	return now.Sub(weatherData.ObservedAt.UTC()) > MAX_OBSERVATION_AGE_SECONDS*time.Second
 }
 
-// mapWeatherStackResponse extracts windspeed & temperature from weatherstack.APIResponse
-func mapWeatherData(data *postgres.WeatherData) *GetWeatherResponse {
-	return &GetWeatherResponse{
-		WindSpeed:   data.WindSpeed,
-		Temperature: data.Temperature,
+// servableWhenStale reports whether cached data can still be served when it can't be refreshed
+func servableWhenStale(weatherData *storage.WeatherData) bool {
+	if weatherData == nil {
+		return false
+	}
+
+	duration := time.Now().UTC().Sub(weatherData.ObservationTime().UTC())
+	return duration <= MAX_STALE_SECONDS*time.Second
+}
+
+// mapWeatherData extracts windspeed, temperature, humidity, condition & observation time from storage.WeatherData,
+// deriving the comfort metrics, describing the condition in the language and rendering times in the stored timezone
+// (left out when it's empty)
+func mapWeatherData(data *storage.WeatherData, language string) *GetWeatherResponse {
+	observedAt := data.ObservationTime().UTC()
+
+	// Provider clocks can be slightly ahead
+	age := time.Now().UTC().Sub(observedAt)
+	if age < 0 {
+		age = 0
 	}
+
+	var humidity *int
+	if data.Humidity > 0 {
+		h := data.Humidity
+		humidity = &h
+	}
+
+	condition := conditions.Condition(data.Condition)
+	conditionText, language := describeCondition(data, language)
+
+	weather := &GetWeatherResponse{
+		WindSpeed:     data.WindSpeed,
+		Temperature:   data.Temperature,
+		Humidity:      humidity,
+		Comfort:       mapComfort(data),
+		Condition:     string(condition),
+		ConditionText: conditionText,
+		Icon:          condition.Icon(data.Night),
+		Language:      language,
+		ObservedAt:    observedAt,
+		AgeSeconds:    int(age / time.Second),
+	}
+
+	if zone := loadTimezone(data.Timezone); zone != nil {
+		observedAtLocal := observedAt.In(zone)
+		localTime := time.Now().In(zone).Truncate(time.Second)
+		_, offset := localTime.Zone()
+
+		weather.ObservedAtLocal = &observedAtLocal
+		weather.LocalTime = &localTime
+		weather.UTCOffset = formatUTCOffset(offset)
+		if !isFixedZone(data.Timezone) {
+			weather.Timezone = data.Timezone
+		}
+	}
+	return weather
 }
 
-// mapWeatherStackResponse extracts windspeed & temperature from weatherstack.APIResponse
-func mapWeatherStackResponse(city string, resp *weatherstack.APIResponse) *postgres.WeatherData {
-	return &postgres.WeatherData{
-		DataSource:  "weatherstack",
-		City:        city,
-		WindSpeed:   resp.Current.WindSpeed,
-		Temperature: resp.Current.Temperature,
-		UpdatedDate: time.Now().UTC(),
+// mapWeatherStackResponse extracts windspeed, temperature, humidity & condition from weatherstack.APIResponse
+func mapWeatherStackResponse(city string, resp *weatherstack.APIResponse) *storage.WeatherData {
+	description, language := "", ""
+	if len(resp.Current.WeatherDescriptions) > 0 {
+		description, language = resp.Current.WeatherDescriptions[0], resp.Request.Language
+	}
+
+	return &storage.WeatherData{
+		DataSource:          WeatherStackSource,
+		City:                city,
+		WindSpeed:           resp.Current.WindSpeed,
+		Temperature:         resp.Current.Temperature,
+		Humidity:            resp.Current.Humidity,
+		Condition:           string(conditions.FromWeatherStack(resp.Current.WeatherCode)),
+		Night:               resp.Night(),
+		Description:         description,
+		DescriptionLanguage: language,
+		UpdatedDate:         time.Now().UTC(),
+		ObservedAt:          resp.ObservedAt(),
 	}
 }
 
-// mapOpenWeatherMapResponse extracts windspeed & temperature from openweathermap.APIResponse
-func mapOpenWeatherMapResponse(city string, resp *openweathermap.APIResponse) *postgres.WeatherData {
+// mapOpenWeatherMapResponse extracts windspeed, temperature, humidity & condition from openweathermap.APIResponse
+// Responses are in metric units, wind speed is converted from m/s to km/h to match weatherstack. language is the
+// language the weather was requested in.
+func mapOpenWeatherMapResponse(city string, language string, resp *openweathermap.APIResponse) *storage.WeatherData {
 
 	temp := int(math.Round(resp.Main.Temp))
-	windSpeed := int(math.Round(resp.Wind.Speed))
-
-	return &postgres.WeatherData{
-		DataSource:  "openweathermap",
-		City:        city,
-		WindSpeed:   temp,
-		Temperature: windSpeed,
-		UpdatedDate: time.Now(),
+	windSpeed := int(math.Round(resp.Wind.Speed * 3.6))
+	description := ""
+	if len(resp.Weather) > 0 {
+		description = resp.Weather[0].Description
+	}
+
+	return &storage.WeatherData{
+		DataSource:          OpenWeatherMapSource,
+		City:                city,
+		WindSpeed:           windSpeed,
+		Temperature:         temp,
+		Humidity:            resp.Main.Humidity,
+		Condition:           string(conditions.FromOpenWeatherMap(resp.ConditionID())),
+		Night:               resp.Night(),
+		Description:         description,
+		DescriptionLanguage: language,
+		UpdatedDate:         time.Now().UTC(),
+		ObservedAt:          resp.ObservedAt(),
 	}
 }
@@ -2,14 +2,17 @@ package weatherapi_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	weatherapi "github.com/TomSED/weather-api"
 	"github.com/TomSED/weather-api/mocks"
 	"github.com/TomSED/weather-api/pkg/openweathermap"
-	"github.com/TomSED/weather-api/pkg/postgres"
+	"github.com/TomSED/weather-api/pkg/ratelimit"
+	"github.com/TomSED/weather-api/pkg/storage"
 	"github.com/TomSED/weather-api/pkg/weatherstack"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/stretchr/testify/assert"
@@ -18,21 +21,21 @@ import (
 func TestGetWeatherWithDB(t *testing.T) {
 	t.Run("If DB success and data is up to date, it should return data from DB", func(t *testing.T) {
 
-		mockPostgresClient := &mocks.PostgresClientMock{
-			GetLatestWeatherDataFunc: func(city string) (*postgres.WeatherData, error) {
-				return &postgres.WeatherData{
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return &storage.WeatherData{
 					DataSource:  "datasource",
 					Temperature: 1,
 					WindSpeed:   2,
 					UpdatedDate: time.Now()}, nil
 			},
-			InsertWeatherDataFunc: func(in1 *postgres.WeatherData) error {
-				return nil
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				return true, nil
 			},
 		}
 
 		mockWeatherStackClient := &mocks.WeatherStackClientMock{
-			GetWeatherFunc: func(city string) (*weatherstack.APIResponse, error) {
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
 				resp := &weatherstack.APIResponse{}
 				resp.Current.Temperature = 10
 				resp.Current.WindSpeed = 11
@@ -41,12 +44,12 @@ func TestGetWeatherWithDB(t *testing.T) {
 		}
 
 		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
-			GetWeatherFunc: func(city string) (*openweathermap.APIResponse, error) {
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
 				return nil, errors.New("openweathermap error")
 			},
 		}
 
-		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockPostgresClient)
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
 
 		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
 			QueryStringParameters: map[string]string{
@@ -57,28 +60,28 @@ func TestGetWeatherWithDB(t *testing.T) {
 			t.Fatal(err)
 		}
 		assert.Equal(t, 200, resp.StatusCode)
-		assert.Len(t, mockPostgresClient.GetLatestWeatherDataCalls(), 1)
+		assert.Len(t, mockStore.GetLatestWeatherDataCalls(), 1)
 		assert.Len(t, mockWeatherStackClient.GetWeatherCalls(), 0)
 		assert.Len(t, mockOpenWeatherMapClient.GetWeatherCalls(), 0)
 	})
 
 	t.Run("If DB success but data is out of date, it should use weatherstack", func(t *testing.T) {
 
-		mockPostgresClient := &mocks.PostgresClientMock{
-			GetLatestWeatherDataFunc: func(city string) (*postgres.WeatherData, error) {
-				return &postgres.WeatherData{
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return &storage.WeatherData{
 					DataSource:  "datasource",
 					Temperature: 1,
 					WindSpeed:   2,
 					UpdatedDate: time.Now().Add(-1 * ((weatherapi.CACHE_SECONDS + 1) * time.Second))}, nil
 			},
-			InsertWeatherDataFunc: func(in1 *postgres.WeatherData) error {
-				return nil
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				return true, nil
 			},
 		}
 
 		mockWeatherStackClient := &mocks.WeatherStackClientMock{
-			GetWeatherFunc: func(city string) (*weatherstack.APIResponse, error) {
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
 				resp := &weatherstack.APIResponse{}
 				resp.Current.Temperature = 10
 				resp.Current.WindSpeed = 11
@@ -87,12 +90,12 @@ func TestGetWeatherWithDB(t *testing.T) {
 		}
 
 		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
-			GetWeatherFunc: func(city string) (*openweathermap.APIResponse, error) {
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
 				return nil, errors.New("openweathermap error")
 			},
 		}
 
-		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockPostgresClient)
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
 
 		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
 			QueryStringParameters: map[string]string{
@@ -103,7 +106,7 @@ func TestGetWeatherWithDB(t *testing.T) {
 			t.Fatal(err)
 		}
 		assert.Equal(t, 200, resp.StatusCode)
-		assert.Len(t, mockPostgresClient.GetLatestWeatherDataCalls(), 1)
+		assert.Len(t, mockStore.GetLatestWeatherDataCalls(), 1)
 		assert.Len(t, mockWeatherStackClient.GetWeatherCalls(), 1)
 		assert.Len(t, mockOpenWeatherMapClient.GetWeatherCalls(), 0)
 
@@ -111,17 +114,17 @@ func TestGetWeatherWithDB(t *testing.T) {
 
 	t.Run("If DB returns no data, it should use weatherstack", func(t *testing.T) {
 
-		mockPostgresClient := &mocks.PostgresClientMock{
-			GetLatestWeatherDataFunc: func(city string) (*postgres.WeatherData, error) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
 				return nil, nil
 			},
-			InsertWeatherDataFunc: func(in1 *postgres.WeatherData) error {
-				return nil
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				return true, nil
 			},
 		}
 
 		mockWeatherStackClient := &mocks.WeatherStackClientMock{
-			GetWeatherFunc: func(city string) (*weatherstack.APIResponse, error) {
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
 				resp := &weatherstack.APIResponse{}
 				resp.Current.Temperature = 10
 				resp.Current.WindSpeed = 11
@@ -130,12 +133,12 @@ func TestGetWeatherWithDB(t *testing.T) {
 		}
 
 		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
-			GetWeatherFunc: func(city string) (*openweathermap.APIResponse, error) {
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
 				return nil, errors.New("openweathermap error")
 			},
 		}
 
-		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockPostgresClient)
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
 
 		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
 			QueryStringParameters: map[string]string{
@@ -146,26 +149,66 @@ func TestGetWeatherWithDB(t *testing.T) {
 			t.Fatal(err)
 		}
 		assert.Equal(t, 200, resp.StatusCode)
-		assert.Len(t, mockPostgresClient.GetLatestWeatherDataCalls(), 1)
+		assert.Len(t, mockStore.GetLatestWeatherDataCalls(), 1)
 		assert.Len(t, mockWeatherStackClient.GetWeatherCalls(), 1)
 		assert.Len(t, mockOpenWeatherMapClient.GetWeatherCalls(), 0)
 	})
 
+	t.Run("If the DB is unavailable, it should serve weatherstack without the cache", func(t *testing.T) {
+
+		unavailable := fmt.Errorf("postgres: %w after dial tcp: connection refused", storage.ErrUnavailable)
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return nil, unavailable
+			},
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				return false, unavailable
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				resp := &weatherstack.APIResponse{}
+				resp.Current.Temperature = 10
+				resp.Current.WindSpeed = 11
+				return resp, nil
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		weather := decodeWeatherResponse(t, resp.Body)
+		assert.Equal(t, 11, weather.WindSpeed)
+		assert.Equal(t, 10, weather.Temperature)
+		assert.Len(t, mockWeatherStackClient.GetWeatherCalls(), 1)
+	})
+
 }
 func TestGetWeatherWithAPI(t *testing.T) {
 
 	t.Run("If weatherstack succeeds, it should use weather stack", func(t *testing.T) {
-		mockPostgresClient := &mocks.PostgresClientMock{
-			GetLatestWeatherDataFunc: func(city string) (*postgres.WeatherData, error) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
 				return nil, errors.New("db error")
 			},
-			InsertWeatherDataFunc: func(in1 *postgres.WeatherData) error {
-				return nil
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				return true, nil
 			},
 		}
 
 		mockWeatherStackClient := &mocks.WeatherStackClientMock{
-			GetWeatherFunc: func(city string) (*weatherstack.APIResponse, error) {
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
 				resp := &weatherstack.APIResponse{}
 				resp.Current.Temperature = 10
 				resp.Current.WindSpeed = 11
@@ -174,12 +217,12 @@ func TestGetWeatherWithAPI(t *testing.T) {
 		}
 
 		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
-			GetWeatherFunc: func(city string) (*openweathermap.APIResponse, error) {
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
 				return nil, errors.New("openweathermap error")
 			},
 		}
 
-		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockPostgresClient)
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
 
 		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
 			QueryStringParameters: map[string]string{
@@ -195,24 +238,24 @@ func TestGetWeatherWithAPI(t *testing.T) {
 	})
 
 	t.Run("If weatherstack fails, it should use openweather map", func(t *testing.T) {
-		mockPostgresClient := &mocks.PostgresClientMock{
-			GetLatestWeatherDataFunc: func(city string) (*postgres.WeatherData, error) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
 				return nil, errors.New("db error")
 			},
-			InsertWeatherDataFunc: func(in1 *postgres.WeatherData) error {
-				return nil
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				return true, nil
 			},
 		}
 
 		mockWeatherStackClient := &mocks.WeatherStackClientMock{
-			GetWeatherFunc: func(city string) (*weatherstack.APIResponse, error) {
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
 				return nil, errors.New("weatherstack error")
 
 			},
 		}
 
 		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
-			GetWeatherFunc: func(city string) (*openweathermap.APIResponse, error) {
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
 				resp := &openweathermap.APIResponse{}
 				resp.Main.Temp = 10
 				resp.Wind.Speed = 11
@@ -220,7 +263,7 @@ func TestGetWeatherWithAPI(t *testing.T) {
 			},
 		}
 
-		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockPostgresClient)
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
 
 		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
 			QueryStringParameters: map[string]string{
@@ -235,29 +278,73 @@ func TestGetWeatherWithAPI(t *testing.T) {
 		assert.Len(t, mockOpenWeatherMapClient.GetWeatherCalls(), 1)
 	})
 
+	t.Run("If weatherstack is rate limited, it should use openweather map", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return nil, nil
+			},
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				return true, nil
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				return &weatherstack.APIResponse{}, nil
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				return &openweathermap.APIResponse{}, nil
+			},
+		}
+
+		// Use up the only token
+		limiter := ratelimit.NewLimiter(1, time.Hour)
+		limiter.Allow()
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+		mockWeatherService.SetRateLimiters(map[string]*ratelimit.Limiter{
+			weatherapi.WeatherStackSource: limiter,
+		})
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Len(t, mockWeatherStackClient.GetWeatherCalls(), 0)
+		assert.Len(t, mockOpenWeatherMapClient.GetWeatherCalls(), 1)
+	})
+
 	t.Run("If both data sources fail, it should return a 500 response", func(t *testing.T) {
-		mockPostgresClient := &mocks.PostgresClientMock{
-			GetLatestWeatherDataFunc: func(city string) (*postgres.WeatherData, error) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
 				return nil, errors.New("db error")
 			},
-			InsertWeatherDataFunc: func(in1 *postgres.WeatherData) error {
-				return nil
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				return true, nil
 			},
 		}
 
 		mockWeatherStackClient := &mocks.WeatherStackClientMock{
-			GetWeatherFunc: func(city string) (*weatherstack.APIResponse, error) {
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
 				return nil, errors.New("weatherstack error")
 			},
 		}
 
 		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
-			GetWeatherFunc: func(city string) (*openweathermap.APIResponse, error) {
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
 				return nil, errors.New("openweathermap error")
 			},
 		}
 
-		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockPostgresClient)
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
 
 		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
 			QueryStringParameters: map[string]string{
@@ -273,17 +360,17 @@ func TestGetWeatherWithAPI(t *testing.T) {
 	})
 
 	t.Run("If no city in query provided, it should return a 400 error", func(t *testing.T) {
-		mockPostgresClient := &mocks.PostgresClientMock{
-			GetLatestWeatherDataFunc: func(city string) (*postgres.WeatherData, error) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
 				return nil, errors.New("db error")
 			},
-			InsertWeatherDataFunc: func(in1 *postgres.WeatherData) error {
-				return nil
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				return true, nil
 			},
 		}
 
 		mockWeatherStackClient := &mocks.WeatherStackClientMock{
-			GetWeatherFunc: func(city string) (*weatherstack.APIResponse, error) {
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
 				resp := &weatherstack.APIResponse{}
 				resp.Current.Temperature = 10
 				resp.Current.WindSpeed = 11
@@ -292,7 +379,7 @@ func TestGetWeatherWithAPI(t *testing.T) {
 		}
 
 		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
-			GetWeatherFunc: func(city string) (*openweathermap.APIResponse, error) {
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
 				resp := &openweathermap.APIResponse{}
 				resp.Main.Temp = 10
 				resp.Wind.Speed = 11
@@ -300,7 +387,7 @@ func TestGetWeatherWithAPI(t *testing.T) {
 			},
 		}
 
-		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockPostgresClient)
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
 
 		// city == ""
 		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
@@ -327,3 +414,559 @@ func TestGetWeatherWithAPI(t *testing.T) {
 		assert.Len(t, mockOpenWeatherMapClient.GetWeatherCalls(), 0)
 	})
 }
+
+func TestGetWeatherConsensus(t *testing.T) {
+
+	t.Run("If consensus is enabled, it should query both providers and store the median", func(t *testing.T) {
+		var inserted *storage.WeatherData
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return nil, nil
+			},
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				inserted = in1
+				return true, nil
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				resp := &weatherstack.APIResponse{}
+				resp.Current.Temperature = 10
+				resp.Current.WindSpeed = 20
+				return resp, nil
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				resp := &openweathermap.APIResponse{}
+				resp.Main.Temp = 14
+				resp.Wind.Speed = 10 // m/s
+				return resp, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+		mockWeatherService.SetConsensus(&weatherapi.ConsensusConfig{Method: weatherapi.ConsensusMedian})
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		weather := decodeWeatherResponse(t, resp.Body)
+		assert.Equal(t, 28, weather.WindSpeed)
+		assert.Equal(t, 12, weather.Temperature)
+		assert.Len(t, mockWeatherStackClient.GetWeatherCalls(), 1)
+		assert.Len(t, mockOpenWeatherMapClient.GetWeatherCalls(), 1)
+		if !assert.NotNil(t, inserted) {
+			t.Fatal()
+		}
+		assert.Equal(t, weatherapi.ConsensusDataSource, inserted.DataSource)
+		assert.Equal(t, []string{weatherapi.WeatherStackSource, weatherapi.OpenWeatherMapSource}, inserted.Sources)
+	})
+
+	t.Run("If weighted, it should use the configured weights", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return nil, nil
+			},
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				return true, nil
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				resp := &weatherstack.APIResponse{}
+				resp.Current.Temperature = 10
+				resp.Current.WindSpeed = 36
+				return resp, nil
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				resp := &openweathermap.APIResponse{}
+				resp.Main.Temp = 14
+				resp.Wind.Speed = 0
+				return resp, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+		mockWeatherService.SetConsensus(&weatherapi.ConsensusConfig{
+			Method:  weatherapi.ConsensusWeighted,
+			Weights: map[string]float64{weatherapi.WeatherStackSource: 3},
+		})
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		weather := decodeWeatherResponse(t, resp.Body)
+		assert.Equal(t, 27, weather.WindSpeed)
+		assert.Equal(t, 11, weather.Temperature)
+	})
+
+	t.Run("If one provider fails, it should use the remaining provider", func(t *testing.T) {
+		var inserted *storage.WeatherData
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return nil, nil
+			},
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				inserted = in1
+				return true, nil
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				return nil, errors.New("weatherstack error")
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				resp := &openweathermap.APIResponse{}
+				resp.Main.Temp = 14
+				resp.Wind.Speed = 5
+				return resp, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+		mockWeatherService.SetConsensus(&weatherapi.ConsensusConfig{Method: weatherapi.ConsensusMedian})
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		weather := decodeWeatherResponse(t, resp.Body)
+		assert.Equal(t, 18, weather.WindSpeed)
+		assert.Equal(t, 14, weather.Temperature)
+		assert.Equal(t, []string{weatherapi.OpenWeatherMapSource}, inserted.Sources)
+	})
+
+	t.Run("If every provider fails, it should return a 500 response", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return nil, nil
+			},
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				return true, nil
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				return nil, errors.New("weatherstack error")
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				return nil, errors.New("openweathermap error")
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+		mockWeatherService.SetConsensus(&weatherapi.ConsensusConfig{Method: weatherapi.ConsensusMedian})
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 500, resp.StatusCode)
+		assert.Len(t, mockStore.InsertWeatherDataCalls(), 0)
+	})
+}
+
+func TestGetWeatherObservationTime(t *testing.T) {
+
+	t.Run("It should store and return the provider's observation time and age", func(t *testing.T) {
+		observedAt := time.Now().UTC().Add(-5 * time.Minute).Truncate(time.Second)
+		var inserted *storage.WeatherData
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return nil, nil
+			},
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				inserted = in1
+				return true, nil
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				return nil, errors.New("weatherstack error")
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				resp := &openweathermap.APIResponse{}
+				resp.Dt = int(observedAt.Unix())
+				return resp, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		weather := decodeWeatherResponse(t, resp.Body)
+		assert.True(t, observedAt.Equal(weather.ObservedAt))
+		assert.InDelta(t, 300, weather.AgeSeconds, 5)
+		if !assert.NotNil(t, inserted) {
+			t.Fatal()
+		}
+		assert.True(t, observedAt.Equal(inserted.ObservedAt))
+	})
+
+	t.Run("It should refresh data by the age of its observation rather than when it was fetched", func(t *testing.T) {
+		for _, c := range []struct {
+			observedAgo time.Duration
+			refreshed   bool
+		}{
+			{observedAgo: 5 * time.Minute, refreshed: false},
+			{observedAgo: 30 * time.Minute, refreshed: true},
+		} {
+			mockStore := &mocks.StoreMock{
+				GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+					return &storage.WeatherData{
+						DataSource:  "weatherstack",
+						Temperature: 1,
+						WindSpeed:   2,
+						UpdatedDate: time.Now().Add(-time.Minute),
+						ObservedAt:  time.Now().Add(-c.observedAgo)}, nil
+				},
+				InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+					return true, nil
+				},
+			}
+			mockWeatherStackClient := &mocks.WeatherStackClientMock{
+				GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+					return &weatherstack.APIResponse{}, nil
+				},
+			}
+			mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+			resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+				QueryStringParameters: map[string]string{
+					"city": "Sydney",
+				},
+			})
+			if !assert.Nil(t, err) {
+				t.Fatal(err)
+			}
+			assert.Equal(t, 200, resp.StatusCode)
+			if c.refreshed {
+				assert.Len(t, mockWeatherStackClient.GetWeatherCalls(), 1, "observed %v ago", c.observedAgo)
+			} else {
+				assert.Len(t, mockWeatherStackClient.GetWeatherCalls(), 0, "observed %v ago", c.observedAgo)
+			}
+		}
+	})
+
+	t.Run("If the data was just fetched, it should serve it however old the observation is", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return &storage.WeatherData{
+					DataSource:  "weatherstack",
+					Temperature: 1,
+					WindSpeed:   2,
+					UpdatedDate: time.Now(),
+					ObservedAt:  time.Now().Add(-20 * time.Minute)}, nil
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{}
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.InDelta(t, 1200, decodeWeatherResponse(t, resp.Body).AgeSeconds, 5)
+		assert.Len(t, mockWeatherStackClient.GetWeatherCalls(), 0)
+	})
+
+	t.Run("If the observation is too old to serve, it should return a 500 response even though it was fetched recently", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return &storage.WeatherData{
+					DataSource:  "weatherstack",
+					UpdatedDate: time.Now().Add(-time.Minute),
+					ObservedAt:  time.Now().Add(-1 * (weatherapi.MAX_STALE_SECONDS + 60) * time.Second)}, nil
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				return nil, errors.New("weatherstack error")
+			},
+		}
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				return nil, errors.New("openweathermap error")
+			},
+		}
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 500, resp.StatusCode)
+	})
+}
+
+func TestGetWeatherComfort(t *testing.T) {
+
+	t.Run("It should store the provider's humidity and return the comfort metrics derived from it", func(t *testing.T) {
+		var inserted *storage.WeatherData
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return nil, nil
+			},
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				inserted = in1
+				return true, nil
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				resp := &weatherstack.APIResponse{}
+				resp.Current.Temperature = 32
+				resp.Current.WindSpeed = 15
+				resp.Current.Humidity = 70
+				return resp, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		if !assert.NotNil(t, inserted) {
+			t.Fatal()
+		}
+		assert.Equal(t, 70, inserted.Humidity)
+
+		weather := decodeWeatherResponse(t, resp.Body)
+		if assert.NotNil(t, weather.Humidity) {
+			assert.Equal(t, 70, *weather.Humidity)
+		}
+		if !assert.NotNil(t, weather.Comfort) {
+			t.Fatal()
+		}
+		if assert.NotNil(t, weather.Comfort.HeatIndex) {
+			assert.InDelta(t, 40.5, *weather.Comfort.HeatIndex, 0.5)
+		}
+		if assert.NotNil(t, weather.Comfort.DewPoint) {
+			assert.InDelta(t, 25.9, *weather.Comfort.DewPoint, 0.1)
+		}
+		assert.NotNil(t, weather.Comfort.Humidex)
+		assert.NotNil(t, weather.Comfort.ApparentTemperature)
+		assert.Nil(t, weather.Comfort.WindChill)
+		assert.Equal(t, weatherapi.Beaufort{Force: 3, Description: "gentle breeze"}, weather.Comfort.Beaufort)
+	})
+
+	t.Run("If the humidity is unknown, it should leave out the metrics that need it", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return &storage.WeatherData{
+					DataSource:  "weatherstack",
+					Temperature: -10,
+					WindSpeed:   20,
+					UpdatedDate: time.Now()}, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.NotContains(t, resp.Body, "humidity")
+		assert.NotContains(t, resp.Body, "dew_point")
+
+		weather := decodeWeatherResponse(t, resp.Body)
+		if assert.NotNil(t, weather.Comfort) && assert.NotNil(t, weather.Comfort.WindChill) {
+			assert.Equal(t, -17.9, *weather.Comfort.WindChill)
+		}
+	})
+}
+
+func TestGetWeatherCondition(t *testing.T) {
+
+	t.Run("It should map weatherstack's weather code and is_day onto the condition and icon", func(t *testing.T) {
+		var inserted *storage.WeatherData
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return nil, nil
+			},
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				inserted = in1
+				return true, nil
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				resp := &weatherstack.APIResponse{}
+				resp.Current.WeatherCode = 116
+				resp.Current.IsDay = "no"
+				return resp, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		if !assert.NotNil(t, inserted) {
+			t.Fatal()
+		}
+		assert.Equal(t, "partly_cloudy", inserted.Condition)
+		assert.True(t, inserted.Night)
+
+		weather := decodeWeatherResponse(t, resp.Body)
+		assert.Equal(t, "partly_cloudy", weather.Condition)
+		assert.Equal(t, "Partly cloudy", weather.ConditionText)
+		assert.Equal(t, "partly-cloudy-night", weather.Icon)
+	})
+
+	t.Run("It should map openweathermap's condition id, by day between sunrise and sunset", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return nil, nil
+			},
+			InsertWeatherDataFunc: func(ctx context.Context, in1 *storage.WeatherData) (bool, error) {
+				return true, nil
+			},
+		}
+
+		mockWeatherStackClient := &mocks.WeatherStackClientMock{
+			GetWeatherFunc: func(city string, language string) (*weatherstack.APIResponse, error) {
+				return nil, errors.New("weatherstack error")
+			},
+		}
+
+		mockOpenWeatherMapClient := &mocks.OpenWeatherMapClientMock{
+			GetWeatherFunc: func(city string, language string) (*openweathermap.APIResponse, error) {
+				resp := &openweathermap.APIResponse{}
+				err := json.Unmarshal([]byte(`{"weather":[{"id":800,"main":"Clear","description":"clear sky","icon":"01d"}],"dt":1621130173,"sys":{"sunrise":1621111254,"sunset":1621148542}}`), resp)
+				return resp, err
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(mockWeatherStackClient, mockOpenWeatherMapClient, mockStore)
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+
+		weather := decodeWeatherResponse(t, resp.Body)
+		assert.Equal(t, "clear", weather.Condition)
+		assert.Equal(t, "Sunny", weather.ConditionText)
+		assert.Equal(t, "clear-day", weather.Icon)
+	})
+
+	t.Run("If the condition is unknown, it should leave it out", func(t *testing.T) {
+		mockStore := &mocks.StoreMock{
+			GetLatestWeatherDataFunc: func(ctx context.Context, city string) (*storage.WeatherData, error) {
+				return &storage.WeatherData{DataSource: "weatherstack", Temperature: 1, WindSpeed: 2, UpdatedDate: time.Now()}, nil
+			},
+		}
+
+		mockWeatherService := weatherapi.NewWeatherService(&mocks.WeatherStackClientMock{}, &mocks.OpenWeatherMapClientMock{}, mockStore)
+
+		resp, err := mockWeatherService.GetWeather(context.Background(), events.APIGatewayProxyRequest{
+			QueryStringParameters: map[string]string{
+				"city": "Sydney",
+			},
+		})
+		if !assert.Nil(t, err) {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.NotContains(t, resp.Body, "condition")
+		assert.NotContains(t, resp.Body, "icon")
+	})
+}
+
+func decodeWeatherResponse(t *testing.T, body string) *weatherapi.GetWeatherResponse {
+	t.Helper()
+
+	weather := &weatherapi.GetWeatherResponse{}
+	err := json.Unmarshal([]byte(body), weather)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return weather
+}
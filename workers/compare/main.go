@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+
+	"github.com/TomSED/weather-api/internal/bootstrap"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+
+	logger := logrus.New()
+	logger.Out = os.Stdout
+
+	ws, err := bootstrap.NewWeatherService(logger)
+	if err != nil {
+		logger.Errorf("bootstrap.NewWeatherService error: %v", err)
+		os.Exit(1)
+	}
+
+	lambda.Start(ws.CompareWeather)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/TomSED/weather-api/internal/bootstrap"
+	"github.com/TomSED/weather-api/pkg/sensorfeed"
+	"github.com/sirupsen/logrus"
+)
+
+// Long running worker (not a lambda) that subscribes to site sensors over MQTT and stores their observations
+func main() {
+
+	logger := logrus.New()
+	logger.Out = os.Stdout
+
+	config, err := sensorfeed.LoadConfig(os.Getenv("MQTT_CONFIG"))
+	if err != nil {
+		logger.Errorf("sensorfeed.LoadConfig error: %v", err)
+		os.Exit(1)
+	}
+
+	store, err := bootstrap.NewStore(logger)
+	if err != nil {
+		logger.Errorf("bootstrap.NewStore error: %v", err)
+		os.Exit(1)
+	}
+
+	subscriber, err := sensorfeed.NewSubscriber(config, store)
+	if err != nil {
+		logger.Errorf("sensorfeed.NewSubscriber error: %v", err)
+		os.Exit(1)
+	}
+	subscriber.SetLogger(logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	err = subscriber.Run(ctx)
+	if err != nil {
+		logger.Errorf("subscriber.Run error: %v", err)
+		os.Exit(1)
+	}
+}
@@ -3,10 +3,7 @@ package main
 import (
 	"os"
 
-	weatherapi "github.com/TomSED/weather-api"
-	"github.com/TomSED/weather-api/pkg/openweathermap"
-	"github.com/TomSED/weather-api/pkg/postgres"
-	"github.com/TomSED/weather-api/pkg/weatherstack"
+	"github.com/TomSED/weather-api/internal/bootstrap"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/sirupsen/logrus"
 )
@@ -16,17 +13,11 @@ func main() {
 	logger := logrus.New()
 	logger.Out = os.Stdout
 
-	weatherStackClient := weatherstack.NewClient("", os.Getenv("WEATHERSTACK_API_KEY"))
-	openWeatherMapClient := openweathermap.NewClient("", os.Getenv("OPENWEATHERMAP_API_KEY"))
-
-	postgresClient, err := postgres.NewClient(os.Getenv("PG_HOST"), os.Getenv("PG_PORT"), os.Getenv("PG_USERNAME"), os.Getenv("PG_PASSWORD"), os.Getenv("PG_DB_NAME"))
+	ws, err := bootstrap.NewWeatherService(logger)
 	if err != nil {
-		logger.Errorf("postgres.NewClient error: %v", err)
+		logger.Errorf("bootstrap.NewWeatherService error: %v", err)
 		os.Exit(1)
 	}
 
-	ws := weatherapi.NewWeatherService(weatherStackClient, openWeatherMapClient, postgresClient)
-	ws.SetLogger(logger)
-
 	lambda.Start(ws.GetWeather)
 }